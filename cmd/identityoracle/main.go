@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/pkg/rpcnode"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/pkg/identity"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+)
+
+// Reference Identity Oracle
+//
+// Serves the identity oracle endpoints used by pkg/identity.HTTPClient, so oracle gated transfers
+//   and contract offers can be tested end to end without a third party service. It approves every
+//   receive to a registered xpub and doesn't verify identities, so it must not be used in
+//   production.
+
+// Config is the oracle configuration, read from ORACLE_ prefixed environment variables.
+type Config struct {
+	Listen          string `default:":8081" envconfig:"LISTEN"`
+	Key             string `envconfig:"KEY"`              // WIF private key that signs approvals
+	ContractAddress string `envconfig:"CONTRACT_ADDRESS"` // Address of the oracle's entity contract
+	Network         string `default:"mainnet" envconfig:"BITCOIN_CHAIN"`
+
+	Storage struct {
+		Bucket string `default:"standalone" envconfig:"STORAGE_BUCKET"`
+		Root   string `default:"./tmp/identity" envconfig:"STORAGE_ROOT"`
+	}
+
+	RpcNode struct {
+		Host       string `envconfig:"RPC_HOST"`
+		Username   string `envconfig:"RPC_USERNAME"`
+		Password   string `envconfig:"RPC_PASSWORD"`
+		MaxRetries int    `default:"10" envconfig:"RPC_MAX_RETRIES"`
+		RetryDelay int    `default:"2000" envconfig:"RPC_RETRY_DELAY"`
+	}
+}
+
+// storage adapts the db to the server, which expects its own not found error.
+type storage struct {
+	*db.DB
+}
+
+func (s storage) Fetch(ctx context.Context, key string) ([]byte, error) {
+	b, err := s.DB.Fetch(ctx, key)
+	if err == db.ErrNotFound {
+		return nil, errors.Wrap(identity.ErrNotFound, key)
+	}
+	return b, err
+}
+
+func main() {
+	ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+	var cfg Config
+	if err := envconfig.Process("ORACLE", &cfg); err != nil {
+		logger.Fatal(ctx, "Config : %s", err)
+	}
+
+	key, err := bitcoin.KeyFromStr(cfg.Key)
+	if err != nil {
+		logger.Fatal(ctx, "Key : %s", err)
+	}
+
+	var contractAddress bitcoin.RawAddress
+	if len(cfg.ContractAddress) == 0 {
+		// Default to the key's address when the oracle's contract uses the same key.
+		contractAddress, err = key.RawAddress()
+		if err != nil {
+			logger.Fatal(ctx, "Key address : %s", err)
+		}
+	} else {
+		address, err := bitcoin.DecodeAddress(cfg.ContractAddress)
+		if err != nil {
+			logger.Fatal(ctx, "Contract address : %s", err)
+		}
+		contractAddress = bitcoin.NewRawAddressFromAddress(address)
+	}
+
+	rpcNode, err := rpcnode.NewNode(&rpcnode.Config{
+		Host:       cfg.RpcNode.Host,
+		Username:   cfg.RpcNode.Username,
+		Password:   cfg.RpcNode.Password,
+		MaxRetries: cfg.RpcNode.MaxRetries,
+		RetryDelay: cfg.RpcNode.RetryDelay,
+	})
+	if err != nil {
+		logger.Fatal(ctx, "RPC node : %s", err)
+	}
+
+	dbConn, err := db.New(&db.StorageConfig{
+		Bucket: cfg.Storage.Bucket,
+		Root:   cfg.Storage.Root,
+	})
+	if err != nil {
+		logger.Fatal(ctx, "Storage : %s", err)
+	}
+	defer dbConn.Close()
+
+	server := &http.Server{
+		Addr:    cfg.Listen,
+		Handler: identity.NewServer(ctx, contractAddress, key, storage{dbConn}, rpcNode),
+	}
+
+	osSignals := make(chan os.Signal, 1)
+	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-osSignals
+		logger.Info(ctx, "Shutting down")
+		server.Close()
+	}()
+
+	logger.Info(ctx, "Identity oracle for %s on %s",
+		bitcoin.NewAddressFromRawAddress(contractAddress,
+			bitcoin.NetworkFromString(cfg.Network)).String(), cfg.Listen)
+	if err := server.ListenAndServe(); err != nil {
+		logger.Info(ctx, "Stopped : %s", err)
+	}
+}
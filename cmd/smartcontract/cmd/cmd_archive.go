@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/archive"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagKey       = "key"
+	FlagKeyOut    = "key-out"
+	FlagOverwrite = "overwrite"
+)
+
+var cmdExport = &cobra.Command{
+	Use:   "export <contract address> <file>",
+	Short: "Export a contract's stored data to an archive.",
+	Long:  "Export a contract's stored data to a versioned archive with checksums. Includes everything stored for the contract, such as its assets, holdings, votes, pending transfers, register snapshots and policies, along with its formation, relevant txs and the traces they started. Use --key to include the contract key, encrypted with a passphrase read from stdin. The daemon should be stopped first.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		address, err := bitcoin.DecodeAddress(args[0])
+		if err != nil {
+			return err
+		}
+		contractAddress := bitcoin.NewRawAddressFromAddress(address)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		a, err := archive.Export(ctx, masterDB, contractAddress, cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "export")
+		}
+
+		withKey, _ := c.Flags().GetBool(FlagKey)
+		if withKey {
+			key, err := bitcoin.KeyFromStr(cfg.Contract.PrivateKey)
+			if err != nil {
+				return errors.Wrap(err, "contract key")
+			}
+
+			passphrase, err := readPassphrase()
+			if err != nil {
+				return err
+			}
+
+			if err := a.SetKey(wallet.NewKey(key), passphrase); err != nil {
+				return errors.Wrap(err, "set key")
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := a.Write(&buf); err != nil {
+			return errors.Wrap(err, "write archive")
+		}
+
+		if err := ioutil.WriteFile(args[1], buf.Bytes(), 0600); err != nil {
+			return errors.Wrap(err, "write file")
+		}
+
+		fmt.Printf("Exported %d records for %s\n", len(a.Records),
+			bitcoin.NewAddressFromRawAddress(contractAddress, net).String())
+		return nil
+	},
+}
+
+var cmdImport = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a contract archive into storage.",
+	Long:  "Import a contract archive created by export into storage, verifying checksums before and after writing. Only records belonging to the contract are accepted, and its traces are merged into the existing tracer. Fails if the contract is already in storage unless --overwrite is specified. Use --key-out to decrypt the contract key, with a passphrase read from stdin, and write it to a file.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return errors.Wrap(err, "open archive")
+		}
+		a, err := archive.Read(file)
+		file.Close()
+		if err != nil {
+			return errors.Wrap(err, "read archive")
+		}
+
+		// Decrypt the key before changing storage so a wrong passphrase doesn't leave a partial
+		//   import.
+		keyOut, _ := c.Flags().GetString(FlagKeyOut)
+		var keyText string
+		if len(keyOut) > 0 {
+			passphrase, err := readPassphrase()
+			if err != nil {
+				return err
+			}
+
+			key, err := a.Key(passphrase, net)
+			if err != nil {
+				return errors.Wrap(err, "decrypt key")
+			}
+			keyText = key.Key.String()
+		}
+
+		overwrite, _ := c.Flags().GetBool(FlagOverwrite)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		if err := archive.Import(ctx, masterDB, a, overwrite); err != nil {
+			return errors.Wrap(err, "import")
+		}
+
+		if len(keyOut) > 0 {
+			if err := ioutil.WriteFile(keyOut, []byte(keyText+"\n"), 0600); err != nil {
+				return errors.Wrap(err, "write key")
+			}
+		}
+
+		fmt.Printf("Imported %d records for %s\n", len(a.Records),
+			bitcoin.NewAddressFromRawAddress(a.ContractAddress, net).String())
+		return nil
+	},
+}
+
+// stdinReader is shared so that consecutive reads don't lose buffered input.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readPassphrase reads a passphrase line from stdin.
+func readPassphrase() (string, error) {
+	return readPassphraseWithPrompt("Passphrase: ")
+}
+
+// readPassphraseWithPrompt writes the prompt to stderr and reads a passphrase line from stdin.
+func readPassphraseWithPrompt(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := stdinReader.ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "read passphrase")
+	}
+
+	passphrase = strings.TrimRight(passphrase, "\r\n")
+	if len(passphrase) == 0 {
+		return "", errors.New("Empty passphrase")
+	}
+	return passphrase, nil
+}
+
+func init() {
+	cmdExport.Flags().Bool(FlagKey, false, "include the contract key encrypted with a passphrase")
+	cmdImport.Flags().String(FlagKeyOut, "", "file to write the decrypted contract key to")
+	cmdImport.Flags().Bool(FlagOverwrite, false, "overwrite a contract already in storage")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/audit"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var cmdAudit = &cobra.Command{
+	Use:   "audit <contract address>",
+	Short: "Check the contract state for consistency.",
+	Long:  "Check the contract state for consistency. Verifies holdings add up to token quantities, holding statuses and pending transfers reference stored txs, asset codes match stored assets, and open votes have not expired. Violations are printed with suggested repairs.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+
+		address, err := bitcoin.DecodeAddress(args[0])
+		if err != nil {
+			return err
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		ct, err := contract.Fetch(ctx, masterDB, bitcoin.NewRawAddressFromAddress(address),
+			cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "fetch contract")
+		}
+
+		violations, err := audit.Audit(ctx, masterDB, ct, cfg.Contract.IsTest,
+			protocol.CurrentTimestamp())
+		if err != nil {
+			return errors.Wrap(err, "audit")
+		}
+
+		if len(violations) == 0 {
+			fmt.Printf("No violations found\n")
+			return nil
+		}
+
+		for _, v := range violations {
+			fmt.Printf("%s\n", v.String())
+		}
+
+		return fmt.Errorf("%d violations found", len(violations))
+	},
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/json"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/revenue"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagPeriod  = "period"
+	FlagFrom    = "from"
+	FlagTo      = "to"
+	FlagEntries = "entries"
+)
+
+var cmdFees = &cobra.Command{
+	Use:   "fees <contract address>",
+	Short: "Report the fee revenue of a contract.",
+	Long:  "Report the contract fees paid to the fee address and the mining fees paid from the contract's UTXOs, aggregated by day, month, year or all. Use --from and --to (RFC3339) to limit the time range and --entries to list every response instead.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		format, _ := c.Flags().GetString(FlagFormat)
+		format = strings.ToLower(format)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("Unsupported format : %s", format)
+		}
+
+		period, _ := c.Flags().GetString(FlagPeriod)
+		listEntries, _ := c.Flags().GetBool(FlagEntries)
+
+		var start, end protocol.Timestamp
+		fromText, _ := c.Flags().GetString(FlagFrom)
+		if len(fromText) > 0 {
+			t, err := time.Parse(time.RFC3339, fromText)
+			if err != nil {
+				return errors.Wrap(err, "parse from")
+			}
+			start = protocol.NewTimestamp(uint64(t.UnixNano()))
+		}
+		toText, _ := c.Flags().GetString(FlagTo)
+		if len(toText) > 0 {
+			t, err := time.Parse(time.RFC3339, toText)
+			if err != nil {
+				return errors.Wrap(err, "parse to")
+			}
+			end = protocol.NewTimestamp(uint64(t.UnixNano()))
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		address, err := bitcoin.DecodeAddress(args[0])
+		if err != nil {
+			return err
+		}
+		contractAddress := bitcoin.NewRawAddressFromAddress(address)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		entries, err := revenue.Fetch(ctx, masterDB, contractAddress, start, end)
+		if err != nil {
+			return errors.Wrap(err, "fetch entries")
+		}
+
+		if listEntries {
+			if format == "json" {
+				return printJSON(entries)
+			}
+			return revenue.WriteEntriesCSV(os.Stdout, entries, net)
+		}
+
+		report, err := revenue.NewReport(contractAddress, period, start, end, entries)
+		if err != nil {
+			return errors.Wrap(err, "build report")
+		}
+
+		if format == "json" {
+			return printJSON(report)
+		}
+		return report.WriteCSV(os.Stdout)
+	},
+}
+
+func printJSON(o interface{}) error {
+	js, err := json.MarshalIndent(o, "", "    ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", js)
+	return nil
+}
+
+func init() {
+	cmdFees.Flags().String(FlagFormat, "csv", "output format (csv or json)")
+	cmdFees.Flags().String(FlagPeriod, revenue.PeriodMonth, "aggregation period (day, month, year or all)")
+	cmdFees.Flags().String(FlagFrom, "", "only include responses at or after this time (RFC3339)")
+	cmdFees.Flags().String(FlagTo, "", "only include responses before this time (RFC3339)")
+	cmdFees.Flags().Bool(FlagEntries, false, "list ledger entries instead of totals")
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagQuorum     = "quorum"
+	FlagAutoApply  = "auto-apply"
+	FlagEarlyClose = "early-close"
+)
+
+var cmdGovernance = &cobra.Command{
+	Use:   "governance-policy <contract address>",
+	Short: "Show or change the governance policy of a contract.",
+	Long:  "Show or change the operator managed policy for votes. --quorum sets the percentage of the vote's tokens that must be voted for a vote to have a result, for each voting system in order, like 30,0,50, with 0 for no quorum. Votes below quorum complete without a result and are recorded as having no quorum. --auto-apply sets whether the amendments of passed proposals are applied without an amendment request, for each voting system in order, like true,false. The proposal tx must fund the amendment with a third output to the contract. --early-close sets whether absolute votes are finalized as soon as the ballots not yet cast can no longer change the result, for each voting system in order, like true,false. The daemon applies changes to votes created after them.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		contractAddress, err := decodeNetAddress(args[0], net)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		ct, err := contract.Retrieve(ctx, masterDB, contractAddress, cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "retrieve contract")
+		}
+
+		policy, err := contract.FetchGovernancePolicy(ctx, masterDB, contractAddress)
+		if err != nil {
+			return errors.Wrap(err, "fetch policy")
+		}
+
+		if c.Flags().Changed(FlagQuorum) {
+			quorums, _ := c.Flags().GetUintSlice(FlagQuorum)
+			if len(quorums) > len(ct.VotingSystems) {
+				return fmt.Errorf("Contract only has %d voting systems", len(ct.VotingSystems))
+			}
+
+			policy.QuorumPercentages = nil
+			for _, quorum := range quorums {
+				if quorum > 100 {
+					return fmt.Errorf("Quorum percentage out of range : %d", quorum)
+				}
+				policy.QuorumPercentages = append(policy.QuorumPercentages, uint32(quorum))
+			}
+		}
+
+		if c.Flags().Changed(FlagAutoApply) {
+			autoApply, _ := c.Flags().GetBoolSlice(FlagAutoApply)
+			if len(autoApply) > len(ct.VotingSystems) {
+				return fmt.Errorf("Contract only has %d voting systems", len(ct.VotingSystems))
+			}
+			policy.AutoApply = autoApply
+		}
+
+		if c.Flags().Changed(FlagEarlyClose) {
+			earlyClose, _ := c.Flags().GetBoolSlice(FlagEarlyClose)
+			if len(earlyClose) > len(ct.VotingSystems) {
+				return fmt.Errorf("Contract only has %d voting systems", len(ct.VotingSystems))
+			}
+			policy.EarlyClose = earlyClose
+		}
+
+		if c.Flags().Changed(FlagQuorum) || c.Flags().Changed(FlagAutoApply) ||
+			c.Flags().Changed(FlagEarlyClose) {
+			policy.UpdatedAt = protocol.CurrentTimestamp()
+			if err := contract.SaveGovernancePolicy(ctx, masterDB, contractAddress,
+				policy); err != nil {
+				return errors.Wrap(err, "save policy")
+			}
+		}
+
+		for i, votingSystem := range ct.VotingSystems {
+			quorum := "no quorum"
+			if q := policy.Quorum(uint32(i)); q != 0 {
+				quorum = fmt.Sprintf("quorum %d%%", q)
+			}
+			apply := "amendments applied by request"
+			if policy.AutoApplies(uint32(i)) {
+				apply = "amendments applied automatically"
+			}
+			closes := "closes at cut off"
+			if policy.ClosesEarly(uint32(i)) {
+				closes = "closes early when decided"
+			}
+			fmt.Printf("Voting system %d (%s) : %s, %s, %s\n", i, votingSystem.Name, quorum, apply,
+				closes)
+		}
+		return nil
+	},
+}
+
+func init() {
+	cmdGovernance.Flags().UintSlice(FlagQuorum, nil, "comma separated quorum percentages for each voting system")
+	cmdGovernance.Flags().BoolSlice(FlagAutoApply, nil, "comma separated flags to apply passed amendments automatically for each voting system")
+	cmdGovernance.Flags().BoolSlice(FlagEarlyClose, nil, "comma separated flags to close absolute votes early when decided for each voting system")
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/enforcement"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagStatus  = "status"
+	FlagAddress = "address"
+)
+
+var cmdOrders = &cobra.Command{
+	Use:   "orders <contract address>",
+	Short: "List the enforcement orders of a contract.",
+	Long:  "List every enforcement order accepted by a contract with its authority, supporting evidence hash, deposit address, affected addresses and quantities, status (pending, applied or thawed) and the txids of the responses that applied it. Use --status to only list orders with a status and --address to only list orders that affect an address.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		format, _ := c.Flags().GetString(FlagFormat)
+		format = strings.ToLower(format)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("Unsupported format : %s", format)
+		}
+
+		status, _ := c.Flags().GetString(FlagStatus)
+		status = strings.ToLower(status)
+		switch status {
+		case "", enforcement.StatusPending, enforcement.StatusApplied, enforcement.StatusThawed:
+		default:
+			return fmt.Errorf("Unsupported status : %s", status)
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		contractAddress, err := decodeNetAddress(args[0], net)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+
+		var address bitcoin.RawAddress
+		addressText, _ := c.Flags().GetString(FlagAddress)
+		if len(addressText) > 0 {
+			address, err = decodeNetAddress(addressText, net)
+			if err != nil {
+				return errors.Wrap(err, "address")
+			}
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		orders, err := enforcement.List(ctx, masterDB, contractAddress)
+		if err != nil {
+			return errors.Wrap(err, "list orders")
+		}
+		orders = enforcement.Filter(orders, status, address)
+
+		if format == "json" {
+			return printJSON(orders)
+		}
+		return enforcement.WriteCSV(os.Stdout, orders, net)
+	},
+}
+
+func init() {
+	cmdOrders.Flags().String(FlagFormat, "csv", "output format (csv or json)")
+	cmdOrders.Flags().String(FlagStatus, "", "only list orders with this status (pending, applied or thawed)")
+	cmdOrders.Flags().String(FlagAddress, "", "only list orders that affect this address")
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagTimeout       = "timeout"
+	FlagRefuseMulti   = "refuse-multi"
+	FlagAllow         = "allow"
+	FlagDeny          = "deny"
+	FlagAllowEntity   = "allow-entity"
+	FlagDenyEntity    = "deny-entity"
+	FlagAllowOperator = "allow-operator"
+	FlagDenyOperator  = "deny-operator"
+)
+
+var cmdPolicy = &cobra.Command{
+	Use:   "transfer-policy <contract address>",
+	Short: "Show or change the transfer policy of a contract.",
+	Long:  "Show or change the operator managed policy for transfers involving other contracts. --timeout sets the request timeout, like 2m, with 0 for the node's default. --refuse-multi rejects all multi-contract transfers. --allow, --allow-entity and --allow-operator set the only counterparties permitted, with empty values permitting any that aren't denied. Entities match the issuer LEI, name or domain name in the counterparty's contract formation. --deny, --deny-entity and --deny-operator set counterparties that are never permitted. The daemon applies changes to the next transfer.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		contractAddress, err := decodeNetAddress(args[0], net)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		policy, err := contract.FetchTransferPolicy(ctx, masterDB, contractAddress)
+		if err != nil {
+			return errors.Wrap(err, "fetch policy")
+		}
+
+		modified := false
+		if c.Flags().Changed(FlagTimeout) {
+			timeout, _ := c.Flags().GetDuration(FlagTimeout)
+			if timeout < 0 {
+				return errors.New("Negative timeout")
+			}
+			policy.RequestTimeout = uint64(timeout)
+			modified = true
+		}
+
+		if c.Flags().Changed(FlagRefuseMulti) {
+			policy.RefuseMultiContract, _ = c.Flags().GetBool(FlagRefuseMulti)
+			modified = true
+		}
+
+		addressLists := []struct {
+			flag string
+			list *[]bitcoin.RawAddress
+		}{
+			{FlagAllow, &policy.AllowedContracts},
+			{FlagDeny, &policy.DeniedContracts},
+			{FlagAllowOperator, &policy.AllowedOperators},
+			{FlagDenyOperator, &policy.DeniedOperators},
+		}
+		for _, addressList := range addressLists {
+			if !c.Flags().Changed(addressList.flag) {
+				continue
+			}
+			values, _ := c.Flags().GetStringSlice(addressList.flag)
+			*addressList.list = nil
+			for _, value := range values {
+				if len(value) == 0 {
+					continue
+				}
+				ra, err := decodeNetAddress(value, net)
+				if err != nil {
+					return errors.Wrap(err, addressList.flag)
+				}
+				*addressList.list = append(*addressList.list, ra)
+			}
+			modified = true
+		}
+
+		entityLists := []struct {
+			flag string
+			list *[]string
+		}{
+			{FlagAllowEntity, &policy.AllowedEntities},
+			{FlagDenyEntity, &policy.DeniedEntities},
+		}
+		for _, entityList := range entityLists {
+			if !c.Flags().Changed(entityList.flag) {
+				continue
+			}
+			values, _ := c.Flags().GetStringSlice(entityList.flag)
+			*entityList.list = nil
+			for _, value := range values {
+				if len(value) > 0 {
+					*entityList.list = append(*entityList.list, value)
+				}
+			}
+			modified = true
+		}
+
+		if modified {
+			policy.UpdatedAt = protocol.CurrentTimestamp()
+			if err := contract.SaveTransferPolicy(ctx, masterDB, contractAddress,
+				policy); err != nil {
+				return errors.Wrap(err, "save policy")
+			}
+		}
+
+		if policy.RequestTimeout == 0 {
+			fmt.Printf("Request timeout : default (%s)\n",
+				time.Duration(cfg.Contract.RequestTimeout))
+		} else {
+			fmt.Printf("Request timeout : %s\n", time.Duration(policy.RequestTimeout))
+		}
+		fmt.Printf("Refuse multi-contract : %t\n", policy.RefuseMultiContract)
+		printAddresses("Allowed contracts", policy.AllowedContracts, net)
+		printStrings("Allowed entities", policy.AllowedEntities)
+		printAddresses("Allowed operators", policy.AllowedOperators, net)
+		printAddresses("Denied contracts", policy.DeniedContracts, net)
+		printStrings("Denied entities", policy.DeniedEntities)
+		printAddresses("Denied operators", policy.DeniedOperators, net)
+		return nil
+	},
+}
+
+var cmdInteractions = &cobra.Command{
+	Use:   "interactions <contract address>",
+	Short: "List the interactions of a contract with other contracts.",
+	Long:  "List every check of another contract involved in a transfer against the transfer policy, with whether it was permitted. Use --from and --to (RFC3339) to limit the time range.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		var start, end protocol.Timestamp
+		fromText, _ := c.Flags().GetString(FlagFrom)
+		if len(fromText) > 0 {
+			t, err := time.Parse(time.RFC3339, fromText)
+			if err != nil {
+				return errors.Wrap(err, "parse from")
+			}
+			start = protocol.NewTimestamp(uint64(t.UnixNano()))
+		}
+		toText, _ := c.Flags().GetString(FlagTo)
+		if len(toText) > 0 {
+			t, err := time.Parse(time.RFC3339, toText)
+			if err != nil {
+				return errors.Wrap(err, "parse to")
+			}
+			end = protocol.NewTimestamp(uint64(t.UnixNano()))
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		contractAddress, err := decodeNetAddress(args[0], net)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		interactions, err := contract.FetchInteractions(ctx, masterDB, contractAddress, start, end)
+		if err != nil {
+			return errors.Wrap(err, "fetch interactions")
+		}
+
+		for _, interaction := range interactions {
+			result := "permitted"
+			if !interaction.Permitted {
+				result = "refused : " + interaction.Reason
+			}
+			fmt.Printf("%s %s %s %s %s\n", interaction.Timestamp.String(), interaction.Kind,
+				bitcoin.NewAddressFromRawAddress(interaction.Counterparty, net).String(),
+				interaction.TxId.String(), result)
+		}
+		return nil
+	},
+}
+
+func printAddresses(title string, list []bitcoin.RawAddress, net bitcoin.Network) {
+	if len(list) == 0 {
+		fmt.Printf("%s : none\n", title)
+		return
+	}
+
+	fmt.Printf("%s :\n", title)
+	for _, ra := range list {
+		fmt.Printf("  %s\n", bitcoin.NewAddressFromRawAddress(ra, net).String())
+	}
+}
+
+func printStrings(title string, list []string) {
+	if len(list) == 0 {
+		fmt.Printf("%s : none\n", title)
+		return
+	}
+
+	fmt.Printf("%s :\n", title)
+	for _, s := range list {
+		fmt.Printf("  %s\n", s)
+	}
+}
+
+// decodeNetAddress decodes an address and checks that it is for the network.
+func decodeNetAddress(s string, net bitcoin.Network) (bitcoin.RawAddress, error) {
+	address, err := bitcoin.DecodeAddress(s)
+	if err != nil {
+		return bitcoin.RawAddress{}, err
+	}
+	if !bitcoin.DecodeNetMatches(address.Network(), net) {
+		return bitcoin.RawAddress{}, fmt.Errorf("Wrong address encoding network : %s", s)
+	}
+
+	return bitcoin.NewRawAddressFromAddress(address), nil
+}
+
+func init() {
+	cmdPolicy.Flags().Duration(FlagTimeout, 0, "request timeout for other contracts, 0 for the node's default")
+	cmdPolicy.Flags().Bool(FlagRefuseMulti, false, "reject transfers involving other contracts")
+	cmdPolicy.Flags().StringSlice(FlagAllow, nil, "comma separated contract addresses that are permitted")
+	cmdPolicy.Flags().StringSlice(FlagDeny, nil, "comma separated contract addresses that are never permitted")
+	cmdPolicy.Flags().StringSlice(FlagAllowEntity, nil, "comma separated issuer LEIs, names or domain names that are permitted")
+	cmdPolicy.Flags().StringSlice(FlagDenyEntity, nil, "comma separated issuer LEIs, names or domain names that are never permitted")
+	cmdPolicy.Flags().StringSlice(FlagAllowOperator, nil, "comma separated operator addresses that are permitted")
+	cmdPolicy.Flags().StringSlice(FlagDenyOperator, nil, "comma separated operator addresses that are never permitted")
+
+	cmdInteractions.Flags().String(FlagFrom, "", "only include interactions at or after this time (RFC3339)")
+	cmdInteractions.Flags().String(FlagTo, "", "only include interactions before this time (RFC3339)")
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/rebuild"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagTxs   = "txs"
+	FlagOut   = "out"
+	FlagApply = "apply"
+)
+
+var cmdRebuild = &cobra.Command{
+	Use:   "rebuild <contract address>",
+	Short: "Rebuild contract state from on-chain history.",
+	Long:  "Rebuild contract, asset, holding and vote state by replaying the contract's responses in the order they were created, then show the differences from current storage. Txs are read from storage unless --txs specifies a file of hex raw txs, one per line, which must include the requests and the txs they spend. The rebuilt state is written to standalone storage at --out, or a temporary directory, and --apply writes it over current storage.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		nodeConfig := bootstrap.NewNodeConfig(ctx, cfg)
+
+		address, err := bitcoin.DecodeAddress(args[0])
+		if err != nil {
+			return err
+		}
+		contractAddress := bitcoin.NewRawAddressFromAddress(address)
+
+		masterWallet := wallet.New()
+		if err := masterWallet.Register(cfg.Contract.PrivateKey, nodeConfig.Net); err != nil {
+			return errors.Wrap(err, "register key")
+		}
+		if _, err := masterWallet.Get(contractAddress); err != nil {
+			return errors.New("Contract key not configured")
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		var txs []*inspector.Transaction
+		txsPath, _ := c.Flags().GetString(FlagTxs)
+		if len(txsPath) > 0 {
+			file, err := os.Open(txsPath)
+			if err != nil {
+				return errors.Wrap(err, "open txs")
+			}
+			txs, err = rebuild.ReadRawTxs(ctx, file, nodeConfig.IsTest)
+			file.Close()
+			if err != nil {
+				return errors.Wrap(err, "read txs")
+			}
+		} else {
+			txs, err = transactions.List(ctx, masterDB, nodeConfig.IsTest)
+			if err != nil {
+				return errors.Wrap(err, "list txs")
+			}
+		}
+
+		out, _ := c.Flags().GetString(FlagOut)
+		if len(out) == 0 {
+			out, err = ioutil.TempDir("", "rebuild")
+			if err != nil {
+				return errors.Wrap(err, "create temp dir")
+			}
+			defer os.RemoveAll(out)
+		}
+
+		rebuildDB, err := db.New(&db.StorageConfig{
+			Bucket: "standalone",
+			Root:   out,
+		})
+		if err != nil {
+			return errors.Wrap(err, "create rebuild storage")
+		}
+
+		count, err := rebuild.Rebuild(ctx, rebuildDB, nodeConfig, masterWallet, masterWallet,
+			contractAddress, txs)
+		if err != nil {
+			return errors.Wrap(err, "rebuild")
+		}
+		fmt.Printf("Replayed %d responses from %d txs\n", count, len(txs))
+
+		current, err := rebuild.LoadState(ctx, masterDB, contractAddress, nodeConfig.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "load current state")
+		}
+
+		rebuilt, err := rebuild.LoadState(ctx, rebuildDB, contractAddress, nodeConfig.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "load rebuilt state")
+		}
+
+		diffs, err := rebuild.Diff(current, rebuilt)
+		if err != nil {
+			return errors.Wrap(err, "diff")
+		}
+
+		if len(diffs) == 0 {
+			fmt.Printf("Rebuilt state matches current storage\n")
+		} else {
+			for _, diff := range diffs {
+				fmt.Printf("%s\n", diff)
+			}
+		}
+
+		apply, _ := c.Flags().GetBool(FlagApply)
+		if apply {
+			if err := rebuilt.Save(ctx, masterDB, nodeConfig.IsTest); err != nil {
+				return errors.Wrap(err, "apply")
+			}
+			fmt.Printf("Rebuilt state written to storage\n")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	cmdRebuild.Flags().String(FlagTxs, "", "file of hex raw txs to replay instead of stored txs")
+	cmdRebuild.Flags().String(FlagOut, "", "directory to write the rebuilt storage to")
+	cmdRebuild.Flags().Bool(FlagApply, false, "write the rebuilt state over current storage")
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/json"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/pkg/identity"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagFormat   = "format"
+	FlagAt       = "at"
+	FlagSnapshot = "snapshot"
+	FlagNames    = "names"
+)
+
+var cmdRegister = &cobra.Command{
+	Use:   "register <contract address> [asset id]",
+	Short: "Export the holder register (cap table) of a contract's assets.",
+	Long:  "Export the holder register (cap table) of a contract's assets as CSV or JSON. Includes every holder's finalized, pending and frozen balance and percentage of supply. Use --snapshot to save the current register as a snapshot, and --at to export the latest snapshot saved at or before a time (RFC3339). --at only works for times after a register was saved with --snapshot, since past balances aren't kept otherwise. --names asks the contract's identity oracles for the entity name of each holder through the /identity/entity endpoint. That endpoint is served by this repo's reference oracle (cmd/identityoracle) but not by all oracles, so an oracle that doesn't serve it is skipped and names are left empty.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 1 && len(args) != 2 {
+			return errors.New("Incorrect argument count")
+		}
+
+		format, _ := c.Flags().GetString(FlagFormat)
+		format = strings.ToLower(format)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("Unsupported format : %s", format)
+		}
+
+		at := protocol.CurrentTimestamp()
+		useSnapshot := false
+		atText, _ := c.Flags().GetString(FlagAt)
+		if len(atText) > 0 {
+			t, err := time.Parse(time.RFC3339, atText)
+			if err != nil {
+				return errors.Wrap(err, "parse time")
+			}
+			at = protocol.NewTimestamp(uint64(t.UnixNano()))
+			useSnapshot = true
+		}
+
+		saveSnapshot, _ := c.Flags().GetBool(FlagSnapshot)
+		withNames, _ := c.Flags().GetBool(FlagNames)
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		address, err := bitcoin.DecodeAddress(args[0])
+		if err != nil {
+			return err
+		}
+		contractAddress := bitcoin.NewRawAddressFromAddress(address)
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		ct, err := contract.Fetch(ctx, masterDB, contractAddress, cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "fetch contract")
+		}
+
+		assetCodes := ct.AssetCodes
+		if len(args) == 2 {
+			_, assetCode, err := protocol.DecodeAssetID(args[1])
+			if err != nil {
+				return errors.Wrap(err, "decode asset id")
+			}
+			assetCodes = []*protocol.AssetCode{&assetCode}
+		}
+
+		registers := make([]*holdings.Register, 0, len(assetCodes))
+		for _, assetCode := range assetCodes {
+			var r *holdings.Register
+			if useSnapshot {
+				r, err = holdings.FetchRegisterSnapshot(ctx, masterDB, contractAddress, assetCode,
+					at)
+				if err != nil {
+					if errors.Cause(err) == holdings.ErrSnapshotNotFound {
+						return fmt.Errorf("No snapshot saved at or before %s. Save snapshots with --%s",
+							atText, FlagSnapshot)
+					}
+					return errors.Wrap(err, "fetch snapshot")
+				}
+			} else {
+				as, err := asset.Fetch(ctx, masterDB, contractAddress, assetCode)
+				if err != nil {
+					return errors.Wrap(err, "fetch asset")
+				}
+
+				r, err = holdings.BuildRegister(ctx, masterDB, contractAddress, as, at)
+				if err != nil {
+					return errors.Wrap(err, "build register")
+				}
+
+				if saveSnapshot {
+					if err := holdings.SaveRegisterSnapshot(ctx, masterDB, r); err != nil {
+						return errors.Wrap(err, "save snapshot")
+					}
+				}
+			}
+
+			if withNames {
+				if err := r.ApplyEntityNames(ctx, newOracleNameLookup(ct)); err != nil {
+					return errors.Wrap(err, "entity names")
+				}
+			}
+
+			registers = append(registers, r)
+		}
+
+		if format == "json" {
+			js, err := json.MarshalIndent(registers, "", "    ")
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s\n", js)
+			return nil
+		}
+
+		for _, r := range registers {
+			fmt.Printf("# Asset %s\n", protocol.AssetID(r.AssetType, *r.AssetCode))
+			if err := r.WriteCSV(os.Stdout, net); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	},
+}
+
+// oracleNameLookup resolves entity names through a contract's identity oracles. Entity lookup
+//   isn't part of every oracle's API, so an oracle that fails a lookup isn't asked again.
+type oracleNameLookup struct {
+	clients []*identity.HTTPClient
+}
+
+func newOracleNameLookup(ct *state.Contract) *oracleNameLookup {
+	result := &oracleNameLookup{}
+	for _, oracle := range ct.FullOracles {
+		if len(oracle.URL) == 0 {
+			continue // Not an identity oracle
+		}
+
+		url := oracle.URL
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			url = "https://" + url
+		}
+
+		client, err := identity.NewHTTPClient(oracle.Address, url, oracle.PublicKey)
+		if err != nil {
+			continue
+		}
+		result.clients = append(result.clients, client)
+	}
+	return result
+}
+
+// EntityName returns the first name provided by any of the contract's identity oracles.
+func (l *oracleNameLookup) EntityName(ctx context.Context,
+	address bitcoin.RawAddress) (string, error) {
+
+	for i := 0; i < len(l.clients); {
+		client := l.clients[i]
+		name, err := client.EntityName(ctx, address)
+		if err != nil {
+			logger.Warn(ctx, "Skipping entity names from oracle %s : %s", client.URL, err)
+			l.clients = append(l.clients[:i], l.clients[i+1:]...)
+			continue
+		}
+		if len(name) > 0 {
+			return name, nil
+		}
+		i++
+	}
+
+	return "", nil
+}
+
+func init() {
+	cmdRegister.Flags().String(FlagFormat, "csv", "output format (csv or json)")
+	cmdRegister.Flags().String(FlagAt, "", "export the latest snapshot at or before this time (RFC3339)")
+	cmdRegister.Flags().Bool(FlagSnapshot, false, "save the current register as a snapshot")
+	cmdRegister.Flags().Bool(FlagNames, false, "include entity names from identity oracles that serve /identity/entity")
+}
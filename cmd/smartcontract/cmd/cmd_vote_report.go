@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagVerify = "verify"
+	FlagHolder = "holder"
+)
+
+var cmdVoteReport = &cobra.Command{
+	Use:   "vote-report <contract address> <vote txid>",
+	Short: "Print the audit report of a vote.",
+	Long:  "Print every eligible voter of a vote with their quantity, ballot cast txid, choices and counted score for each option, followed by the merkle root over the ballots. The root is recorded with the vote when it completes. --holder prints the merkle proof that a holder's ballot is included in the root. --verify recomputes the tally from the stored ballot cast txs and reports any ballot or result that doesn't match.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			return errors.New("Incorrect argument count")
+		}
+
+		format, _ := c.Flags().GetString(FlagFormat)
+		format = strings.ToLower(format)
+		if format != "csv" && format != "json" {
+			return fmt.Errorf("Unsupported format : %s", format)
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		net := bitcoin.NetworkFromString(cfg.Bitcoin.Network)
+
+		contractAddress, err := decodeNetAddress(args[0], net)
+		if err != nil {
+			return errors.Wrap(err, "contract address")
+		}
+
+		voteHash, err := bitcoin.NewHash32FromStr(args[1])
+		if err != nil {
+			return errors.Wrap(err, "vote txid")
+		}
+
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+
+		ct, err := contract.Fetch(ctx, masterDB, contractAddress, cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "fetch contract")
+		}
+
+		vt, err := vote.Fetch(ctx, masterDB, contractAddress,
+			protocol.TxIdFromBytes(voteHash[:]))
+		if err != nil {
+			return errors.Wrap(err, "fetch vote")
+		}
+
+		proposalHash, err := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "proposal txid")
+		}
+		proposalTx, err := transactions.GetTx(ctx, masterDB, proposalHash, cfg.Contract.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "get proposal tx")
+		}
+		proposal, ok := proposalTx.MsgProto.(*actions.Proposal)
+		if !ok {
+			return errors.New("Proposal tx invalid for vote")
+		}
+
+		if int(proposal.VoteSystem) >= len(ct.VotingSystems) {
+			return fmt.Errorf("Voting system not found : %d", proposal.VoteSystem)
+		}
+		votingSystem := ct.VotingSystems[proposal.VoteSystem]
+
+		report, err := vote.NewAuditReport(vt, proposal, votingSystem)
+		if err != nil {
+			return errors.Wrap(err, "build report")
+		}
+
+		holderText, _ := c.Flags().GetString(FlagHolder)
+		if len(holderText) > 0 {
+			holder, err := decodeNetAddress(holderText, net)
+			if err != nil {
+				return errors.Wrap(err, "holder address")
+			}
+
+			index, proof, err := report.Proof(holder)
+			if err != nil {
+				return errors.Wrap(err, "ballot proof")
+			}
+
+			entry := report.Entries[index]
+			fmt.Printf("Ballot %d : quantity %d, vote \"%s\"\n", index, entry.Quantity, entry.Vote)
+			fmt.Printf("Leaf : %s\n", entry.Leaf.String())
+			for _, hash := range proof {
+				fmt.Printf("Proof : %s\n", hash.String())
+			}
+			fmt.Printf("Root : %s\n", report.BallotRoot.String())
+			if !vote.VerifyMerkleProof(entry.Leaf, index, proof, report.BallotRoot) {
+				return errors.New("Proof doesn't verify")
+			}
+			return nil
+		}
+
+		if verify, _ := c.Flags().GetBool(FlagVerify); verify {
+			mismatches, err := vote.VerifyAudit(ctx, masterDB, vt, proposal, votingSystem,
+				cfg.Contract.IsTest)
+			if err != nil {
+				return errors.Wrap(err, "verify")
+			}
+
+			if len(mismatches) == 0 {
+				fmt.Printf("Tally verified from %d ballots\n", len(report.Entries))
+				return nil
+			}
+
+			for _, mismatch := range mismatches {
+				fmt.Printf("%s\n", mismatch)
+			}
+			return fmt.Errorf("%d mismatches found", len(mismatches))
+		}
+
+		if format == "json" {
+			return printJSON(report)
+		}
+		return report.WriteCSV(os.Stdout, net)
+	},
+}
+
+func init() {
+	cmdVoteReport.Flags().String(FlagFormat, "csv", "output format (csv or json)")
+	cmdVoteReport.Flags().Bool(FlagVerify, false, "recompute the tally from the stored ballot cast txs")
+	cmdVoteReport.Flags().String(FlagHolder, "", "print the merkle proof of a holder's ballot")
+}
@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+const (
+	FlagKeyFile   = "key-file"
+	FlagPlainText = "plain-text"
+)
+
+var cmdWalletRekey = &cobra.Command{
+	Use:   "wallet-rekey",
+	Short: "Re-encrypt the stored wallet.",
+	Long:  "Decrypt the stored wallet with the configured NODE_WALLET_PASSPHRASE or NODE_WALLET_KEY_FILE, or read it as plain text if neither is set, then encrypt it again with a new passphrase read from stdin, or with --key-file. Use --plain-text to remove encryption. The daemon should be stopped first and restarted with the new configuration.",
+	RunE: func(c *cobra.Command, args []string) error {
+		if len(args) != 0 {
+			return errors.New("Incorrect argument count")
+		}
+
+		ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+		cfg := bootstrap.NewConfigFromEnv(ctx)
+		masterDB := bootstrap.NewMasterDB(ctx, cfg)
+		current := bootstrap.NewWalletProtection(ctx, cfg)
+
+		data, err := masterDB.Fetch(ctx, listeners.WalletStorageKey)
+		if err != nil {
+			return errors.Wrap(err, "fetch wallet")
+		}
+
+		data, err = wallet.Unseal(data, current)
+		if err != nil {
+			return errors.Wrap(err, "unseal wallet")
+		}
+
+		// Check the wallet is valid before replacing it.
+		w := wallet.New()
+		if err := w.Deserialize(bytes.NewReader(data)); err != nil {
+			return errors.Wrap(err, "deserialize wallet")
+		}
+
+		next := &wallet.Protection{}
+		keyFile, _ := c.Flags().GetString(FlagKeyFile)
+		plainText, _ := c.Flags().GetBool(FlagPlainText)
+		switch {
+		case plainText && len(keyFile) > 0:
+			return errors.New("Can't use both --plain-text and --key-file")
+		case plainText:
+		case len(keyFile) > 0:
+			next.KeyEncryptionKey, err = wallet.LoadKeyEncryptionKey(keyFile)
+			if err != nil {
+				return err
+			}
+		default:
+			next.Passphrase, err = readPassphraseWithPrompt("New passphrase: ")
+			if err != nil {
+				return err
+			}
+			confirm, err := readPassphraseWithPrompt("Confirm passphrase: ")
+			if err != nil {
+				return err
+			}
+			if confirm != next.Passphrase {
+				return errors.New("Passphrases don't match")
+			}
+		}
+
+		sealed, err := wallet.Seal(data, next)
+		if err != nil {
+			return errors.Wrap(err, "seal wallet")
+		}
+
+		// Verify the new encryption before writing it.
+		if _, err := wallet.Unseal(sealed, next); err != nil {
+			return errors.Wrap(err, "verify wallet")
+		}
+
+		if err := masterDB.Put(ctx, listeners.WalletStorageKey, sealed); err != nil {
+			return errors.Wrap(err, "put wallet")
+		}
+
+		if next.IsEnabled() {
+			fmt.Printf("Re-encrypted wallet with %d keys\n", len(w.ListAll()))
+		} else {
+			fmt.Printf("Decrypted wallet with %d keys\n", len(w.ListAll()))
+		}
+		return nil
+	},
+}
+
+func init() {
+	cmdWalletRekey.Flags().String(FlagKeyFile, "", "file containing the new key encryption key")
+	cmdWalletRekey.Flags().Bool(FlagPlainText, false, "store the wallet without encryption")
+}
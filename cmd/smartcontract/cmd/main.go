@@ -35,6 +35,18 @@ func Execute() {
 	scCmd.AddCommand(cmdState)
 	scCmd.AddCommand(cmdJSON)
 	scCmd.AddCommand(cmdFIP)
+	scCmd.AddCommand(cmdRegister)
+	scCmd.AddCommand(cmdAudit)
+	scCmd.AddCommand(cmdRebuild)
+	scCmd.AddCommand(cmdExport)
+	scCmd.AddCommand(cmdImport)
+	scCmd.AddCommand(cmdWalletRekey)
+	scCmd.AddCommand(cmdFees)
+	scCmd.AddCommand(cmdPolicy)
+	scCmd.AddCommand(cmdInteractions)
+	scCmd.AddCommand(cmdGovernance)
+	scCmd.AddCommand(cmdVoteReport)
+	scCmd.AddCommand(cmdOrders)
 	scCmd.Execute()
 }
 
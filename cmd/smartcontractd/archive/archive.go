@@ -0,0 +1,381 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Version of the archive format.
+	Version = uint8(1)
+
+	contractsKey  = "contracts"
+	formationsKey = "formations"
+	txsKey        = "txs"
+)
+
+var (
+	// ErrChecksum occurs when archive contents don't match their checksums.
+	ErrChecksum = errors.New("Archive checksum mismatch")
+
+	// ErrContractExists occurs when importing over a contract that is already in storage.
+	ErrContractExists = errors.New("Contract already in storage")
+
+	// ErrNoKey occurs when a key is requested from an archive that doesn't contain one.
+	ErrNoKey = errors.New("Archive doesn't contain a key")
+
+	// ErrInvalidRecord occurs when an archive contains a record that doesn't belong to the contract.
+	ErrInvalidRecord = errors.New("Archive record not for contract")
+)
+
+// Archive is a portable copy of all the stored data for a contract. Records are copied verbatim
+//   from storage so an archive can be imported into any storage backend.
+type Archive struct {
+	Version         uint8              `json:"Version"`
+	ContractAddress bitcoin.RawAddress `json:"ContractAddress"`
+	CreatedAt       protocol.Timestamp `json:"CreatedAt"`
+	Records         []*Record          `json:"Records"`
+
+	// EncryptedKey is the contract key encrypted with a passphrase.
+	EncryptedKey []byte `json:"EncryptedKey,omitempty"`
+
+	// Checksum covers the version, contract address, records and encrypted key.
+	Checksum []byte `json:"Checksum"`
+}
+
+// Record is a single storage entry.
+type Record struct {
+	Key  string `json:"Key"`
+	Data []byte `json:"Data"`
+	Hash []byte `json:"Hash"`
+}
+
+// Export builds an archive of a contract's stored data. This includes everything stored under
+//   the contract's path, such as the contract, assets, holdings, votes, pending transfers and
+//   register snapshots, along with the contract formation, the stored txs involving the contract,
+//   and the traces started by those txs.
+// The daemon should be stopped so that storage is consistent.
+func Export(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	isTest bool) (*Archive, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+	contractPath := fmt.Sprintf("%s/%s", contractsKey, contractHash.String())
+
+	result := &Archive{
+		Version:         Version,
+		ContractAddress: contractAddress,
+		CreatedAt:       protocol.CurrentTimestamp(),
+	}
+
+	if _, err := dbConn.Fetch(ctx, contractPath+"/contract"); err != nil {
+		return nil, errors.Wrap(err, "contract")
+	}
+
+	if err := result.addPath(ctx, dbConn, contractPath); err != nil {
+		return nil, errors.Wrap(err, "contract records")
+	}
+
+	if err := result.addKey(ctx, dbConn,
+		fmt.Sprintf("%s/%x", formationsKey, contractAddress.Bytes()), false); err != nil {
+		return nil, errors.Wrap(err, "formation")
+	}
+
+	txs, err := transactions.List(ctx, dbConn, isTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "list txs")
+	}
+	var txids []bitcoin.Hash32
+	for _, itx := range txs {
+		if !itx.IsRelevant(contractAddress) {
+			continue
+		}
+
+		if err := result.addKey(ctx, dbConn, fmt.Sprintf("%s/%s", txsKey, itx.Hash.String()),
+			true); err != nil {
+			return nil, errors.Wrap(err, "tx")
+		}
+		txids = append(txids, *itx.Hash)
+	}
+
+	// The tracer is shared by all contracts, so only include the traces started by this contract's
+	//   txs.
+	tracer := filters.NewTracer()
+	if err := tracer.Load(ctx, dbConn); err != nil {
+		return nil, errors.Wrap(err, "load tracer")
+	}
+	traces := tracer.Subset(txids)
+	if traces.Count() > 0 {
+		var buf bytes.Buffer
+		if err := traces.Serialize(&buf); err != nil {
+			return nil, errors.Wrap(err, "serialize tracer")
+		}
+		result.add(filters.TracerStorageKey, buf.Bytes())
+	}
+
+	return result, nil
+}
+
+// addPath adds the records of every key under a path in storage.
+func (a *Archive) addPath(ctx context.Context, dbConn *db.DB, path string) error {
+	keys, err := dbConn.List(ctx, path)
+	if err != nil {
+		return errors.Wrapf(err, "list %s", path)
+	}
+
+	for _, key := range keys {
+		data, err := dbConn.Fetch(ctx, key)
+		if err == nil {
+			a.add(key, data)
+			continue
+		}
+
+		// Filesystem storage only lists one level, so a key that can't be read is a directory.
+		if key == path || err == db.ErrNotFound {
+			return errors.Wrapf(err, "fetch %s", key)
+		}
+		if err := a.addPath(ctx, dbConn, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addKey adds a record from storage. If required is false then a missing key is skipped.
+func (a *Archive) addKey(ctx context.Context, dbConn *db.DB, key string, required bool) error {
+	data, err := dbConn.Fetch(ctx, key)
+	if err != nil {
+		if err == db.ErrNotFound && !required {
+			return nil
+		}
+		return err
+	}
+
+	a.add(key, data)
+	return nil
+}
+
+// add adds a record.
+func (a *Archive) add(key string, data []byte) {
+	hash := sha256.Sum256(data)
+	a.Records = append(a.Records, &Record{
+		Key:  key,
+		Data: data,
+		Hash: hash[:],
+	})
+}
+
+// SetKey encrypts the contract key with a passphrase and adds it to the archive.
+func (a *Archive) SetKey(key *wallet.Key, passphrase string) error {
+	if !key.Address.Equal(a.ContractAddress) {
+		return errors.New("Key is not for contract")
+	}
+
+	var buf bytes.Buffer
+	if err := key.Write(&buf); err != nil {
+		return errors.Wrap(err, "write key")
+	}
+
+	encrypted, err := wallet.EncryptWithPassphrase(buf.Bytes(), passphrase)
+	if err != nil {
+		return errors.Wrap(err, "encrypt key")
+	}
+
+	a.EncryptedKey = encrypted
+	return nil
+}
+
+// Key decrypts the contract key in the archive.
+func (a *Archive) Key(passphrase string, net bitcoin.Network) (*wallet.Key, error) {
+	if len(a.EncryptedKey) == 0 {
+		return nil, ErrNoKey
+	}
+
+	data, err := wallet.DecryptWithPassphrase(a.EncryptedKey, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &wallet.Key{}
+	if err := result.Read(bytes.NewReader(data), net); err != nil {
+		return nil, errors.Wrap(err, "read key")
+	}
+
+	if !result.Address.Equal(a.ContractAddress) {
+		return nil, errors.New("Key is not for contract")
+	}
+
+	return result, nil
+}
+
+// Import writes the archive's records to storage and verifies them by reading them back. If
+//   overwrite is false and the contract is already in storage then ErrContractExists is returned.
+// Records must be under the contract's path, its formation, or stored txs. The archive's traces
+//   are merged into the tracer in storage.
+func Import(ctx context.Context, dbConn *db.DB, a *Archive, overwrite bool) error {
+	if err := a.Verify(); err != nil {
+		return err
+	}
+
+	contractHash, err := a.ContractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+	contractPath := fmt.Sprintf("%s/%s/", contractsKey, contractHash.String())
+	formationKey := fmt.Sprintf("%s/%x", formationsKey, a.ContractAddress.Bytes())
+
+	for _, record := range a.Records {
+		if strings.Contains(record.Key, "..") {
+			return errors.Wrap(ErrInvalidRecord, record.Key)
+		}
+
+		switch {
+		case strings.HasPrefix(record.Key, contractPath):
+		case record.Key == formationKey:
+		case strings.HasPrefix(record.Key, txsKey+"/"):
+		case record.Key == filters.TracerStorageKey:
+		default:
+			return errors.Wrap(ErrInvalidRecord, record.Key)
+		}
+	}
+
+	if !overwrite {
+		_, err := dbConn.Fetch(ctx, contractPath+"contract")
+		if err == nil {
+			return ErrContractExists
+		}
+		if err != db.ErrNotFound {
+			return errors.Wrap(err, "fetch contract")
+		}
+	}
+
+	for _, record := range a.Records {
+		if record.Key == filters.TracerStorageKey {
+			if err := mergeTracer(ctx, dbConn, record.Data); err != nil {
+				return errors.Wrap(err, "tracer")
+			}
+			continue
+		}
+
+		if err := dbConn.Put(ctx, record.Key, record.Data); err != nil {
+			return errors.Wrapf(err, "put %s", record.Key)
+		}
+	}
+
+	for _, record := range a.Records {
+		if record.Key == filters.TracerStorageKey {
+			continue // merged, so it won't match
+		}
+
+		data, err := dbConn.Fetch(ctx, record.Key)
+		if err != nil {
+			return errors.Wrapf(err, "fetch %s", record.Key)
+		}
+
+		hash := sha256.Sum256(data)
+		if !bytes.Equal(hash[:], record.Hash) {
+			return errors.Wrapf(ErrChecksum, "stored %s", record.Key)
+		}
+	}
+
+	return nil
+}
+
+// mergeTracer adds serialized traces to the tracer in storage.
+func mergeTracer(ctx context.Context, dbConn *db.DB, data []byte) error {
+	traces := filters.NewTracer()
+	if err := traces.Deserialize(bytes.NewReader(data)); err != nil {
+		return errors.Wrap(err, "deserialize")
+	}
+
+	tracer := filters.NewTracer()
+	if err := tracer.Load(ctx, dbConn); err != nil {
+		return errors.Wrap(err, "load")
+	}
+
+	tracer.Merge(traces)
+	return tracer.Save(ctx, dbConn)
+}
+
+// Verify checks the checksums of the archive.
+func (a *Archive) Verify() error {
+	if a.Version != Version {
+		return fmt.Errorf("Unsupported archive version : %d", a.Version)
+	}
+
+	for _, record := range a.Records {
+		hash := sha256.Sum256(record.Data)
+		if !bytes.Equal(hash[:], record.Hash) {
+			return errors.Wrapf(ErrChecksum, "record %s", record.Key)
+		}
+	}
+
+	if !bytes.Equal(a.calculateChecksum(), a.Checksum) {
+		return ErrChecksum
+	}
+
+	return nil
+}
+
+// calculateChecksum returns a hash of the archive contents. Records are covered by their hashes.
+func (a *Archive) calculateChecksum() []byte {
+	hasher := sha256.New()
+	hasher.Write([]byte{a.Version})
+	hasher.Write(a.ContractAddress.Bytes())
+	for _, record := range a.Records {
+		hasher.Write([]byte(record.Key))
+		hasher.Write([]byte{0})
+		hasher.Write(record.Hash)
+	}
+	hasher.Write(a.EncryptedKey)
+	return hasher.Sum(nil)
+}
+
+// Write sets the checksum and writes the archive as compressed JSON.
+func (a *Archive) Write(w io.Writer) error {
+	a.Checksum = a.calculateChecksum()
+
+	gw := gzip.NewWriter(w)
+	if err := json.NewEncoder(gw).Encode(a); err != nil {
+		return errors.Wrap(err, "encode archive")
+	}
+	return gw.Close()
+}
+
+// Read reads an archive and verifies its checksums.
+func Read(r io.Reader) (*Archive, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "decompress archive")
+	}
+	defer gr.Close()
+
+	result := &Archive{}
+	if err := json.NewDecoder(gr).Decode(result); err != nil {
+		return nil, errors.Wrap(err, "decode archive")
+	}
+
+	if err := result.Verify(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
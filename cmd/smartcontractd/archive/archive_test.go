@@ -0,0 +1,213 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+func TestArchive(t *testing.T) {
+	ctx := context.Background()
+
+	sourceDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp/source",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+	defer sourceDB.Clear(ctx, "")
+
+	targetDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp/target",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+	defer targetDB.Clear(ctx, "")
+
+	bkey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	key := wallet.NewKey(bkey)
+
+	now := protocol.CurrentTimestamp()
+	assetCode := protocol.AssetCodeFromContract(key.Address, 0)
+
+	ct := &state.Contract{
+		Address:    key.Address,
+		AssetCodes: []*protocol.AssetCode{assetCode},
+	}
+	if err := contract.Save(ctx, sourceDB, ct, true); err != nil {
+		t.Fatalf("Failed to save contract : %s", err)
+	}
+
+	as := &state.Asset{
+		Code:      assetCode,
+		AssetType: "SHC",
+		TokenQty:  1000,
+	}
+	if err := asset.Save(ctx, sourceDB, key.Address, as); err != nil {
+		t.Fatalf("Failed to save asset : %s", err)
+	}
+
+	h, err := holdings.GetHolding(ctx, sourceDB, key.Address, assetCode, key.Address, now)
+	if err != nil {
+		t.Fatalf("Failed to get holding : %s", err)
+	}
+	h.FinalizedBalance = 1000
+	h.PendingBalance = 1000
+	ci, err := holdings.Save(ctx, sourceDB, key.Address, assetCode, h)
+	if err != nil {
+		t.Fatalf("Failed to save holding : %s", err)
+	}
+	if err := ci.Write(ctx, sourceDB); err != nil {
+		t.Fatalf("Failed to write holding : %s", err)
+	}
+
+	// Stores added per contract are exported with the rest of the contract's path.
+	policy := &contract.GovernancePolicy{QuorumPercentages: []uint32{30}}
+	if err := contract.SaveGovernancePolicy(ctx, sourceDB, key.Address, policy); err != nil {
+		t.Fatalf("Failed to save governance policy : %s", err)
+	}
+
+	// A tx paying the contract and a trace started by it.
+	lockingScript, err := key.Address.LockingScript()
+	if err != nil {
+		t.Fatalf("Failed to create locking script : %s", err)
+	}
+	tx := wire.NewMsgTx(1)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&bitcoin.Hash32{1}, 0), nil))
+	tx.AddTxOut(wire.NewTxOut(1000, lockingScript))
+	itx := &inspector.Transaction{MsgTx: tx, Hash: tx.TxHash()}
+	if err := transactions.AddTx(ctx, sourceDB, itx); err != nil {
+		t.Fatalf("Failed to add tx : %s", err)
+	}
+
+	// Traces for other contracts are not exported.
+	sourceTracer := filters.NewTracer()
+	sourceTracer.Add(ctx, wire.NewOutPoint(itx.Hash, 0))
+	sourceTracer.Add(ctx, wire.NewOutPoint(&bitcoin.Hash32{2}, 0))
+	if err := sourceTracer.Save(ctx, sourceDB); err != nil {
+		t.Fatalf("Failed to save tracer : %s", err)
+	}
+
+	a, err := Export(ctx, sourceDB, key.Address, true)
+	if err != nil {
+		t.Fatalf("Failed to export : %s", err)
+	}
+	if len(a.Records) != 6 {
+		t.Fatalf("Wrong record count : got %d, want %d", len(a.Records), 6)
+	}
+
+	exportedTracer := filters.NewTracer()
+	for _, record := range a.Records {
+		if record.Key == filters.TracerStorageKey {
+			if err := exportedTracer.Deserialize(bytes.NewReader(record.Data)); err != nil {
+				t.Fatalf("Failed to deserialize exported tracer : %s", err)
+			}
+		}
+	}
+	if exportedTracer.Count() != 1 {
+		t.Fatalf("Wrong exported trace count : got %d, want %d", exportedTracer.Count(), 1)
+	}
+
+	// Traces already in the target are kept.
+	targetTracer := filters.NewTracer()
+	targetTracer.Add(ctx, wire.NewOutPoint(&bitcoin.Hash32{3}, 0))
+	if err := targetTracer.Save(ctx, targetDB); err != nil {
+		t.Fatalf("Failed to save tracer : %s", err)
+	}
+
+	if err := a.SetKey(key, "passphrase"); err != nil {
+		t.Fatalf("Failed to set key : %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := a.Write(&buf); err != nil {
+		t.Fatalf("Failed to write archive : %s", err)
+	}
+
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to read archive : %s", err)
+	}
+
+	if _, err := read.Key("wrong", bitcoin.MainNet); err != wallet.ErrWrongPassphrase {
+		t.Fatalf("Decrypted key with wrong passphrase : %v", err)
+	}
+	readKey, err := read.Key("passphrase", bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to decrypt key : %s", err)
+	}
+	if !bytes.Equal(readKey.Key.Bytes(), key.Key.Bytes()) {
+		t.Fatalf("Wrong key")
+	}
+
+	if err := Import(ctx, targetDB, read, false); err != nil {
+		t.Fatalf("Failed to import : %s", err)
+	}
+	if err := Import(ctx, targetDB, read, false); err != ErrContractExists {
+		t.Fatalf("Imported over existing contract : %v", err)
+	}
+
+	importedTracer := filters.NewTracer()
+	if err := importedTracer.Load(ctx, targetDB); err != nil {
+		t.Fatalf("Failed to load imported tracer : %s", err)
+	}
+	if importedTracer.Count() != 2 {
+		t.Fatalf("Wrong imported trace count : got %d, want %d", importedTracer.Count(), 2)
+	}
+
+	// Records outside of the contract's data are rejected.
+	for _, invalidKey := range []string{"contracts/other/contract", "wallet",
+		"txs/../wallet"} {
+		invalid := *read
+		invalid.Records = append([]*Record{}, read.Records...)
+		invalid.add(invalidKey, []byte{1})
+		invalid.Checksum = invalid.calculateChecksum()
+		if err := Import(ctx, targetDB, &invalid, true); errors.Cause(err) != ErrInvalidRecord {
+			t.Fatalf("Imported invalid record %s : %v", invalidKey, err)
+		}
+	}
+
+	holdings.Reset(ctx)
+	imported, err := holdings.GetHolding(ctx, targetDB, key.Address, assetCode, key.Address, now)
+	if err != nil {
+		t.Fatalf("Failed to get imported holding : %s", err)
+	}
+	if imported.FinalizedBalance != 1000 {
+		t.Fatalf("Wrong imported balance : got %d, want %d", imported.FinalizedBalance, 1000)
+	}
+
+	importedPolicy, err := contract.FetchGovernancePolicy(ctx, targetDB, key.Address)
+	if err != nil {
+		t.Fatalf("Failed to fetch imported governance policy : %s", err)
+	}
+	if len(importedPolicy.QuorumPercentages) != 1 || importedPolicy.QuorumPercentages[0] != 30 {
+		t.Fatalf("Wrong imported governance policy : %v", importedPolicy.QuorumPercentages)
+	}
+
+	// Tampered data is detected.
+	read.Records[0].Data[0] ^= 0xff
+	if err := read.Verify(); err == nil {
+		t.Fatalf("Tampered archive verified")
+	}
+}
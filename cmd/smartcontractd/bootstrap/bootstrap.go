@@ -3,17 +3,23 @@ package bootstrap
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"strings"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/config"
 	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/utxos"
+	"github.com/tokenized/smart-contract/pkg/signer"
 	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
 )
 
 func NewContextWithDevelopmentLogger() context.Context {
@@ -30,21 +36,80 @@ func NewWallet() *wallet.Wallet {
 	return wallet.New()
 }
 
+// NewSigner adds the contract keys to the wallet and returns the signer for them. With a remote
+//   signer configured the wallet only gets the public keys held by the signer, otherwise it gets
+//   the configured private key and signs itself.
+func NewSigner(ctx context.Context, cfg *config.Config, masterWallet *wallet.Wallet,
+	net bitcoin.Network) wallet.Signer {
+
+	if len(cfg.Contract.SignerAddress) == 0 {
+		if err := masterWallet.Register(cfg.Contract.PrivateKey, net); err != nil {
+			logger.Fatal(ctx, "Register contract key : %s", err)
+		}
+		return masterWallet
+	}
+
+	client := signer.NewClient(cfg.Contract.SignerAddress)
+	publicKeys, err := client.PublicKeys(ctx)
+	if err != nil {
+		logger.Fatal(ctx, "Signer public keys : %s", err)
+	}
+	if len(publicKeys) == 0 {
+		logger.Fatal(ctx, "Signer has no keys")
+	}
+
+	for _, publicKey := range publicKeys {
+		if err := masterWallet.Add(wallet.NewPublicKey(publicKey)); err != nil {
+			logger.Fatal(ctx, "Add signer key : %s", err)
+		}
+	}
+
+	logger.Info(ctx, "Using remote signer at %s with %d keys", cfg.Contract.SignerAddress,
+		len(publicKeys))
+	return client
+}
+
+// NewWalletProtection returns the configured encryption for the stored wallet.
+func NewWalletProtection(ctx context.Context, cfg *config.Config) *wallet.Protection {
+	if len(cfg.Contract.WalletPassphrase) > 0 && len(cfg.Contract.WalletKeyFile) > 0 {
+		logger.Fatal(ctx, "Wallet passphrase and key file are both configured")
+	}
+
+	result := &wallet.Protection{
+		Passphrase: cfg.Contract.WalletPassphrase,
+	}
+
+	if len(cfg.Contract.WalletKeyFile) > 0 {
+		key, err := wallet.LoadKeyEncryptionKey(cfg.Contract.WalletKeyFile)
+		if err != nil {
+			logger.Fatal(ctx, "Wallet key file : %s", err)
+		}
+		result.KeyEncryptionKey = key
+	}
+
+	return result
+}
+
+// NewConfigFromEnv returns the config from environment variables and the config file they
+//   specify.
 func NewConfigFromEnv(ctx context.Context) *config.Config {
-	cfg, err := config.Environment()
+	cfg, err := config.Load()
 	if err != nil {
 		logger.Fatal(ctx, "Parsing Config : %s", err)
 	}
 
-	// Mask sensitive values
+	LogConfig(ctx, cfg)
+	return cfg
+}
+
+// LogConfig logs the config with sensitive values masked.
+func LogConfig(ctx context.Context, cfg *config.Config) {
 	cfgSafe := config.SafeConfig(*cfg)
 	cfgJSON, err := json.MarshalIndent(cfgSafe, "", "    ")
 	if err != nil {
 		logger.Fatal(ctx, "Marshalling Config to JSON : %s", err)
 	}
 	logger.Info(ctx, "Config : %v", string(cfgJSON))
-
-	return cfg
 }
 
 func NewMasterDB(ctx context.Context, cfg *config.Config) *db.DB {
@@ -66,13 +131,27 @@ func NewNodeConfig(ctx context.Context, cfg *config.Config) *node.Config {
 		Net:                bitcoin.NetworkFromString(cfg.Bitcoin.Network),
 		ContractProviderID: cfg.Contract.OperatorName,
 		Version:            cfg.Contract.Version,
-		FeeRate:            cfg.Contract.FeeRate,
-		DustFeeRate:        cfg.Contract.DustFeeRate,
-		MinFeeRate:         cfg.Contract.MinFeeRate,
 		RequestTimeout:     cfg.Contract.RequestTimeout,
 		PreprocessThreads:  cfg.Contract.PreprocessThreads,
+		NoticeBatchSize:    cfg.Contract.NoticeBatchSize,
 		IsTest:             cfg.Contract.IsTest,
+
+		UTXOMinConfirmations: cfg.Contract.UTXOMinConfirmations,
+	}
+
+	var err error
+	appConfig.UTXOStrategy, err = utxos.StrategyFromString(cfg.Contract.UTXOStrategy)
+	if err != nil {
+		logger.Fatal(ctx, "Invalid UTXO strategy : %s", err)
+	}
+
+	appConfig.Fees = NewFeePolicy(ctx, cfg)
+
+	_, requestTimeouts, err := contractOverrides(cfg, appConfig.Net)
+	if err != nil {
+		logger.Fatal(ctx, "Invalid contract config : %s", err)
 	}
+	appConfig.RequestTimeouts = node.NewRequestTimeouts(requestTimeouts)
 
 	feeAddress, err := bitcoin.DecodeAddress(cfg.Contract.FeeAddress)
 	if err != nil {
@@ -86,6 +165,139 @@ func NewNodeConfig(ctx context.Context, cfg *config.Config) *node.Config {
 	return appConfig
 }
 
+// NewFeePolicy returns the fee policy for the configured fee rate source. Estimates aren't
+//   retrieved until the policy is updated.
+func NewFeePolicy(ctx context.Context, cfg *config.Config) *fees.Policy {
+	result := fees.NewPolicy(cfg.Contract.FeeRate, cfg.Contract.DustFeeRate,
+		cfg.Contract.MinFeeRate)
+	if err := UpdateFeePolicy(cfg, result); err != nil {
+		logger.Fatal(ctx, "Invalid fee policy : %s", err)
+	}
+
+	switch strings.ToLower(cfg.Contract.FeeRateSource) {
+	case "", fees.SourceStatic:
+	case fees.SourceRPC:
+		if len(cfg.RpcNode.Host) == 0 {
+			logger.Fatal(ctx, "RPC fee estimates require RPC_HOST")
+		}
+		result.SetEstimator(fees.NewRPCEstimator(cfg.RpcNode.Host, cfg.RpcNode.Username,
+			cfg.RpcNode.Password, cfg.Contract.FeeEstimateBlocks))
+	case fees.SourceSchedule:
+	default:
+		logger.Fatal(ctx, "Unknown fee rate source : %s", cfg.Contract.FeeRateSource)
+	}
+
+	return result
+}
+
+// UpdateFeePolicy applies the fee rates of the config to the policy. The fee rate source isn't
+//   changed.
+func UpdateFeePolicy(cfg *config.Config, policy *fees.Policy) error {
+	var schedule []fees.ScheduleEntry
+	if strings.ToLower(cfg.Contract.FeeRateSource) == fees.SourceSchedule {
+		var err error
+		schedule, err = fees.ParseSchedule(cfg.Contract.FeeRateSchedule)
+		if err != nil {
+			return errors.Wrap(err, "fee rate schedule")
+		}
+		if len(schedule) == 0 {
+			return errors.New("Fee rate schedule is empty")
+		}
+	}
+
+	contractRates, _, err := contractOverrides(cfg, bitcoin.NetworkFromString(cfg.Bitcoin.Network))
+	if err != nil {
+		return errors.Wrap(err, "contract config")
+	}
+
+	policy.SetFeeRate(cfg.Contract.FeeRate)
+	policy.SetDustFeeRate(cfg.Contract.DustFeeRate)
+	policy.SetMinFeeRate(cfg.Contract.MinFeeRate)
+	policy.SetMaxFeeRate(cfg.Contract.MaxFeeRate)
+	policy.SetOverrides(cfg.Contract.FeeRateOverrides)
+	policy.SetContractRates(contractRates)
+	if schedule != nil {
+		policy.SetSchedule(schedule)
+	}
+
+	return nil
+}
+
+// contractOverrides returns the per contract fee rates and request timeouts, keyed by the raw
+//   address bytes.
+func contractOverrides(cfg *config.Config,
+	net bitcoin.Network) (map[string]fees.ContractRates, map[string]uint64, error) {
+
+	rates := make(map[string]fees.ContractRates)
+	timeouts := make(map[string]uint64)
+	for addressString, contract := range cfg.Contracts {
+		ra, err := decodeAddress(addressString, net)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if contract.FeeRate > 0.0 || contract.MinFeeRate > 0.0 {
+			rates[string(ra.Bytes())] = fees.ContractRates{
+				FeeRate:    contract.FeeRate,
+				MinFeeRate: contract.MinFeeRate,
+			}
+		}
+		if contract.RequestTimeout > 0 {
+			timeouts[string(ra.Bytes())] = contract.RequestTimeout
+		}
+	}
+
+	return rates, timeouts, nil
+}
+
+// NewUTXOMaintenanceConfig returns the UTXO consolidation and low balance alert settings.
+func NewUTXOMaintenanceConfig(ctx context.Context, cfg *config.Config,
+	net bitcoin.Network) listeners.UTXOMaintenanceConfig {
+
+	result, err := utxoMaintenanceConfig(cfg, net)
+	if err != nil {
+		logger.Fatal(ctx, "Invalid UTXO maintenance config : %s", err)
+	}
+
+	return result
+}
+
+func utxoMaintenanceConfig(cfg *config.Config,
+	net bitcoin.Network) (listeners.UTXOMaintenanceConfig, error) {
+
+	result := listeners.UTXOMaintenanceConfig{
+		ConsolidationThreshold:  cfg.Contract.ConsolidationThreshold,
+		ConsolidationMinCount:   cfg.Contract.ConsolidationMinCount,
+		ConsolidationMaxInputs:  cfg.Contract.ConsolidationMaxInputs,
+		ConsolidationMaxFeeRate: cfg.Contract.ConsolidationMaxFeeRate,
+		LowBalanceThreshold:     cfg.Contract.LowBalanceThreshold,
+		LowBalanceThresholds:    make(map[string]uint64),
+	}
+
+	for addressString, threshold := range cfg.Contract.LowBalanceThresholds {
+		ra, err := decodeAddress(addressString, net)
+		if err != nil {
+			return result, errors.Wrap(err, "low balance threshold")
+		}
+		result.LowBalanceThresholds[string(ra.Bytes())] = threshold
+	}
+
+	return result, nil
+}
+
+// decodeAddress decodes an address and checks that it is for the network.
+func decodeAddress(s string, net bitcoin.Network) (bitcoin.RawAddress, error) {
+	address, err := bitcoin.DecodeAddress(s)
+	if err != nil {
+		return bitcoin.RawAddress{}, errors.Wrap(err, s)
+	}
+	if !bitcoin.DecodeNetMatches(address.Network(), net) {
+		return bitcoin.RawAddress{}, fmt.Errorf("Wrong address encoding network : %s", s)
+	}
+
+	return bitcoin.NewRawAddressFromAddress(address), nil
+}
+
 func LoadUTXOsFromDB(ctx context.Context, masterDB *db.DB) *utxos.UTXOs {
 	utxos, err := utxos.Load(ctx, masterDB)
 	if err != nil {
@@ -0,0 +1,75 @@
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/platform/config"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigReloader loads the config again and applies the values that can change while running,
+//   like fee rates, UTXO maintenance thresholds and per contract overrides. Other values, like the
+//   spynode connection, are kept until restart.
+type ConfigReloader struct {
+	previous        *config.Config
+	appConfig       *node.Config
+	utxoMaintenance *listeners.UTXOMaintenance
+}
+
+// NewConfigReloader returns a reloader for the config loaded at startup. utxoMaintenance can be
+//   nil.
+func NewConfigReloader(cfg *config.Config, appConfig *node.Config,
+	utxoMaintenance *listeners.UTXOMaintenance) *ConfigReloader {
+
+	return &ConfigReloader{
+		previous:        cfg,
+		appConfig:       appConfig,
+		utxoMaintenance: utxoMaintenance,
+	}
+}
+
+// Reload applies the current config. Nothing is applied when the new config is invalid. Returns
+//   true when values that aren't applied until restart changed since the previous reload, so each
+//   change is only reported once.
+func (r *ConfigReloader) Reload(ctx context.Context) (bool, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return false, errors.Wrap(err, "load")
+	}
+
+	// Validate everything before applying anything.
+	_, requestTimeouts, err := contractOverrides(cfg, r.appConfig.Net)
+	if err != nil {
+		return false, errors.Wrap(err, "contract config")
+	}
+
+	umConfig, err := utxoMaintenanceConfig(cfg, r.appConfig.Net)
+	if err != nil {
+		return false, errors.Wrap(err, "utxo maintenance config")
+	}
+
+	if err := UpdateFeePolicy(cfg, r.appConfig.Fees); err != nil {
+		return false, errors.Wrap(err, "fee policy")
+	}
+
+	if r.appConfig.RequestTimeouts != nil {
+		r.appConfig.RequestTimeouts.Set(requestTimeouts)
+	}
+
+	if r.utxoMaintenance != nil {
+		r.utxoMaintenance.SetConfig(umConfig)
+	}
+
+	LogConfig(ctx, cfg)
+	restartRequired := config.RestartRequired(r.previous, cfg)
+	if restartRequired {
+		logger.Warn(ctx, "Config has changes that aren't applied until restart")
+	}
+
+	r.previous = cfg
+	return restartRequired, nil
+}
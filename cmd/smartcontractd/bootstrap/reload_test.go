@@ -0,0 +1,61 @@
+package bootstrap
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/config"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+)
+
+func TestConfigReloader(t *testing.T) {
+	ctx := context.Background()
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("Failed to load config : %s", err)
+	}
+	appConfig := &node.Config{
+		Net:  bitcoin.MainNet,
+		Fees: fees.NewPolicy(cfg.Contract.FeeRate, cfg.Contract.DustFeeRate, 0.5),
+	}
+	reloader := NewConfigReloader(cfg, appConfig, nil)
+
+	setEnv(t, "FEE_RATE", "2.0")
+	defer os.Unsetenv("FEE_RATE")
+	restartRequired, err := reloader.Reload(ctx)
+	if err != nil {
+		t.Fatalf("Failed to reload : %s", err)
+	}
+	if restartRequired {
+		t.Errorf("Restart required for fee rate")
+	}
+
+	setEnv(t, "NODE_ADDRESS", "10.0.0.1:8333")
+	defer os.Unsetenv("NODE_ADDRESS")
+	restartRequired, err = reloader.Reload(ctx)
+	if err != nil {
+		t.Fatalf("Failed to reload : %s", err)
+	}
+	if !restartRequired {
+		t.Errorf("Restart not required for spynode address")
+	}
+
+	// The change was already reported, so it is compared against the previous reload.
+	restartRequired, err = reloader.Reload(ctx)
+	if err != nil {
+		t.Fatalf("Failed to reload : %s", err)
+	}
+	if restartRequired {
+		t.Errorf("Restart required again for the same spynode address")
+	}
+}
+
+func setEnv(t *testing.T, key, value string) {
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("Failed to set %s : %s", key, err)
+	}
+}
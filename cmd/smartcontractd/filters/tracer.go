@@ -14,7 +14,8 @@ import (
 )
 
 const (
-	tracerStorageKey = "tracer"
+	// TracerStorageKey is the storage path for the tracer.
+	TracerStorageKey = "tracer"
 )
 
 // Tracer watches UTXO paths starting with a specified outpoint. It can be used to retrace back to
@@ -41,26 +42,16 @@ func (tracer *Tracer) Clear() {
 func (tracer *Tracer) Save(ctx context.Context, masterDB *db.DB) error {
 	// Save the cache list
 	var buf bytes.Buffer
-
-	// Write length of list
-	count := uint32(len(tracer.traces))
-	if err := binary.Write(&buf, protocol.DefaultEndian, &count); err != nil {
+	if err := tracer.Serialize(&buf); err != nil {
 		return err
 	}
 
-	// Write items
-	for _, trace := range tracer.traces {
-		if err := trace.write(&buf); err != nil {
-			return err
-		}
-	}
-
 	node.LogVerbose(ctx, "Saving %d traces", len(tracer.traces))
-	return masterDB.Put(ctx, tracerStorageKey, buf.Bytes())
+	return masterDB.Put(ctx, TracerStorageKey, buf.Bytes())
 }
 
 func (tracer *Tracer) Load(ctx context.Context, masterDB *db.DB) error {
-	data, err := masterDB.Fetch(ctx, tracerStorageKey)
+	data, err := masterDB.Fetch(ctx, TracerStorageKey)
 	if err != nil {
 		if err == db.ErrNotFound {
 			return nil
@@ -68,11 +59,37 @@ func (tracer *Tracer) Load(ctx context.Context, masterDB *db.DB) error {
 		return err
 	}
 
-	buf := bytes.NewBuffer(data)
+	if err := tracer.Deserialize(bytes.NewReader(data)); err != nil {
+		return err
+	}
 
+	node.LogVerbose(ctx, "Loaded %d traces", len(tracer.traces))
+	return nil
+}
+
+// Serialize writes the traces in the format used by storage.
+func (tracer *Tracer) Serialize(w io.Writer) error {
+	// Write length of list
+	count := uint32(len(tracer.traces))
+	if err := binary.Write(w, protocol.DefaultEndian, &count); err != nil {
+		return err
+	}
+
+	// Write items
+	for _, trace := range tracer.traces {
+		if err := trace.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deserialize replaces the traces with those read from the format used by storage.
+func (tracer *Tracer) Deserialize(r io.Reader) error {
 	// Read length of list
 	var count uint32
-	if err := binary.Read(buf, protocol.DefaultEndian, &count); err != nil {
+	if err := binary.Read(r, protocol.DefaultEndian, &count); err != nil {
 		return err
 	}
 
@@ -80,16 +97,47 @@ func (tracer *Tracer) Load(ctx context.Context, masterDB *db.DB) error {
 	tracer.traces = make([]*traceNode, count)
 	for i := range tracer.traces {
 		newTrace := &traceNode{}
-		if err := newTrace.read(buf); err != nil {
+		if err := newTrace.read(r); err != nil {
 			return err
 		}
 		tracer.traces[i] = newTrace
 	}
 
-	node.LogVerbose(ctx, "Loaded %d traces", len(tracer.traces))
 	return nil
 }
 
+// Subset returns a tracer containing only the traces that start at an output of one of the
+//   specified txs.
+func (tracer *Tracer) Subset(txids []bitcoin.Hash32) *Tracer {
+	result := NewTracer()
+	for _, trace := range tracer.traces {
+		for _, txid := range txids {
+			if trace.outpoint.Hash.Equal(&txid) {
+				result.traces = append(result.traces, trace)
+				break
+			}
+		}
+	}
+	return result
+}
+
+// Merge adds the traces from another tracer that don't start at an output already traced.
+func (tracer *Tracer) Merge(other *Tracer) {
+	for _, trace := range other.traces {
+		found := false
+		for _, existing := range tracer.traces {
+			if existing.outpoint.Hash.Equal(&trace.outpoint.Hash) &&
+				existing.outpoint.Index == trace.outpoint.Index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tracer.traces = append(tracer.traces, trace)
+		}
+	}
+}
+
 // Add adds a new trace starting at the specified output.
 func (tracer *Tracer) Add(ctx context.Context, start *wire.OutPoint) {
 	newNode := traceNode{
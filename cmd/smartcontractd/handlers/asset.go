@@ -329,35 +329,15 @@ func (a *Asset) ModificationRequest(ctx context.Context, w *node.ResponseWriter,
 		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
 	}
 
-	var h *state.Holding
-	updateHoldings := false
-	if ac.TokenQty != as.TokenQty {
-		updateHoldings = true
-
-		// Check administration balance for token quantity reductions. Administration has to hold
-		//   any tokens being "burned".
-		h, err = holdings.GetHolding(ctx, a.MasterDB, rk.Address, assetCode, ct.AdminAddress, v.Now)
-		if err != nil {
-			return errors.Wrap(err, "Failed to get admin holding")
-		}
-
-		txid := protocol.TxIdFromBytes(itx.Hash[:])
-
-		if ac.TokenQty < as.TokenQty {
-			if err := holdings.AddDebit(h, txid, as.TokenQty-ac.TokenQty, true, v.Now); err != nil {
-				node.LogWarn(ctx, "Failed to reduce administration holdings : %s", err)
-				if err == holdings.ErrInsufficientHoldings {
-					return node.RespondReject(ctx, w, itx, rk, actions.RejectionsInsufficientQuantity)
-				} else {
-					return errors.Wrap(err, "Failed to reduce holdings")
-				}
-			}
-		} else {
-			if err := holdings.AddDeposit(h, txid, ac.TokenQty-as.TokenQty, true, v.Now); err != nil {
-				node.LogWarn(ctx, "Failed to increase administration holdings : %s", err)
-				return errors.Wrap(err, "Failed to increase holdings")
-			}
+	h, err := adjustAdminHoldings(ctx, a.MasterDB, rk.Address, ct, as, &ac,
+		protocol.TxIdFromBytes(itx.Hash[:]), v.Now)
+	if err != nil {
+		code, ok := node.ErrorCode(err)
+		if !ok {
+			return errors.Wrap(err, "adjust admin holdings")
 		}
+		node.LogWarn(ctx, "Failed to adjust administration holdings : %s", err)
+		return node.RespondReject(ctx, w, itx, rk, code)
 	}
 
 	// Build outputs
@@ -376,7 +356,7 @@ func (a *Asset) ModificationRequest(ctx context.Context, w *node.ResponseWriter,
 		return errors.Wrap(err, "Failed to respond")
 	}
 
-	if updateHoldings {
+	if h != nil {
 		cacheItem, err := holdings.Save(ctx, a.MasterDB, rk.Address, assetCode, h)
 		if err != nil {
 			return errors.Wrap(err, "Failed to save holdings")
@@ -461,6 +441,15 @@ func (a *Asset) CreationResponse(ctx context.Context, w *node.ResponseWriter,
 				return errors.New("Failed to retrieve vote for modification")
 			}
 		}
+
+		// Amendments applied automatically by a vote spend the vote result's contract output.
+		if voteResult, ok := request.MsgProto.(*actions.Result); ok {
+			voteTxId := protocol.TxIdFromBytes(voteResult.VoteTxId)
+			vt, err = vote.Retrieve(ctx, a.MasterDB, rk.Address, voteTxId)
+			if err != nil {
+				return errors.Wrap(err, "Failed to retrieve vote for modification")
+			}
+		}
 	}
 
 	// Create or update Asset
@@ -708,6 +697,39 @@ func (a *Asset) CreationResponse(ctx context.Context, w *node.ResponseWriter,
 	return nil
 }
 
+// adjustAdminHoldings applies a change of an asset's token quantity to the administration's
+//   holding. Administration has to hold any tokens being "burned". It returns the holding to save
+//   after the response is sent, or nil when the quantity didn't change.
+func adjustAdminHoldings(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	ct *state.Contract, as *state.Asset, ac *actions.AssetCreation, txid *protocol.TxId,
+	now protocol.Timestamp) (*state.Holding, error) {
+
+	if ac.TokenQty == as.TokenQty {
+		return nil, nil
+	}
+
+	h, err := holdings.GetHolding(ctx, dbConn, contractAddress, as.Code, ct.AdminAddress, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get admin holding")
+	}
+
+	if ac.TokenQty < as.TokenQty {
+		if err := holdings.AddDebit(h, txid, as.TokenQty-ac.TokenQty, true, now); err != nil {
+			if err == holdings.ErrInsufficientHoldings {
+				return nil, node.NewError(actions.RejectionsInsufficientQuantity,
+					"Administration doesn't hold the tokens being removed")
+			}
+			return nil, errors.Wrap(err, "Failed to reduce holdings")
+		}
+	} else {
+		if err := holdings.AddDeposit(h, txid, ac.TokenQty-as.TokenQty, true, now); err != nil {
+			return nil, errors.Wrap(err, "Failed to increase holdings")
+		}
+	}
+
+	return h, nil
+}
+
 func applyAssetAmendments(ac *actions.AssetCreation, votingSystems []*actions.VotingSystemField,
 	amendments []*actions.AmendmentField, proposed bool, proposalType, votingSystem uint32) error {
 
@@ -379,13 +379,6 @@ func (c *Contract) AmendmentRequest(ctx context.Context, w *node.ResponseWriter,
 		return errors.Wrap(err, "fetch contract formation")
 	}
 
-	// Ensure reduction in qty is OK, keeping in mind that zero (0) means
-	// unlimited asset creation is permitted.
-	if cf.RestrictedQtyAssets > 0 && cf.RestrictedQtyAssets < uint64(len(ct.AssetCodes)) {
-		node.LogWarn(ctx, "Cannot reduce allowable assets below existing number")
-		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsContractAssetQtyReduction)
-	}
-
 	if msg.ChangeAdministrationAddress || msg.ChangeOperatorAddress {
 		if !ct.OperatorAddress.IsEmpty() {
 			if len(itx.Inputs) < 2 {
@@ -448,65 +441,14 @@ func (c *Contract) AmendmentRequest(ctx context.Context, w *node.ResponseWriter,
 		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
 	}
 
-	// Verify entity contract
-	if len(cf.EntityContract) > 0 {
-		ra, err := bitcoin.DecodeRawAddress(cf.EntityContract)
-		if err != nil {
-			return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-				fmt.Sprintf("Entity contract address invalid : %s", err))
-		}
-
-		entityCF, err := contract.FetchContractFormation(ctx, c.MasterDB, ra, c.Config.IsTest)
-		if err != nil {
-			if errors.Cause(err) == contract.ErrNotFound {
-				return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-					"Entity contract not found")
-			}
-			return errors.Wrap(err, "fetch entity contract formation")
-		}
-		logger.Info(ctx, "Found Parent Entity Contract : %s", entityCF.ContractName)
-	}
-
-	// Verify operator entity contract
-	if len(cf.OperatorEntityContract) > 0 {
-		ra, err := bitcoin.DecodeRawAddress(cf.OperatorEntityContract)
-		if err != nil {
-			return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-				fmt.Sprintf("Operator entity contract address invalid : %s", err))
-		}
-
-		entityCF, err := contract.FetchContractFormation(ctx, c.MasterDB, ra, c.Config.IsTest)
-		if err != nil {
-			if errors.Cause(err) == contract.ErrNotFound {
-				return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-					"Operator entity contract not found")
-			}
-			return errors.Wrap(err, "fetch operator entity contract formation")
-		}
-		logger.Info(ctx, "Found Operator Entity Contract : %s", entityCF.ContractName)
-	}
-
-	// Check admin identity oracle signatures
-	for _, adminCert := range cf.AdminIdentityCertificates {
-		if err := validateContractAmendOracleSig(ctx, c.MasterDB, cf, adminCert, c.Headers,
-			c.Config.IsTest); err != nil {
-			node.LogVerbose(ctx, "New admin identity signature invalid : %s", err)
-			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsInvalidSignature)
-		}
-	}
-
-	// Check any oracle entity contracts
-	for _, oracle := range cf.Oracles {
-		ra, err := bitcoin.DecodeRawAddress(oracle.EntityContract)
-		if err != nil {
-			node.LogWarn(ctx, "Invalid oracle entity address : %s", err)
-			return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-				fmt.Sprintf("Invalid oracle entity address : %s", err))
-		}
-		if _, err := contract.FetchContractFormation(ctx, c.MasterDB, ra, c.Config.IsTest); err != nil {
-			return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsMsgMalformed,
-				fmt.Sprintf("Oracle entity address : %s", err))
+	if err := verifyContractFormation(ctx, c.MasterDB, c.Headers, ct, cf,
+		c.Config.IsTest); err != nil {
+		code, ok := node.ErrorCode(err)
+		if !ok {
+			return errors.Wrap(err, "verify formation")
 		}
+		node.LogWarn(ctx, "Contract amendment invalid : %s", err)
+		return node.RespondRejectText(ctx, w, itx, rk, code, err.Error())
 	}
 
 	// Apply modifications
@@ -598,6 +540,15 @@ func (c *Contract) FormationResponse(ctx context.Context, w *node.ResponseWriter
 				return errors.New("Failed to retrieve vote for amendment")
 			}
 		}
+
+		// Amendments applied automatically by a vote spend the vote result's contract output.
+		if voteResult, ok := request.MsgProto.(*actions.Result); ok {
+			voteTxId := protocol.TxIdFromBytes(voteResult.VoteTxId)
+			vt, err = vote.Retrieve(ctx, c.MasterDB, rk.Address, voteTxId)
+			if err != nil {
+				return errors.Wrap(err, "Failed to retrieve vote for amendment")
+			}
+		}
 	}
 
 	// Create or update Contract
@@ -824,6 +775,80 @@ func (c *Contract) AddressChange(ctx context.Context, w *node.ResponseWriter,
 	return nil
 }
 
+// verifyContractFormation checks a contract formation that has amendments applied. Invalid
+//   formations are returned as node errors containing the rejection code.
+func verifyContractFormation(ctx context.Context, dbConn *db.DB, headers node.BitcoinHeaders,
+	ct *state.Contract, cf *actions.ContractFormation, isTest bool) error {
+
+	// Ensure reduction in qty is OK, keeping in mind that zero (0) means
+	// unlimited asset creation is permitted.
+	if cf.RestrictedQtyAssets > 0 && cf.RestrictedQtyAssets < uint64(len(ct.AssetCodes)) {
+		return node.NewError(actions.RejectionsContractAssetQtyReduction,
+			"Cannot reduce allowable assets below existing number")
+	}
+
+	// Verify entity contract
+	if len(cf.EntityContract) > 0 {
+		ra, err := bitcoin.DecodeRawAddress(cf.EntityContract)
+		if err != nil {
+			return node.NewError(actions.RejectionsMsgMalformed,
+				fmt.Sprintf("Entity contract address invalid : %s", err))
+		}
+
+		entityCF, err := contract.FetchContractFormation(ctx, dbConn, ra, isTest)
+		if err != nil {
+			if errors.Cause(err) == contract.ErrNotFound {
+				return node.NewError(actions.RejectionsMsgMalformed, "Entity contract not found")
+			}
+			return errors.Wrap(err, "fetch entity contract formation")
+		}
+		logger.Info(ctx, "Found Parent Entity Contract : %s", entityCF.ContractName)
+	}
+
+	// Verify operator entity contract
+	if len(cf.OperatorEntityContract) > 0 {
+		ra, err := bitcoin.DecodeRawAddress(cf.OperatorEntityContract)
+		if err != nil {
+			return node.NewError(actions.RejectionsMsgMalformed,
+				fmt.Sprintf("Operator entity contract address invalid : %s", err))
+		}
+
+		entityCF, err := contract.FetchContractFormation(ctx, dbConn, ra, isTest)
+		if err != nil {
+			if errors.Cause(err) == contract.ErrNotFound {
+				return node.NewError(actions.RejectionsMsgMalformed,
+					"Operator entity contract not found")
+			}
+			return errors.Wrap(err, "fetch operator entity contract formation")
+		}
+		logger.Info(ctx, "Found Operator Entity Contract : %s", entityCF.ContractName)
+	}
+
+	// Check admin identity oracle signatures
+	for _, adminCert := range cf.AdminIdentityCertificates {
+		if err := validateContractAmendOracleSig(ctx, dbConn, cf, adminCert, headers,
+			isTest); err != nil {
+			return node.NewError(actions.RejectionsInvalidSignature,
+				fmt.Sprintf("New admin identity signature invalid : %s", err))
+		}
+	}
+
+	// Check any oracle entity contracts
+	for _, oracle := range cf.Oracles {
+		ra, err := bitcoin.DecodeRawAddress(oracle.EntityContract)
+		if err != nil {
+			return node.NewError(actions.RejectionsMsgMalformed,
+				fmt.Sprintf("Invalid oracle entity address : %s", err))
+		}
+		if _, err := contract.FetchContractFormation(ctx, dbConn, ra, isTest); err != nil {
+			return node.NewError(actions.RejectionsMsgMalformed,
+				fmt.Sprintf("Oracle entity address : %s", err))
+		}
+	}
+
+	return nil
+}
+
 // applyContractAmendments applies the amendments to the contract formation.
 func applyContractAmendments(cf *actions.ContractFormation, amendments []*actions.AmendmentField,
 	proposed bool, proposalType, votingSystem uint32) error {
@@ -8,6 +8,7 @@ import (
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/smart-contract/internal/asset"
 	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/enforcement"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/db"
 	"github.com/tokenized/smart-contract/internal/platform/node"
@@ -83,6 +84,16 @@ func (e *Enforcement) OrderRequest(ctx context.Context, w *node.ResponseWriter,
 			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
 		}
 
+		// Any key can sign an order, so it must be the current key of one of the contract's
+		//   authority oracles.
+		authority := contract.Authority(ct, authorityPubKey)
+		if authority == nil {
+			node.LogWarn(ctx, "Authority is not a registered authority oracle : %s",
+				authorityPubKey.String())
+			return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsInvalidSignature,
+				"Authority not registered")
+		}
+
 		authoritySig, err := bitcoin.SignatureFromBytes(msg.OrderSignature)
 		if err != nil {
 			node.LogWarn(ctx, "Failed to parse authority signature : %s", err)
@@ -94,7 +105,7 @@ func (e *Enforcement) OrderRequest(ctx context.Context, w *node.ResponseWriter,
 			return errors.Wrap(err, "Failed to calculate authority sig hash")
 		}
 
-		if !authoritySig.Verify(sigHash, authorityPubKey) {
+		if !authoritySig.Verify(sigHash, authority.PublicKey) {
 			node.LogWarn(ctx, "Authority Sig Verify Failed")
 			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsInvalidSignature)
 		}
@@ -235,7 +246,7 @@ func (e *Enforcement) OrderFreezeRequest(ctx context.Context, w *node.ResponseWr
 		return errors.Wrap(err, "Failed to respond")
 	}
 
-	return nil
+	return e.saveOrder(ctx, itx, rk, msg, v.Now)
 }
 
 // OrderThawRequest is a helper of Order
@@ -347,7 +358,11 @@ func (e *Enforcement) OrderThawRequest(ctx context.Context, w *node.ResponseWrit
 	w.AddContractFee(ctx, ct.ContractFee)
 
 	// Respond with a thaw action
-	return node.RespondSuccess(ctx, w, itx, rk, &thaw)
+	if err := node.RespondSuccess(ctx, w, itx, rk, &thaw); err != nil {
+		return err
+	}
+
+	return e.saveOrder(ctx, itx, rk, msg, v.Now)
 }
 
 // OrderConfiscateRequest is a helper of Order
@@ -514,6 +529,10 @@ func (e *Enforcement) OrderConfiscateRequest(ctx context.Context, w *node.Respon
 		return err
 	}
 
+	if err := e.saveOrder(ctx, itx, rk, msg, v.Now); err != nil {
+		return err
+	}
+
 	for _, h := range hds {
 		cacheItem, err := holdings.Save(ctx, e.MasterDB, rk.Address, assetCode, h)
 		if err != nil {
@@ -663,6 +682,10 @@ func (e *Enforcement) OrderReconciliationRequest(ctx context.Context, w *node.Re
 		return err
 	}
 
+	if err := e.saveOrder(ctx, itx, rk, msg, v.Now); err != nil {
+		return err
+	}
+
 	for _, h := range hds {
 		cacheItem, err := holdings.Save(ctx, e.MasterDB, rk.Address, assetCode, h)
 		if err != nil {
@@ -789,6 +812,10 @@ func (e *Enforcement) FreezeResponse(ctx context.Context, w *node.ResponseWriter
 		}
 	}
 
+	if err := e.orderApplied(ctx, itx, rk, protocol.NewTimestamp(msg.Timestamp)); err != nil {
+		return err
+	}
+
 	// Save Tx for thaw action.
 	if err := transactions.AddTx(ctx, e.MasterDB, itx); err != nil {
 		return errors.Wrap(err, "Failed to save tx")
@@ -932,7 +959,21 @@ func (e *Enforcement) ThawResponse(ctx context.Context, w *node.ResponseWriter,
 		}
 	}
 
+	if err := e.orderApplied(ctx, itx, rk, protocol.NewTimestamp(msg.Timestamp)); err != nil {
+		return err
+	}
+
+	// The freeze response spends the freeze order.
+	freezeOrderTxId := protocol.TxIdFromBytes(freezeTx.MsgTx.TxIn[0].PreviousOutPoint.Hash[:])
 	txid := protocol.TxIdFromBytes(itx.Hash[:])
+	if err := enforcement.Thawed(ctx, e.MasterDB, rk.Address, freezeOrderTxId, txid,
+		protocol.NewTimestamp(msg.Timestamp)); err != nil {
+		if errors.Cause(err) != enforcement.ErrNotFound {
+			return errors.Wrap(err, "Failed to update freeze order record")
+		}
+		node.LogWarn(ctx, "Freeze order record not found : %s", freezeOrderTxId.String())
+	}
+
 	node.Log(ctx, "Processed Thaw : %s", txid.String())
 	return nil
 }
@@ -1048,6 +1089,10 @@ func (e *Enforcement) ConfiscationResponse(ctx context.Context, w *node.Response
 		e.HoldingsChannel.Add(cacheItem)
 	}
 
+	if err := e.orderApplied(ctx, itx, rk, timestamp); err != nil {
+		return err
+	}
+
 	node.Log(ctx, "Processed Confiscation : %x", msg.AssetCode)
 	return nil
 }
@@ -1135,6 +1180,46 @@ func (e *Enforcement) ReconciliationResponse(ctx context.Context, w *node.Respon
 		e.HoldingsChannel.Add(cacheItem)
 	}
 
+	if err := e.orderApplied(ctx, itx, rk, timestamp); err != nil {
+		return err
+	}
+
 	node.Log(ctx, "Processed Confiscation : %x", msg.AssetCode)
 	return nil
 }
+
+// saveOrder records an order as pending until its response is processed. It is only called after
+//   the response was sent, so orders that were rejected or couldn't be funded aren't recorded.
+func (e *Enforcement) saveOrder(ctx context.Context, itx *inspector.Transaction, rk *wallet.Key,
+	msg *actions.Order, now protocol.Timestamp) error {
+
+	o, err := enforcement.NewOrder(protocol.TxIdFromBytes(itx.Hash[:]), msg, now)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create order record")
+	}
+
+	if err := enforcement.Save(ctx, e.MasterDB, rk.Address, o); err != nil {
+		return errors.Wrap(err, "Failed to save order record")
+	}
+
+	return nil
+}
+
+// orderApplied links a response to the record of the order it spends. Orders accepted before
+//   records were kept don't have one.
+func (e *Enforcement) orderApplied(ctx context.Context, itx *inspector.Transaction,
+	rk *wallet.Key, timestamp protocol.Timestamp) error {
+
+	orderTxId := protocol.TxIdFromBytes(itx.MsgTx.TxIn[0].PreviousOutPoint.Hash[:])
+	responseTxId := protocol.TxIdFromBytes(itx.Hash[:])
+	if err := enforcement.Applied(ctx, e.MasterDB, rk.Address, orderTxId, responseTxId,
+		timestamp); err != nil {
+		if errors.Cause(err) == enforcement.ErrNotFound {
+			node.LogWarn(ctx, "Order record not found : %s", orderTxId.String())
+			return nil
+		}
+		return errors.Wrap(err, "Failed to update order record")
+	}
+
+	return nil
+}
@@ -27,10 +27,12 @@ import (
 )
 
 type Governance struct {
-	handler   protomux.Handler
-	MasterDB  *db.DB
-	Config    *node.Config
-	Scheduler *scheduler.Scheduler
+	handler         protomux.Handler
+	MasterDB        *db.DB
+	Config          *node.Config
+	Headers         node.BitcoinHeaders
+	Scheduler       *scheduler.Scheduler
+	HoldingsChannel *holdings.CacheChannel
 }
 
 // ProposalRequest handles an incoming proposal request and prepares a Vote response
@@ -350,6 +352,15 @@ func (g *Governance) VoteResponse(ctx context.Context, w *node.ResponseWriter, i
 	nv.Timestamp = protocol.NewTimestamp(msg.Timestamp)
 	nv.Ballots = make(map[bitcoin.Hash20]state.Ballot)
 
+	// The quorum and early close are kept with the vote so policy changes don't apply to votes in
+	//   progress.
+	policy, err := contract.FetchGovernancePolicy(ctx, g.MasterDB, rk.Address)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch governance policy")
+	}
+	nv.Quorum = policy.Quorum(proposal.VoteSystem)
+	nv.EarlyClose = policy.ClosesEarly(proposal.VoteSystem)
+
 	if len(proposal.AssetCode) > 0 {
 		as, err := asset.Retrieve(ctx, g.MasterDB, rk.Address,
 			protocol.AssetCodeFromBytes(proposal.AssetCode))
@@ -480,6 +491,11 @@ func (g *Governance) BallotCastRequest(ctx context.Context, w *node.ResponseWrit
 		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsVoteClosed)
 	}
 
+	if vt.ClosedEarlyAt.Nano() != 0 {
+		node.LogWarn(ctx, "Vote closed early : %s : %s", voteTxId.String(), vt.CloseReason)
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsVoteClosed)
+	}
+
 	// Get Proposal
 	hash, err := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
 	proposalTx, err := transactions.GetTx(ctx, g.MasterDB, hash, g.Config.IsTest)
@@ -520,33 +536,32 @@ func (g *Governance) BallotCastRequest(ctx context.Context, w *node.ResponseWrit
 		}
 	}
 
-	addressHash, err := itx.Inputs[0].Address.Hash()
-	if err != nil {
-		node.LogWarn(ctx, "Ballot address not valid : %s",
-			bitcoin.NewAddressFromRawAddress(itx.Inputs[0].Address, g.Config.Net).String())
-		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
-	}
-
-	ballot, exists := vt.Ballots[*addressHash]
-	if !exists {
-		node.LogWarn(ctx, "Ballot address not permitted to vote : %s",
-			bitcoin.NewAddressFromRawAddress(itx.Inputs[0].Address, g.Config.Net).String())
-		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsUnauthorizedAddress)
-	}
+	// Proxies cast the ballots delegated to them along with their own.
+	ballots := vote.CastableBallots(vt, itx.Inputs[0].Address)
+	if len(ballots) == 0 {
+		if !vote.IsVoter(vt, itx.Inputs[0].Address) {
+			node.LogWarn(ctx, "Ballot address not permitted to vote : %s",
+				bitcoin.NewAddressFromRawAddress(itx.Inputs[0].Address, g.Config.Net).String())
+			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsUnauthorizedAddress)
+		}
 
-	if ballot.Timestamp.Nano() != 0 {
 		node.LogWarn(ctx, "Ballot address already voted : %s",
 			bitcoin.NewAddressFromRawAddress(itx.Inputs[0].Address, g.Config.Net).String())
 		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsBallotAlreadyCounted)
 	}
 
+	quantity := uint64(0)
+	for _, ballot := range ballots {
+		quantity += ballot.Quantity
+	}
+
 	// Build Response
 	ballotCounted := actions.BallotCounted{}
 	err = node.Convert(ctx, msg, &ballotCounted)
 	if err != nil {
 		return errors.Wrap(err, "Failed to convert ballot cast to counted")
 	}
-	ballotCounted.Quantity = ballot.Quantity
+	ballotCounted.Quantity = quantity
 	ballotCounted.Timestamp = v.Now.Nano()
 
 	// Build outputs
@@ -563,7 +578,8 @@ func (g *Governance) BallotCastRequest(ctx context.Context, w *node.ResponseWrit
 	// Respond with a vote
 	address := bitcoin.NewAddressFromRawAddress(itx.Inputs[0].Address,
 		w.Config.Net)
-	node.LogWarn(ctx, "Accepting ballot for %d from %s", ballot.Quantity, address.String())
+	node.LogWarn(ctx, "Accepting %d ballots for %d from %s", len(ballots), quantity,
+		address.String())
 	return node.RespondSuccess(ctx, w, itx, rk, &ballotCounted)
 }
 
@@ -612,24 +628,75 @@ func (g *Governance) BallotCountedResponse(ctx context.Context, w *node.Response
 		return errors.Wrap(err, "Failed to retrieve vote for ballot cast")
 	}
 
-	hash, err := castTx.Inputs[0].Address.Hash()
-	if err != nil {
-		return fmt.Errorf("Ballot address not valid : %s",
+	ballots := vote.CastableBallots(vt, castTx.Inputs[0].Address)
+	if len(ballots) == 0 {
+		return fmt.Errorf("Ballot address not permitted to vote : %s",
 			bitcoin.NewAddressFromRawAddress(castTx.Inputs[0].Address, g.Config.Net).String())
 	}
 
-	ballot, exists := vt.Ballots[*hash]
-	if !exists {
-		return fmt.Errorf("Ballot address not permitted to vote : %s",
-			bitcoin.NewAddressFromRawAddress(castTx.Inputs[0].Address, g.Config.Net).String())
+	// Add to vote results
+	for _, ballot := range ballots {
+		ballot.Vote = cast.Vote
+		ballot.Timestamp = protocol.NewTimestamp(msg.Timestamp)
+		ballot.CastByProxy = !ballot.Address.Equal(castTx.Inputs[0].Address)
+		ballot.TxId = protocol.TxIdFromBytes(castTx.Hash[:])
+
+		if err := vote.AddBallot(ctx, g.MasterDB, rk.Address, vt, &ballot, v.Now); err != nil {
+			return errors.Wrap(err, "Failed to add ballot")
+		}
 	}
 
-	ballot.Vote = cast.Vote
-	ballot.Timestamp = protocol.NewTimestamp(msg.Timestamp)
+	if vt.EarlyClose && vt.ClosedEarlyAt.Nano() == 0 {
+		return g.closeEarly(ctx, ct, vt, v.Now)
+	}
 
-	// Add to vote results
-	if err := vote.AddBallot(ctx, g.MasterDB, rk.Address, vt, &ballot, v.Now); err != nil {
-		return errors.Wrap(err, "Failed to add ballot")
+	return nil
+}
+
+// closeEarly finalizes the vote now if no ballots remaining to be cast can change its result.
+func (g *Governance) closeEarly(ctx context.Context, ct *state.Contract, vt *state.Vote,
+	now protocol.Timestamp) error {
+
+	hash, err := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
+	proposalTx, err := transactions.GetTx(ctx, g.MasterDB, hash, g.Config.IsTest)
+	if err != nil {
+		return fmt.Errorf("Proposal not found for vote")
+	}
+
+	proposal, ok := proposalTx.MsgProto.(*actions.Proposal)
+	if !ok {
+		return fmt.Errorf("Proposal invalid for vote")
+	}
+
+	decided, reason := vote.Decided(vt, proposal, ct.VotingSystems[proposal.VoteSystem])
+	if !decided {
+		return nil
+	}
+
+	hash, err = bitcoin.NewHash32(vt.VoteTxId.Bytes())
+	voteTx, err := transactions.GetTx(ctx, g.MasterDB, hash, g.Config.IsTest)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve vote tx")
+	}
+
+	uv := vote.UpdateVote{
+		ClosedEarlyAt: &now,
+		CloseReason:   &reason,
+	}
+	if err := vote.Update(ctx, g.MasterDB, ct.Address, vt.VoteTxId, &uv, now); err != nil {
+		return errors.Wrap(err, "Failed to update vote")
+	}
+
+	node.Log(ctx, "Closing vote early : %s", reason)
+
+	// Replace the finalizer scheduled for the cut off with one that is ready now.
+	if err := g.Scheduler.CancelJob(ctx, listeners.NewVoteFinalizer(g.handler, voteTx,
+		vt.Expires)); err != nil {
+		return errors.Wrap(err, "Failed to cancel vote finalizer")
+	}
+	if err := g.Scheduler.ScheduleJob(ctx, listeners.NewVoteFinalizer(g.handler, voteTx,
+		now)); err != nil {
+		return errors.Wrap(err, "Failed to schedule vote finalizer")
 	}
 
 	return nil
@@ -754,6 +821,46 @@ func (g *Governance) ResultResponse(ctx context.Context, w *node.ResponseWriter,
 	uv.CompletedAt = &ts
 
 	voteTxId := protocol.TxIdFromBytes(msg.VoteTxId)
+
+	vt, err := vote.Retrieve(ctx, g.MasterDB, rk.Address, voteTxId)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve vote")
+	}
+
+	turnout := vote.Turnout(vt)
+	noQuorum := !vote.HasQuorum(vt)
+	uv.Turnout = &turnout
+	uv.NoQuorum = &noQuorum
+
+	// Commit to the final ballots so holders can verify their ballot was counted.
+	ballotRoot, err := vote.BallotRoot(vt)
+	if err != nil {
+		return errors.Wrap(err, "ballot root")
+	}
+	uv.BallotRoot = ballotRoot
+
+	// Save the rounds of instant runoff votes so the result can be explained to holders.
+	if int(vt.VoteSystem) < len(ct.VotingSystems) &&
+		ct.VotingSystems[vt.VoteSystem].TallyLogic == vote.TallyLogicInstantRunoff {
+		hash, err := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "proposal txid")
+		}
+
+		proposalTx, err := transactions.GetTx(ctx, g.MasterDB, hash, g.Config.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "Failed to retrieve proposal")
+		}
+
+		proposal, ok := proposalTx.MsgProto.(*actions.Proposal)
+		if !ok {
+			return fmt.Errorf("Proposal invalid for vote")
+		}
+
+		rounds := vote.InstantRunoffRounds(vt, proposal)
+		uv.Rounds = &rounds
+	}
+
 	if err := vote.Update(ctx, g.MasterDB, rk.Address, voteTxId, &uv, v.Now); err != nil {
 		return errors.Wrap(err, "Failed to update vote")
 	}
@@ -765,5 +872,163 @@ func (g *Governance) ResultResponse(ctx context.Context, w *node.ResponseWriter,
 		}
 	}
 
+	return g.applyAmendments(ctx, w, itx, rk, ct, voteTxId)
+}
+
+// applyAmendments responds to the result of a passed proposal with its amendments when the
+//   governance policy applies them automatically for the voting system. The response spends the
+//   result's contract output, so it references the result, and is funded by a third contract
+//   output of the proposal tx. Amendments that can't be applied are left for the administration
+//   to apply with an amendment request.
+func (g *Governance) applyAmendments(ctx context.Context, w *node.ResponseWriter,
+	itx *inspector.Transaction, rk *wallet.Key, ct *state.Contract,
+	voteTxId *protocol.TxId) error {
+
+	vt, err := vote.Retrieve(ctx, g.MasterDB, rk.Address, voteTxId)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve vote")
+	}
+
+	if vt.Result != "A" || len(vt.ProposedAmendments) == 0 || vt.AppliedTxId != nil {
+		return nil
+	}
+
+	policy, err := contract.FetchGovernancePolicy(ctx, g.MasterDB, rk.Address)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch governance policy")
+	}
+
+	if !policy.AutoApplies(vt.VoteSystem) {
+		return nil
+	}
+
+	hash, err := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
+	if err != nil {
+		return errors.Wrap(err, "proposal txid")
+	}
+
+	proposalTx, err := transactions.GetTx(ctx, g.MasterDB, hash, g.Config.IsTest)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve proposal")
+	}
+
+	if len(proposalTx.Outputs) < 3 || !proposalTx.Outputs[2].Address.Equal(rk.Address) {
+		node.LogWarn(ctx, "Proposal doesn't fund automatic amendment : %s", hash.String())
+		return nil
+	}
+
+	if !itx.Outputs[0].Address.Equal(rk.Address) {
+		return fmt.Errorf("Vote result first output not to contract")
+	}
+
+	w.SetUTXOs(ctx, []bitcoin.UTXO{itx.Outputs[0].UTXO, proposalTx.Outputs[2].UTXO})
+
+	if vt.AssetCode == nil || vt.AssetCode.IsZero() {
+		return g.applyContractAmendments(ctx, w, itx, rk, ct, vt)
+	}
+	return g.applyAssetAmendments(ctx, w, itx, rk, ct, vt)
+}
+
+// applyContractAmendments responds with a contract formation containing the amendments of a vote.
+func (g *Governance) applyContractAmendments(ctx context.Context, w *node.ResponseWriter,
+	itx *inspector.Transaction, rk *wallet.Key, ct *state.Contract, vt *state.Vote) error {
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	cf, err := contract.FetchContractFormation(ctx, g.MasterDB, rk.Address, g.Config.IsTest)
+	if err != nil {
+		return errors.Wrap(err, "fetch contract formation")
+	}
+
+	if err := applyContractAmendments(cf, vt.ProposedAmendments, true, vt.Type,
+		vt.VoteSystem); err != nil {
+		node.LogWarn(ctx, "Failed to apply amendments of vote %s : %s", vt.VoteTxId.String(),
+			err)
+		return nil
+	}
+
+	if err := verifyContractFormation(ctx, g.MasterDB, g.Headers, ct, cf,
+		g.Config.IsTest); err != nil {
+		if _, ok := node.ErrorCode(err); !ok {
+			return errors.Wrap(err, "verify formation")
+		}
+		node.LogWarn(ctx, "Amendments of vote %s invalid : %s", vt.VoteTxId.String(), err)
+		return nil
+	}
+
+	cf.ContractRevision = ct.Revision + 1 // Bump the revision
+	cf.Timestamp = v.Now.Nano()
+
+	// Build outputs
+	// 1 - Contract Address
+	// 2 - Contract Fee (change)
+	w.AddOutput(ctx, rk.Address, 0)
+	w.AddContractFee(ctx, ct.ContractFee)
+
+	node.Log(ctx, "Applying contract amendments of vote : %s", vt.VoteTxId.String())
+
+	if err := node.RespondSuccess(ctx, w, itx, rk, cf); err != nil {
+		return err
+	}
+	return contract.SaveContractFormation(ctx, g.MasterDB, rk.Address, cf, g.Config.IsTest)
+}
+
+// applyAssetAmendments responds with an asset creation containing the amendments of a vote.
+func (g *Governance) applyAssetAmendments(ctx context.Context, w *node.ResponseWriter,
+	itx *inspector.Transaction, rk *wallet.Key, ct *state.Contract, vt *state.Vote) error {
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	as, err := asset.Retrieve(ctx, g.MasterDB, rk.Address, vt.AssetCode)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve asset")
+	}
+
+	ac := actions.AssetCreation{}
+	if err := node.Convert(ctx, as, &ac); err != nil {
+		return errors.Wrap(err, "Failed to convert state asset to asset creation")
+	}
+
+	ac.AssetRevision = as.Revision + 1
+	ac.Timestamp = v.Now.Nano()
+	ac.AssetCode = vt.AssetCode.Bytes() // Asset code not in state data
+
+	if err := applyAssetAmendments(&ac, ct.VotingSystems, vt.ProposedAmendments, true, vt.Type,
+		vt.VoteSystem); err != nil {
+		node.LogWarn(ctx, "Failed to apply amendments of vote %s : %s", vt.VoteTxId.String(),
+			err)
+		return nil
+	}
+
+	h, err := adjustAdminHoldings(ctx, g.MasterDB, rk.Address, ct, as, &ac,
+		protocol.TxIdFromBytes(itx.Hash[:]), v.Now)
+	if err != nil {
+		if _, ok := node.ErrorCode(err); !ok {
+			return errors.Wrap(err, "adjust admin holdings")
+		}
+		node.LogWarn(ctx, "Amendments of vote %s invalid : %s", vt.VoteTxId.String(), err)
+		return nil
+	}
+
+	// Build outputs
+	// 1 - Contract Address
+	// 2 - Contract Fee (change)
+	w.AddOutput(ctx, rk.Address, 0)
+	w.AddContractFee(ctx, ct.ContractFee)
+
+	node.Log(ctx, "Applying asset amendments of vote : %s", vt.VoteTxId.String())
+
+	if err := node.RespondSuccess(ctx, w, itx, rk, &ac); err != nil {
+		return errors.Wrap(err, "Failed to respond")
+	}
+
+	if h != nil {
+		cacheItem, err := holdings.Save(ctx, g.MasterDB, rk.Address, vt.AssetCode, h)
+		if err != nil {
+			return errors.Wrap(err, "Failed to save holdings")
+		}
+		g.HoldingsChannel.Add(cacheItem)
+	}
+
 	return nil
 }
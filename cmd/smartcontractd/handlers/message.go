@@ -13,7 +13,9 @@ import (
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
 	"github.com/tokenized/smart-contract/internal/asset"
 	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/delegation"
 	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/notice"
 	"github.com/tokenized/smart-contract/internal/platform/db"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/state"
@@ -80,6 +82,18 @@ func (m *Message) ProcessMessage(ctx context.Context, w *node.ResponseWriter,
 		return nil // Message not addressed to contract.
 	}
 
+	// Delegations aren't in the protocol specification so they are decoded separately.
+	if msg.MessageCode == delegation.MessageCode {
+		node.LogVerbose(ctx, "Processing Delegation")
+		return m.processDelegation(ctx, w, itx, msg, rk)
+	}
+
+	// Notices aren't in the protocol specification either.
+	if msg.MessageCode == notice.MessageCode {
+		node.LogVerbose(ctx, "Processing Notice")
+		return m.processNotice(ctx, w, itx, msg, rk)
+	}
+
 	messagePayload, err := messages.Deserialize(msg.MessageCode, msg.MessagePayload)
 	if err != nil {
 		return errors.Wrap(err, "Failed to deserialize message payload")
@@ -179,6 +193,10 @@ func (m *Message) ProcessRevert(ctx context.Context, w *node.ResponseWriter,
 	ctx, span := trace.StartSpan(ctx, "handlers.Message.ProcessRevert")
 	defer span.End()
 
+	if err := m.revertRequestStatuses(ctx, itx, rk); err != nil {
+		return errors.Wrap(err, "Failed to revert holding statuses")
+	}
+
 	// Serialize tx for Message OP_RETURN.
 	var txBuf bytes.Buffer
 	err := itx.MsgTx.Serialize(&txBuf)
@@ -211,7 +229,8 @@ func (m *Message) ProcessRevert(ctx context.Context, w *node.ResponseWriter,
 	}
 
 	// Create tx
-	tx := txbuilder.NewTxBuilder(m.Config.FeeRate, m.Config.DustFeeRate)
+	tx := txbuilder.NewTxBuilder(m.Config.Fees.FeeRate(rk.Address, message.Code()),
+		m.Config.Fees.DustFeeRate())
 	tx.SetChangeAddress(rk.Address, "")
 
 	// Add outputs to administration/operator
@@ -234,43 +253,171 @@ func (m *Message) ProcessRevert(ctx context.Context, w *node.ResponseWriter,
 	// Estimate fee with 2 inputs
 	amount := tx.EstimatedFee() + outputAmount + (2 * txbuilder.MaximumP2PKHInputSize)
 
+	// The UTXOs are reserved until the response is seen spending them, so another response can't
+	//   select them first.
+	var spent []*utxos.UTXO
 	for {
-		utxos, err := m.UTXOs.Get(amount, rk.Address)
+		spent, err = m.UTXOs.Select(amount, rk.Address, m.selectOptions(ctx, rk.Address))
 		if err != nil {
 			return errors.Wrap(err, "Failed to get UTXOs")
 		}
 
-		for _, utxo := range utxos {
+		for _, utxo := range spent {
 			if err := tx.AddInput(utxo.OutPoint, utxo.Output.PkScript,
 				uint64(utxo.Output.Value)); err != nil {
+				m.UTXOs.Release(spent)
 				return errors.Wrap(err, "Failed add input")
 			}
 		}
 
-		err = tx.Sign([]bitcoin.Key{rk.Key})
+		err = w.Signer.Sign(ctx, tx, rk.Address)
 		if err == nil {
 			break
 		}
-		if errors.Cause(err) == txbuilder.ErrInsufficientValue {
-			// Get more utxos
-			amount = uint64(float32(amount) * 1.25)
-			utxos, err = m.UTXOs.Get(amount, rk.Address)
-			if err != nil {
-				return errors.Wrap(err, "Failed to get UTXOs")
-			}
 
-			// Clear inputs
-			tx.Inputs = nil
+		m.UTXOs.Release(spent)
+		if errors.Cause(err) != txbuilder.ErrInsufficientValue {
+			return errors.Wrap(err, "Failed to sign tx")
 		}
+
+		// Get more utxos
+		amount = uint64(float32(amount) * 1.25)
+
+		// Clear inputs
+		tx.Inputs = nil
+		tx.MsgTx.TxIn = nil
 	}
 
 	responseItx, err := inspector.NewTransactionFromTxBuilder(ctx, tx, m.Config.IsTest)
 	if err != nil {
+		m.UTXOs.Release(spent)
 		return errors.Wrap(err, "inspector from builder")
 	}
 
 	// Send tx
-	return node.Respond(ctx, w, responseItx)
+	if err := node.Respond(ctx, w, responseItx); err != nil {
+		m.UTXOs.Release(spent)
+		return err
+	}
+
+	return nil
+}
+
+// revertRequestStatuses reverts the holding statuses added by a reverted transfer or order
+//   request. They would otherwise stay pending when the response was reverted before it was seen.
+//   Statuses already finalized by the response aren't found and are skipped.
+func (m *Message) revertRequestStatuses(ctx context.Context, itx *inspector.Transaction,
+	rk *wallet.Key) error {
+
+	addresses := make(map[protocol.AssetCode][]bitcoin.RawAddress)
+	switch msg := itx.MsgProto.(type) {
+	case *actions.Transfer:
+		for _, assetTransfer := range msg.Assets {
+			if int(assetTransfer.ContractIndex) >= len(itx.Outputs) ||
+				!itx.Outputs[assetTransfer.ContractIndex].Address.Equal(rk.Address) {
+				continue // This asset is not ours. Skip it.
+			}
+
+			assetCode := *protocol.AssetCodeFromBytes(assetTransfer.AssetCode)
+			for _, sender := range assetTransfer.AssetSenders {
+				if int(sender.Index) < len(itx.Inputs) {
+					addresses[assetCode] = append(addresses[assetCode],
+						itx.Inputs[sender.Index].Address)
+				}
+			}
+
+			for _, receiver := range assetTransfer.AssetReceivers {
+				receiverAddress, err := bitcoin.DecodeRawAddress(receiver.Address)
+				if err == nil {
+					addresses[assetCode] = append(addresses[assetCode], receiverAddress)
+				}
+			}
+		}
+
+	case *actions.Order:
+		// Only confiscation and reconciliation orders add statuses keyed by the order. Freeze
+		//   statuses are keyed by the freeze response and thaws don't add any.
+		if (msg.ComplianceAction != actions.ComplianceActionConfiscation &&
+			msg.ComplianceAction != actions.ComplianceActionReconciliation) ||
+			len(msg.AssetCode) == 0 {
+			return nil
+		}
+
+		assetCode := *protocol.AssetCodeFromBytes(msg.AssetCode)
+		for _, target := range msg.TargetAddresses {
+			targetAddress, err := bitcoin.DecodeRawAddress(target.Address)
+			if err == nil {
+				addresses[assetCode] = append(addresses[assetCode], targetAddress)
+			}
+		}
+
+		if msg.ComplianceAction == actions.ComplianceActionConfiscation {
+			depositAddress, err := bitcoin.DecodeRawAddress(msg.DepositAddress)
+			if err == nil {
+				addresses[assetCode] = append(addresses[assetCode], depositAddress)
+			}
+		}
+
+	default:
+		return nil
+	}
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+	txid := protocol.TxIdFromBytes(itx.Hash[:])
+	updates := make(map[protocol.AssetCode]*map[bitcoin.Hash20]*state.Holding)
+	for assetCode, assetAddresses := range addresses {
+		assetCode := assetCode
+		updatedHoldings := make(map[bitcoin.Hash20]*state.Holding)
+		for _, address := range assetAddresses {
+			h, err := holdings.GetHolding(ctx, m.MasterDB, rk.Address, &assetCode, address, v.Now)
+			if err != nil {
+				return errors.Wrap(err, "get holding")
+			}
+
+			if _, exists := h.HoldingStatuses[*txid]; !exists {
+				continue
+			}
+
+			if err := holdings.RevertStatus(h, txid); err != nil {
+				return errors.Wrap(err, "revert status")
+			}
+
+			hash, err := address.Hash()
+			if err != nil {
+				return errors.Wrap(err, "address hash")
+			}
+			updatedHoldings[*hash] = h
+		}
+
+		if len(updatedHoldings) > 0 {
+			updates[assetCode] = &updatedHoldings
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	node.Log(ctx, "Reverting holding statuses of reverted request : %s", itx.Hash.String())
+	return saveHoldings(ctx, m.MasterDB, m.HoldingsChannel, updates, rk.Address)
+}
+
+// selectOptions returns the options for selecting UTXOs to fund a response from the contract.
+func (m *Message) selectOptions(ctx context.Context,
+	contractAddress bitcoin.RawAddress) utxos.SelectOptions {
+	result := utxos.SelectOptions{
+		Strategy:         m.Config.UTXOStrategy,
+		MinConfirmations: m.Config.UTXOMinConfirmations,
+		CostOfChange: uint64(float32(txbuilder.P2PKHOutputSize+txbuilder.MaximumP2PKHInputSize) *
+			m.Config.Fees.FeeRate(contractAddress, actions.CodeMessage)),
+		Reserve: true,
+	}
+
+	if m.Headers != nil {
+		result.Height = m.Headers.LastHeight(ctx)
+	}
+
+	return result
 }
 
 // processSettlementRequest handles an incoming Message SettlementRequest payload.
@@ -336,7 +483,7 @@ func (m *Message) processSettlementRequest(ctx context.Context, w *node.Response
 	contractBalance := firstContractOutput.UTXO.Value
 
 	// Build settle tx
-	settleTx, err := buildSettlementTx(ctx, m.MasterDB, m.Config, transferTx, transfer,
+	settleTx, err := buildSettlementTx(ctx, m.MasterDB, m.Config, w, transferTx, transfer,
 		settlementRequest, contractBalance, rk)
 	if err != nil {
 		return errors.Wrap(err, "Failed to build settle tx")
@@ -382,6 +529,17 @@ func (m *Message) processSettlementRequest(ctx context.Context, w *node.Response
 			actions.RejectionsContractExpired, "Contract expired")
 	}
 
+	if err := m.checkTransferPolicy(ctx, transferTx, transfer, rk,
+		contract.InteractionSettlementRequest); err != nil {
+		rejectCode, ok := node.ErrorCode(err)
+		if !ok {
+			return err
+		}
+		node.LogWarn(ctx, "Rejecting Transfer : %s", err)
+		return m.respondTransferMessageReject(ctx, w, itx, transferTx, transfer, rk, rejectCode,
+			"")
+	}
+
 	// Check Oracle Signature
 	if transferTx.RejectCode != 0 {
 		return m.respondTransferMessageReject(ctx, w, itx, transferTx, transfer, rk,
@@ -416,21 +574,15 @@ func (m *Message) processSettlementRequest(ctx context.Context, w *node.Response
 	// Check if settlement data is complete. No further contracts involved.
 	if settlementIsComplete(ctx, transfer, settlement) {
 		// Sign this contracts input of the settle tx.
-		signed := false
-		var sigHashCache txbuilder.SigHashCache
-		for i, _ := range settleTx.Inputs {
-			err = settleTx.SignP2PKHInput(i, rk.Key, &sigHashCache)
-			if errors.Cause(err) == txbuilder.ErrWrongPrivateKey {
-				continue
-			}
-			if err != nil {
-				return err
-			}
+		signed, err := w.Signer.SignInputs(ctx, settleTx, rk.Address)
+		if err != nil {
+			return err
+		}
+		for _, i := range signed {
 			node.LogVerbose(ctx, "Signed settlement input %d", i)
-			signed = true
 		}
 
-		if !signed {
+		if len(signed) == 0 {
 			return errors.New("Failed to find input to sign")
 		}
 
@@ -498,6 +650,278 @@ func settlementIsComplete(ctx context.Context, transfer *actions.Transfer,
 	return true
 }
 
+// processDelegation handles an incoming Message delegation payload. It sets or revokes the
+//   delegation of the holder at the first input. There is no response tx.
+func (m *Message) processDelegation(ctx context.Context, w *node.ResponseWriter,
+	itx *inspector.Transaction, msg *actions.Message, rk *wallet.Key) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.Message.processDelegation")
+	defer span.End()
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	payload, err := delegation.DeserializeMessage(msg.MessagePayload)
+	if err != nil {
+		node.LogWarn(ctx, "Delegation payload is invalid : %s", err)
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+
+	ct, err := contract.Retrieve(ctx, m.MasterDB, rk.Address, m.Config.IsTest)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve contract")
+	}
+
+	if !ct.MovedTo.IsEmpty() {
+		address := bitcoin.NewAddressFromRawAddress(ct.MovedTo, w.Config.Net)
+		node.LogWarn(ctx, "Contract address changed : %s", address.String())
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsContractMoved)
+	}
+
+	delegator := itx.Inputs[0].Address
+	if payload.Proxy.Equal(delegator) {
+		node.LogWarn(ctx, "Delegation proxy is the delegator")
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+
+	if payload.Expires.Nano() != 0 && payload.Expires.Nano() <= v.Now.Nano() {
+		node.LogWarn(ctx, "Delegation expired : %s", payload.Expires.String())
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+
+	if payload.AssetCode != nil {
+		if _, err := asset.Retrieve(ctx, m.MasterDB, rk.Address, payload.AssetCode); err != nil {
+			node.LogWarn(ctx, "Delegation asset not found : %s", payload.AssetCode.String())
+			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsAssetNotFound)
+		}
+	}
+
+	address := bitcoin.NewAddressFromRawAddress(delegator, w.Config.Net)
+
+	if payload.Proxy.IsEmpty() {
+		err := delegation.Remove(ctx, m.MasterDB, rk.Address, delegator, payload.AssetCode)
+		if err != nil && err != delegation.ErrNotFound {
+			return errors.Wrap(err, "Failed to remove delegation")
+		}
+		node.Log(ctx, "Revoked delegation of %s", address.String())
+		return nil
+	}
+
+	d := &state.Delegation{
+		Delegator: delegator,
+		Proxy:     payload.Proxy,
+		AssetCode: payload.AssetCode,
+		Expires:   payload.Expires,
+		TxId:      protocol.TxIdFromBytes(itx.Hash[:]),
+		Timestamp: v.Now,
+	}
+	if err := delegation.Save(ctx, m.MasterDB, rk.Address, d); err != nil {
+		return errors.Wrap(err, "Failed to save delegation")
+	}
+
+	node.Log(ctx, "Delegated votes of %s to %s", address.String(),
+		bitcoin.NewAddressFromRawAddress(payload.Proxy, w.Config.Net).String())
+	return nil
+}
+
+// processNotice handles a request from the administration to send a public message to holders.
+//   The message is sent to the holders in batches, each in a Message tx that is funded by the
+//   change of the previous one, so the request funds all of them.
+func (m *Message) processNotice(ctx context.Context, w *node.ResponseWriter,
+	itx *inspector.Transaction, msg *actions.Message, rk *wallet.Key) error {
+	ctx, span := trace.StartSpan(ctx, "handlers.Message.processNotice")
+	defer span.End()
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	payload, err := notice.DeserializeMessage(msg.MessagePayload)
+	if err != nil {
+		node.LogWarn(ctx, "Notice payload is invalid : %s", err)
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+
+	publicMessage, err := messages.Deserialize(messages.CodePublicMessage, payload.Payload)
+	if err != nil {
+		node.LogWarn(ctx, "Notice public message is invalid : %s", err)
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+	if err := publicMessage.Validate(); err != nil {
+		node.LogWarn(ctx, "Notice public message is invalid : %s", err)
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsMsgMalformed)
+	}
+
+	ct, err := contract.Retrieve(ctx, m.MasterDB, rk.Address, m.Config.IsTest)
+	if err != nil {
+		return errors.Wrap(err, "Failed to retrieve contract")
+	}
+
+	if !ct.MovedTo.IsEmpty() {
+		address := bitcoin.NewAddressFromRawAddress(ct.MovedTo, w.Config.Net)
+		node.LogWarn(ctx, "Contract address changed : %s", address.String())
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsContractMoved)
+	}
+
+	if !contract.IsOperator(ctx, ct, itx.Inputs[0].Address) {
+		node.LogWarn(ctx, "Notice not from administration or operator")
+		return node.RespondReject(ctx, w, itx, rk, actions.RejectionsNotOperator)
+	}
+
+	assetCodes := ct.AssetCodes
+	if payload.AssetCode != nil {
+		if _, err := asset.Retrieve(ctx, m.MasterDB, rk.Address, payload.AssetCode); err != nil {
+			node.LogWarn(ctx, "Notice asset not found : %s", payload.AssetCode.String())
+			return node.RespondReject(ctx, w, itx, rk, actions.RejectionsAssetNotFound)
+		}
+		assetCodes = []*protocol.AssetCode{payload.AssetCode}
+	}
+
+	hash := notice.Hash(payload.AssetCode, payload.Payload)
+	if _, err := notice.Fetch(ctx, m.MasterDB, rk.Address, hash); err == nil {
+		node.LogWarn(ctx, "Notice already sent : %s", hash.String())
+		return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsContractNotPermitted,
+			"Notice already sent")
+	} else if err != notice.ErrNotFound {
+		return errors.Wrap(err, "Failed to fetch notice")
+	}
+
+	recipients, err := notice.Recipients(ctx, m.MasterDB, rk.Address, assetCodes)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get notice recipients")
+	}
+	if len(recipients) == 0 {
+		node.LogWarn(ctx, "Notice has no recipients")
+		return node.RespondRejectText(ctx, w, itx, rk, actions.RejectionsContractNotPermitted,
+			"No holders to send notice to")
+	}
+
+	funding, err := itx.UTXOs().ForAddress(rk.Address)
+	if err != nil {
+		return errors.Wrap(err, "Failed to get request UTXOs")
+	}
+
+	// Build all of the txs before sending any, so an underfunded request is rejected instead of
+	//   partially sent.
+	var responses []*inspector.Transaction
+	batches := notice.Batches(recipients, m.Config.NoticeBatchSize)
+	for i, batch := range batches {
+		last := i == len(batches)-1
+
+		tx, err := m.buildNoticeTx(ctx, w, rk, ct, funding, batch, payload.Payload, i == 0, last)
+		if err != nil {
+			if errors.Cause(err) == txbuilder.ErrInsufficientValue {
+				node.LogWarn(ctx, "Notice funding insufficient for %d recipients : %s",
+					len(recipients), err)
+				return node.RespondRejectText(ctx, w, itx, rk,
+					actions.RejectionsInsufficientTxFeeFunding, err.Error())
+			}
+			return errors.Wrap(err, "Failed to build notice tx")
+		}
+
+		responseItx, err := inspector.NewTransactionFromTxBuilder(ctx, tx, m.Config.IsTest)
+		if err != nil {
+			return errors.Wrap(err, "inspector from builder")
+		}
+		responses = append(responses, responseItx)
+
+		if !last {
+			// The change output follows the recipients and the contract fee.
+			change := len(batch)
+			if i == 0 && ct.ContractFee > 0 {
+				change++
+			}
+			if change >= len(tx.Outputs) || !tx.Outputs[change].IsRemainder {
+				return fmt.Errorf("Notice change output missing")
+			}
+			funding = []bitcoin.UTXO{{
+				Hash:          *tx.MsgTx.TxHash(),
+				Index:         uint32(change),
+				Value:         uint64(tx.MsgTx.TxOut[change].Value),
+				LockingScript: tx.MsgTx.TxOut[change].PkScript,
+			}}
+		}
+	}
+
+	n := &state.Notice{
+		Hash:        hash,
+		AssetCode:   payload.AssetCode,
+		RequestTxId: protocol.TxIdFromBytes(itx.Hash[:]),
+		Recipients:  recipients,
+		Timestamp:   v.Now,
+	}
+	for i, responseItx := range responses {
+		if i == 0 {
+			w.RecordContractFee(ct.ContractFee) // Only the first tx pays the contract fee.
+		}
+		if err := node.Respond(ctx, w, responseItx); err != nil {
+			return errors.Wrap(err, "Failed to send notice")
+		}
+		w.ContractFee = 0
+		n.TxIds = append(n.TxIds, protocol.TxIdFromBytes(responseItx.Hash[:]))
+	}
+
+	if err := notice.Save(ctx, m.MasterDB, rk.Address, n); err != nil {
+		return errors.Wrap(err, "Failed to save notice")
+	}
+
+	node.Log(ctx, "Sent notice %s to %d holders in %d txs", hash.String(), len(recipients),
+		len(responses))
+	return nil
+}
+
+// buildNoticeTx builds a Message tx that sends the public message to a batch of holders. The
+//   change of all but the last tx goes back to the contract to fund the next one.
+func (m *Message) buildNoticeTx(ctx context.Context, w *node.ResponseWriter, rk *wallet.Key,
+	ct *state.Contract, funding []bitcoin.UTXO, recipients []bitcoin.RawAddress,
+	publicMessage []byte, first, last bool) (*txbuilder.TxBuilder, error) {
+
+	message := actions.Message{
+		MessageCode:    messages.CodePublicMessage,
+		MessagePayload: publicMessage,
+	}
+
+	tx := txbuilder.NewTxBuilder(m.Config.Fees.FeeRate(rk.Address, message.Code()),
+		m.Config.Fees.DustFeeRate())
+
+	for _, utxo := range funding {
+		if err := tx.AddInputUTXO(utxo); err != nil {
+			return nil, errors.Wrap(err, "add input")
+		}
+	}
+
+	for i, recipient := range recipients {
+		if err := tx.AddDustOutput(recipient, false); err != nil {
+			return nil, errors.Wrap(err, "add recipient")
+		}
+		message.ReceiverIndexes = append(message.ReceiverIndexes, uint32(i))
+	}
+
+	if first && ct.ContractFee > 0 {
+		if err := tx.AddPaymentOutput(m.Config.FeeAddress, ct.ContractFee, false); err != nil {
+			return nil, errors.Wrap(err, "add contract fee")
+		}
+	}
+
+	changeAddress := rk.Address
+	if last {
+		changeAddress = m.Config.FeeAddress
+	}
+	if err := tx.AddDustOutput(changeAddress, true); err != nil {
+		return nil, errors.Wrap(err, "add change")
+	}
+
+	script, err := protocol.Serialize(&message, m.Config.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "serialize message")
+	}
+	if err := tx.AddOutput(script, 0, false, false); err != nil {
+		return nil, errors.Wrap(err, "add message")
+	}
+
+	if err := w.Signer.Sign(ctx, tx, rk.Address); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
 // processSigRequest handles an incoming Message SignatureRequest payload.
 func (m *Message) processSigRequest(ctx context.Context, w *node.ResponseWriter,
 	itx *inspector.Transaction, sigRequest *messages.SignatureRequest, rk *wallet.Key) error {
@@ -573,6 +997,17 @@ func (m *Message) processSigRequestSettlement(ctx context.Context, w *node.Respo
 			actions.RejectionsContractExpired, "Contract expired")
 	}
 
+	if err := m.checkTransferPolicy(ctx, transferTx, transferMsg, rk,
+		contract.InteractionSignatureRequest); err != nil {
+		rejectCode, ok := node.ErrorCode(err)
+		if !ok {
+			return err
+		}
+		node.LogWarn(ctx, "Rejecting Transfer : %s", err)
+		return m.respondTransferMessageReject(ctx, w, itx, transferTx, transferMsg, rk,
+			rejectCode, "")
+	}
+
 	// Verify all the data for this contract is correct.
 	err = verifySettlement(ctx, w.Config, m.MasterDB, rk, transferTx, transferMsg, settleWireTx,
 		settlement, m.Headers)
@@ -589,7 +1024,8 @@ func (m *Message) processSigRequestSettlement(ctx context.Context, w *node.Respo
 
 	// Convert settle tx to a txbuilder tx
 	var settleTx *txbuilder.TxBuilder
-	settleTx, err = txbuilder.NewTxBuilderFromWire(m.Config.FeeRate, m.Config.DustFeeRate,
+	settleTx, err = txbuilder.NewTxBuilderFromWire(m.Config.Fees.FeeRate(rk.Address, actions.CodeSettlement),
+		m.Config.Fees.DustFeeRate(),
 		settleWireTx, []*wire.MsgTx{transferTx.MsgTx})
 	settleTx.SetChangeAddress(rk.Address, "")
 	if err != nil {
@@ -597,21 +1033,15 @@ func (m *Message) processSigRequestSettlement(ctx context.Context, w *node.Respo
 	}
 
 	// Sign this contracts input of the settle tx.
-	signed := false
-	var hashCache txbuilder.SigHashCache
-	for i, _ := range settleTx.Inputs {
-		err = settleTx.SignP2PKHInput(i, rk.Key, &hashCache)
-		if errors.Cause(err) == txbuilder.ErrWrongPrivateKey {
-			continue
-		}
-		if err != nil {
-			return err
-		}
+	signed, err := w.Signer.SignInputs(ctx, settleTx, rk.Address)
+	if err != nil {
+		return err
+	}
+	for _, i := range signed {
 		node.LogVerbose(ctx, "Signed settlement input %d", i)
-		signed = true
 	}
 
-	if !signed {
+	if len(signed) == 0 {
 		return errors.New("Failed to find input to sign")
 	}
 
@@ -656,6 +1086,19 @@ func (m *Message) processSigRequestSettlement(ctx context.Context, w *node.Respo
 	return sendToPreviousSettlementContract(ctx, m.Config, w, rk, itx, settleTx)
 }
 
+// checkTransferPolicy returns a rejection error if this contract's transfer policy doesn't permit
+//   the other contracts involved in the transfer, and records the interactions.
+func (m *Message) checkTransferPolicy(ctx context.Context, transferTx *inspector.Transaction,
+	transfer *actions.Transfer, rk *wallet.Key, kind string) error {
+
+	policy, err := contract.FetchTransferPolicy(ctx, m.MasterDB, rk.Address)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch transfer policy")
+	}
+
+	return checkTransferPolicy(ctx, m.MasterDB, m.Config, policy, transferTx, transfer, rk, kind)
+}
+
 // sendToPreviousSettlementContract sends the completed settlement tx to the previous contract involved so it can sign it.
 func sendToPreviousSettlementContract(ctx context.Context, config *node.Config, w *node.ResponseWriter,
 	rk *wallet.Key, itx *inspector.Transaction, settleTx *txbuilder.TxBuilder) error {
@@ -18,6 +18,7 @@ import (
 func API(
 	ctx context.Context,
 	masterWallet wallet.WalletInterface,
+	signer wallet.Signer,
 	config *node.Config,
 	masterDB *db.DB,
 	tracer *filters.Tracer,
@@ -27,7 +28,7 @@ func API(
 	holdingsChannel *holdings.CacheChannel,
 ) (protomux.Handler, error) {
 
-	app := node.New(config, masterDB, masterWallet)
+	app := node.New(config, masterDB, masterWallet, signer)
 
 	// Register contract based events.
 	c := Contract{
@@ -82,10 +83,12 @@ func API(
 
 	// Register enforcement based events.
 	g := Governance{
-		handler:   app,
-		MasterDB:  masterDB,
-		Config:    config,
-		Scheduler: sch,
+		handler:         app,
+		MasterDB:        masterDB,
+		Config:          config,
+		Headers:         headers,
+		Scheduler:       sch,
+		HoldingsChannel: holdingsChannel,
 	}
 
 	app.Handle("SEE", actions.CodeProposal, g.ProposalRequest)
@@ -125,6 +125,22 @@ func (t *Transfer) TransferRequest(ctx context.Context, w *node.ResponseWriter,
 			actions.RejectionsContractExpired, false, "Contract expired")
 	}
 
+	policy, err := contract.FetchTransferPolicy(ctx, t.MasterDB, rk.Address)
+	if err != nil {
+		return errors.Wrap(err, "Failed to fetch transfer policy")
+	}
+
+	if err := checkTransferPolicy(ctx, t.MasterDB, t.Config, policy, itx, msg, rk,
+		contract.InteractionTransfer); err != nil {
+		rejectCode, ok := node.ErrorCode(err)
+		if !ok {
+			return err
+		}
+		node.LogWarn(ctx, "Rejecting Transfer : %s", err)
+		return respondTransferReject(ctx, t.MasterDB, t.HoldingsChannel, t.Config, w, itx, msg, rk,
+			rejectCode, false, "")
+	}
+
 	// Transfer Outputs
 	//   Contract 1 : amount = calculated fee for settlement tx + contract fees + any bitcoins being
 	//   transfered
@@ -144,8 +160,8 @@ func (t *Transfer) TransferRequest(ctx context.Context, w *node.ResponseWriter,
 	// Each contract can be involved in more than one asset in the transfer, but only needs to have
 	//   one output since each asset transfer references the output of it's contract
 	var settleTx *txbuilder.TxBuilder
-	settleTx, err = buildSettlementTx(ctx, t.MasterDB, t.Config, itx, msg, &settlementRequest,
-		contractBalance, rk)
+	settleTx, err = buildSettlementTx(ctx, t.MasterDB, t.Config, w, itx, msg,
+		&settlementRequest, contractBalance, rk)
 	if err != nil {
 		node.LogWarn(ctx, "Failed to build settlement tx : %s", err)
 		return respondTransferReject(ctx, t.MasterDB, t.HoldingsChannel, t.Config, w, itx, msg, rk,
@@ -187,7 +203,7 @@ func (t *Transfer) TransferRequest(ctx context.Context, w *node.ResponseWriter,
 	// Check if settlement data is complete. No other contracts involved
 	if isSingleContract {
 		node.Log(ctx, "Single contract settlement complete")
-		if err := settleTx.Sign([]bitcoin.Key{rk.Key}); err != nil {
+		if err := w.Signer.Sign(ctx, settleTx, rk.Address); err != nil {
 			if errors.Cause(err) == txbuilder.ErrInsufficientValue {
 				node.LogWarn(ctx, "Insufficient settlement tx funding : %s", err)
 				return respondTransferReject(ctx, t.MasterDB, t.HoldingsChannel, t.Config, w, itx,
@@ -227,7 +243,11 @@ func (t *Transfer) TransferRequest(ctx context.Context, w *node.ResponseWriter,
 	}
 
 	// Save pending transfer
-	timeout := protocol.NewTimestamp(v.Now.Nano() + t.Config.RequestTimeout)
+	requestTimeout := policy.RequestTimeout
+	if requestTimeout == 0 {
+		requestTimeout = t.Config.ContractRequestTimeout(rk.Address)
+	}
+	timeout := protocol.NewTimestamp(v.Now.Nano() + requestTimeout)
 	pendingTransfer := state.PendingTransfer{TransferTxId: protocol.TxIdFromBytes(itx.Hash[:]),
 		Timeout: timeout}
 	if err := transfer.Save(ctx, t.MasterDB, rk.Address, &pendingTransfer); err != nil {
@@ -331,7 +351,7 @@ func firstContractOutputIndex(assetTransfers []*actions.AssetTransferField,
 
 // buildSettlementTx builds the tx for a settlement action.
 func buildSettlementTx(ctx context.Context, masterDB *db.DB, config *node.Config,
-	transferTx *inspector.Transaction, transfer *actions.Transfer,
+	w *node.ResponseWriter, transferTx *inspector.Transaction, transfer *actions.Transfer,
 	settlementRequest *messages.SettlementRequest, contractBalance uint64,
 	rk *wallet.Key) (*txbuilder.TxBuilder, error) {
 	ctx, span := trace.StartSpan(ctx, "handlers.Transfer.buildSettlementTx")
@@ -343,7 +363,8 @@ func buildSettlementTx(ctx context.Context, masterDB *db.DB, config *node.Config
 	//
 	// Settle Inputs
 	//   Any contracts involved.
-	settleTx := txbuilder.NewTxBuilder(config.FeeRate, config.DustFeeRate)
+	settleTx := txbuilder.NewTxBuilder(config.Fees.FeeRate(rk.Address, actions.CodeSettlement),
+		config.Fees.DustFeeRate())
 	settleTx.SetChangeAddress(rk.Address, "")
 
 	var err error
@@ -481,6 +502,7 @@ func buildSettlementTx(ctx context.Context, masterDB *db.DB, config *node.Config
 	}
 	if ct.ContractFee > 0 {
 		settleTx.AddPaymentOutput(config.FeeAddress, ct.ContractFee, false)
+		w.RecordContractFee(ct.ContractFee)
 
 		// Add to settlement request
 		settlementRequest.ContractFees = append(settlementRequest.ContractFees,
@@ -1015,6 +1037,83 @@ func transferIsSingleContract(ctx context.Context, itx *inspector.Transaction,
 	return true
 }
 
+// checkTransferPolicy returns a rejection error if the contract's transfer policy doesn't permit
+//   the other contracts involved in the transfer. Every other contract checked is recorded as an
+//   interaction of the kind specified.
+func checkTransferPolicy(ctx context.Context, dbConn *db.DB, config *node.Config,
+	policy *contract.TransferPolicy, transferTx *inspector.Transaction, transfer *actions.Transfer,
+	rk *wallet.Key, kind string) error {
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	var result error
+	var checked []bitcoin.RawAddress
+	for _, assetTransfer := range transfer.Assets {
+		if assetTransfer.AssetType == "BSV" {
+			continue // Bitcoin transfers don't involve a contract
+		}
+
+		if int(assetTransfer.ContractIndex) >= len(transferTx.Outputs) {
+			continue // Invalid contract indexes are rejected when building the settlement
+		}
+
+		address := transferTx.Outputs[assetTransfer.ContractIndex].Address
+		if address.Equal(rk.Address) {
+			continue
+		}
+
+		alreadyChecked := false
+		for _, ra := range checked {
+			if ra.Equal(address) {
+				alreadyChecked = true
+				break
+			}
+		}
+		if alreadyChecked {
+			continue
+		}
+		checked = append(checked, address)
+
+		var cf *actions.ContractFormation
+		if policy.NeedsFormation() {
+			var err error
+			cf, err = contract.FetchContractFormation(ctx, dbConn, address, config.IsTest)
+			if err != nil && err != contract.ErrNotFound {
+				return errors.Wrap(err, "fetch counterparty formation")
+			}
+		}
+
+		permitted, reason := policy.PermitsCounterparty(address, cf)
+
+		counterparty := bitcoin.NewAddressFromRawAddress(address, config.Net).String()
+		if permitted {
+			node.Log(ctx, "%s with contract %s permitted", kind, counterparty)
+		} else {
+			node.LogWarn(ctx, "%s with contract %s refused : %s", kind, counterparty, reason)
+		}
+
+		interaction := &contract.Interaction{
+			ContractAddress: rk.Address,
+			Counterparty:    address,
+			Kind:            kind,
+			TxId:            transferTx.Hash,
+			Permitted:       permitted,
+			Reason:          reason,
+			Timestamp:       v.Now,
+		}
+		if err := contract.SaveInteraction(ctx, dbConn, interaction); err != nil {
+			return errors.Wrap(err, "save interaction")
+		}
+
+		if !permitted && result == nil {
+			result = node.NewError(actions.RejectionsContractNotPermitted,
+				fmt.Sprintf("%s : %s", reason, counterparty))
+		}
+	}
+
+	return result
+}
+
 // SettlementResponse handles an outgoing Settlement action and writes it to the state
 func (t *Transfer) SettlementResponse(ctx context.Context, w *node.ResponseWriter,
 	itx *inspector.Transaction, rk *wallet.Key) error {
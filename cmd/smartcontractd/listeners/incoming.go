@@ -74,13 +74,18 @@ func (server *Server) ProcessIncomingTxs(ctx context.Context, masterDB *db.DB,
 			return errors.Wrap(err, "promote")
 		}
 
-		if server.Config.MinFeeRate > 0.0 && intx.Itx.IsIncomingMessageType() {
+		var contractAddress bitcoin.RawAddress
+		if contractAddresses := intx.Itx.ContractAddresses(); len(contractAddresses) > 0 {
+			contractAddress = contractAddresses[0]
+		}
+		minFeeRate := server.Config.Fees.MinFeeRate(contractAddress)
+		if minFeeRate > 0.0 && intx.Itx.IsIncomingMessageType() {
 			feeRate, err := intx.Itx.FeeRate()
 			if err != nil {
 				server.abortPendingTx(txCtx, *intx.Itx.Hash)
 				return errors.Wrap(err, "fee rate")
 			}
-			if feeRate < server.Config.MinFeeRate {
+			if feeRate < minFeeRate {
 				intx.Itx.RejectCode = actions.RejectionsInsufficientTxFeeFunding
 				node.LogWarn(txCtx, "Low tx fee rate %f", feeRate)
 			}
@@ -72,6 +72,10 @@ func (server *Server) HandleTxState(ctx context.Context, msgType int, txid bitco
 	case handlers.ListenerMsgTxStateConfirm:
 		node.Log(ctx, "Tx confirm")
 
+		if server.Headers != nil {
+			server.utxos.Confirm(txid, server.Headers.LastHeight(ctx))
+		}
+
 		if server.removeFromReverted(ctx, &txid) {
 			node.LogVerbose(ctx, "Tx reconfirmed in reorg")
 			return nil // Already accepted. Reverted and reconfirmed by reorg
@@ -103,6 +107,7 @@ func (server *Server) HandleTxState(ctx context.Context, msgType int, txid bitco
 
 	case handlers.ListenerMsgTxStateRevert:
 		node.Log(ctx, "Tx revert")
+		server.utxos.Unconfirm(txid)
 		server.revertedTxs = append(server.revertedTxs, &txid)
 	}
 
@@ -187,8 +192,12 @@ func (server *Server) HandleInSync(ctx context.Context) error {
 				return nil
 			}
 
-			// Schedule vote finalizer
-			if err = server.Scheduler.ScheduleJob(ctx, NewVoteFinalizer(server.Handler, voteTx, vt.Expires)); err != nil {
+			// Schedule vote finalizer, right away for votes that closed early
+			expires := vt.Expires
+			if vt.ClosedEarlyAt.Nano() != 0 {
+				expires = vt.ClosedEarlyAt
+			}
+			if err = server.Scheduler.ScheduleJob(ctx, NewVoteFinalizer(server.Handler, voteTx, expires)); err != nil {
 				node.LogWarn(ctx, "Failed to schedule vote finalizer : %s", err)
 				return nil
 			}
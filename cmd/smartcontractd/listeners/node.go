@@ -24,8 +24,8 @@ import (
 )
 
 const (
-	walletKey = "wallet" // storage path for wallet
-	serverKey = "server" // storage path for server
+	WalletStorageKey = "wallet" // storage path for wallet
+	serverKey        = "server" // storage path for server
 )
 
 var (
@@ -65,6 +65,12 @@ type Server struct {
 
 	TxSentCount        int
 	AlternateResponder protomux.ResponderFunc
+
+	// WalletProtection is the encryption used for the stored wallet.
+	WalletProtection *wallet.Protection
+
+	// Signer signs txs the server creates itself, like UTXO consolidations.
+	Signer wallet.Signer
 }
 
 type pendingRequest struct {
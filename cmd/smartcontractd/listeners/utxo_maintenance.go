@@ -0,0 +1,203 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/smart-contract/internal/platform/alert"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/utxos"
+
+	"github.com/pkg/errors"
+)
+
+// UTXOMaintenanceConfig specifies when contract UTXOs are consolidated and when low balance
+//   alerts are sent.
+type UTXOMaintenanceConfig struct {
+	// ConsolidationThreshold is the value below which UTXOs are consolidated. Zero disables
+	//   consolidation.
+	ConsolidationThreshold uint64
+
+	// ConsolidationMinCount is the number of small UTXOs an address needs before they are
+	//   consolidated.
+	ConsolidationMinCount int
+
+	// ConsolidationMaxInputs limits the size of a consolidation tx.
+	ConsolidationMaxInputs int
+
+	// ConsolidationMaxFeeRate is the highest fee rate at which to consolidate, so consolidation
+	//   waits for cheap fees. Zero means any fee rate.
+	ConsolidationMaxFeeRate float32
+
+	// LowBalanceThreshold is the balance below which an alert is sent for a contract address
+	//   without its own threshold. Zero disables it.
+	LowBalanceThreshold uint64
+
+	// LowBalanceThresholds are per contract thresholds, keyed by the raw address bytes.
+	LowBalanceThresholds map[string]uint64
+}
+
+// UTXOMaintenance is a periodic process that consolidates small contract UTXOs and alerts when
+//   contract balances get low, before responses start failing for lack of funding.
+type UTXOMaintenance struct {
+	server   *Server
+	config   UTXOMaintenanceConfig
+	notifier *alert.Notifier
+
+	lowBalance map[string]bool // Addresses that have already been alerted
+	lock       sync.Mutex
+}
+
+func NewUTXOMaintenance(server *Server, config UTXOMaintenanceConfig,
+	notifier *alert.Notifier) *UTXOMaintenance {
+
+	return &UTXOMaintenance{
+		server:     server,
+		config:     config,
+		notifier:   notifier,
+		lowBalance: make(map[string]bool),
+	}
+}
+
+// SetConfig replaces the config. It waits for a run in progress to finish.
+func (um *UTXOMaintenance) SetConfig(config UTXOMaintenanceConfig) {
+	um.lock.Lock()
+	defer um.lock.Unlock()
+
+	um.config = config
+}
+
+// Run consolidates and checks balances of all contract addresses.
+func (um *UTXOMaintenance) Run(ctx context.Context) {
+	um.lock.Lock()
+	defer um.lock.Unlock()
+
+	ctx = node.ContextWithLogTrace(ctx, "UTXO Maintenance")
+
+	um.server.walletLock.RLock()
+	addresses := make([]bitcoin.RawAddress, len(um.server.contractAddresses))
+	copy(addresses, um.server.contractAddresses)
+	um.server.walletLock.RUnlock()
+
+	if um.server.IsInSync() && um.canConsolidate() {
+		for _, address := range addresses {
+			if err := um.consolidate(ctx, address); err != nil {
+				node.LogWarn(ctx, "Failed to consolidate %s : %s",
+					bitcoin.NewAddressFromRawAddress(address, um.server.Config.Net), err)
+			}
+		}
+	}
+
+	for _, address := range addresses {
+		um.checkBalance(ctx, address)
+	}
+}
+
+func (um *UTXOMaintenance) canConsolidate() bool {
+	if um.config.ConsolidationThreshold == 0 || um.server.Signer == nil {
+		return false
+	}
+
+	if um.config.ConsolidationMaxFeeRate > 0 &&
+		um.server.Config.Fees.FeeRate(bitcoin.RawAddress{}, "") > um.config.ConsolidationMaxFeeRate {
+		return false
+	}
+
+	return true
+}
+
+// consolidate sweeps the small UTXOs of an address into one output back to the address.
+func (um *UTXOMaintenance) consolidate(ctx context.Context, address bitcoin.RawAddress) error {
+	options := utxos.SelectOptions{
+		MinConfirmations: um.server.Config.UTXOMinConfirmations,
+		Reserve:          true,
+	}
+	if um.server.Headers != nil {
+		options.Height = um.server.Headers.LastHeight(ctx)
+	}
+
+	minCount := um.config.ConsolidationMinCount
+	if minCount < 2 {
+		minCount = 2
+	}
+
+	selected := um.server.utxos.SelectBelow(um.config.ConsolidationThreshold,
+		um.config.ConsolidationMaxInputs, address, options)
+	if len(selected) < minCount {
+		um.server.utxos.Release(selected)
+		return nil
+	}
+
+	tx := txbuilder.NewTxBuilder(um.server.Config.Fees.FeeRate(address, ""),
+		um.server.Config.Fees.DustFeeRate())
+	for _, utxo := range selected {
+		if err := tx.AddInput(utxo.OutPoint, utxo.Output.PkScript,
+			uint64(utxo.Output.Value)); err != nil {
+			um.server.utxos.Release(selected)
+			return errors.Wrap(err, "add input")
+		}
+	}
+
+	if err := tx.AddMaxOutput(address); err != nil {
+		um.server.utxos.Release(selected)
+		return errors.Wrap(err, "add output")
+	}
+
+	if err := um.server.Signer.Sign(ctx, tx, address); err != nil {
+		um.server.utxos.Release(selected)
+		if errors.Cause(err) == txbuilder.ErrInsufficientValue {
+			return nil // Not worth consolidating at this fee rate
+		}
+		return errors.Wrap(err, "sign")
+	}
+
+	if err := um.server.respondTx(ctx, tx.MsgTx); err != nil {
+		um.server.utxos.Release(selected)
+		return errors.Wrap(err, "send")
+	}
+
+	node.Log(ctx, "Consolidated %d UTXOs (%d sat) for %s : %s", len(selected),
+		tx.OutputValue(true), bitcoin.NewAddressFromRawAddress(address, um.server.Config.Net),
+		tx.MsgTx.TxHash())
+	return nil
+}
+
+// checkBalance sends an alert when the balance of an address drops below its threshold, and
+//   again when it recovers.
+func (um *UTXOMaintenance) checkBalance(ctx context.Context, address bitcoin.RawAddress) {
+	key := string(address.Bytes())
+	threshold, exists := um.config.LowBalanceThresholds[key]
+	if !exists {
+		threshold = um.config.LowBalanceThreshold
+	}
+	if threshold == 0 {
+		return
+	}
+
+	balance := um.server.utxos.Balance(address)
+	isLow := balance < threshold
+	if isLow == um.lowBalance[key] {
+		return // No change since last alert
+	}
+	um.lowBalance[key] = isLow
+
+	ad := bitcoin.NewAddressFromRawAddress(address, um.server.Config.Net).String()
+	a := alert.Alert{
+		Type:      alert.TypeLowBalance,
+		Address:   ad,
+		Balance:   balance,
+		Threshold: threshold,
+		Message:   fmt.Sprintf("Contract %s balance %d is below %d", ad, balance, threshold),
+	}
+	if !isLow {
+		a.Type = alert.TypeBalanceRecovered
+		a.Message = fmt.Sprintf("Contract %s balance %d is above %d", ad, balance, threshold)
+	}
+
+	if err := um.notifier.Notify(ctx, a); err != nil {
+		node.LogWarn(ctx, "Failed to send alert : %s", err)
+	}
+}
@@ -36,17 +36,13 @@ func (server *Server) ContractIsStarted(ctx context.Context, ca bitcoin.RawAddre
 func (server *Server) AddContractKey(ctx context.Context, key *wallet.Key) error {
 	server.walletLock.Lock()
 
-	rawAddress, err := key.Key.RawAddress()
-	if err != nil {
-		server.walletLock.Unlock()
-		return err
-	}
+	rawAddress := key.Address
 
 	node.Log(ctx, "Adding key : %s",
 		bitcoin.NewAddressFromRawAddress(rawAddress, server.Config.Net).String())
 
 	server.contractAddresses = append(server.contractAddresses, rawAddress)
-	server.txFilter.AddPubKey(ctx, key.Key.PublicKey().Bytes())
+	server.txFilter.AddPubKey(ctx, key.PublicKey().Bytes())
 
 	server.walletLock.Unlock()
 
@@ -83,6 +79,7 @@ func (server *Server) RemoveContract(ctx context.Context, ca bitcoin.RawAddress,
 	return nil
 }
 
+// SaveWallet writes the wallet to storage, encrypted with the server's wallet protection.
 func (server *Server) SaveWallet(ctx context.Context) error {
 	node.Log(ctx, "Saving wallet")
 
@@ -93,21 +90,34 @@ func (server *Server) SaveWallet(ctx context.Context) error {
 	}
 	logger.Elapsed(ctx, start, "Serialize wallet")
 
+	data, err := wallet.Seal(buf.Bytes(), server.WalletProtection)
+	if err != nil {
+		return errors.Wrap(err, "seal wallet")
+	}
+
 	defer logger.Elapsed(ctx, time.Now(), "Put wallet")
-	return server.MasterDB.Put(ctx, walletKey, buf.Bytes())
+	return server.MasterDB.Put(ctx, WalletStorageKey, data)
 }
 
+// LoadWallet reads the wallet from storage and syncs it. It fails if the stored wallet can't be
+//   decrypted with the server's wallet protection. When no wallet is stored yet, only the
+//   configured keys are synced.
 func (server *Server) LoadWallet(ctx context.Context) error {
 	node.Log(ctx, "Loading wallet")
 
-	data, err := server.MasterDB.Fetch(ctx, walletKey)
+	data, err := server.MasterDB.Fetch(ctx, WalletStorageKey)
 	if err != nil {
 		if err == db.ErrNotFound {
-			return nil // No keys yet
+			return server.SyncWallet(ctx) // First start
 		}
 		return errors.Wrap(err, "fetch wallet")
 	}
 
+	data, err = wallet.Unseal(data, server.WalletProtection)
+	if err != nil {
+		return errors.Wrap(err, "unseal wallet")
+	}
+
 	buf := bytes.NewReader(data)
 
 	if err := server.wallet.Deserialize(buf); err != nil {
@@ -129,7 +139,7 @@ func (server *Server) SyncWallet(ctx context.Context) error {
 		server.contractAddresses = append(server.contractAddresses, key.Address)
 
 		// Tx Filter
-		server.txFilter.AddPubKey(ctx, key.Key.PublicKey().Bytes())
+		server.txFilter.AddPubKey(ctx, key.PublicKey().Bytes())
 	}
 
 	return nil
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/logger"
@@ -18,6 +19,8 @@ import (
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/handlers"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/platform/alert"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
 )
 
 var (
@@ -100,9 +103,7 @@ func main() {
 	// Wallet
 
 	masterWallet := bootstrap.NewWallet()
-	if err := masterWallet.Register(cfg.Contract.PrivateKey, appConfig.Net); err != nil {
-		panic(err)
-	}
+	masterSigner := bootstrap.NewSigner(ctx, cfg, masterWallet, appConfig.Net)
 
 	contractAddress := bitcoin.NewAddressFromRawAddress(masterWallet.KeyStore.GetAddresses()[0],
 		appConfig.Net)
@@ -135,6 +136,7 @@ func main() {
 	appHandlers, apiErr := handlers.API(
 		ctx,
 		masterWallet,
+		masterSigner,
 		appConfig,
 		masterDB,
 		tracer,
@@ -163,7 +165,12 @@ func main() {
 		holdingsChannel,
 	)
 
-	if err := node.SyncWallet(ctx); err != nil {
+	node.WalletProtection = bootstrap.NewWalletProtection(ctx, cfg)
+	if !node.WalletProtection.IsEnabled() {
+		logger.Warn(ctx, "Wallet storage is not encrypted")
+	}
+
+	if err := node.LoadWallet(ctx); err != nil {
 		logger.Fatal(ctx, "Load Wallet : %s", err)
 	}
 
@@ -171,6 +178,52 @@ func main() {
 		logger.Fatal(ctx, "Load Server : %s", err)
 	}
 
+	// -------------------------------------------------------------------------
+	// Fee Estimates
+
+	if strings.ToLower(cfg.Contract.FeeRateSource) == fees.SourceRPC {
+		if err := appConfig.Fees.Update(ctx); err != nil {
+			logger.Warn(ctx, "Failed to get fee estimate : %s", err)
+		}
+		if err := sch.ScheduleJob(ctx, scheduler.NewPeriodicTask("Fee Estimates", appConfig.Fees,
+			time.Duration(cfg.Contract.FeeEstimateFrequency)*time.Second)); err != nil {
+			logger.Fatal(ctx, "Schedule Fee Estimates : %s", err)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// UTXO Maintenance
+
+	node.Signer = masterSigner
+	var utxoMaintenance *listeners.UTXOMaintenance
+	if cfg.Contract.UTXOMaintenanceFrequency > 0 {
+		utxoMaintenance = listeners.NewUTXOMaintenance(node,
+			bootstrap.NewUTXOMaintenanceConfig(ctx, cfg, appConfig.Net),
+			alert.NewNotifier(cfg.Contract.AlertWebhookURL))
+		if err := sch.ScheduleJob(ctx, scheduler.NewPeriodicTask("UTXO Maintenance",
+			utxoMaintenance,
+			time.Duration(cfg.Contract.UTXOMaintenanceFrequency)*time.Second)); err != nil {
+			logger.Fatal(ctx, "Schedule UTXO Maintenance : %s", err)
+		}
+	}
+
+	// -------------------------------------------------------------------------
+	// Config Reload
+
+	// Reload fee rates, thresholds and per contract overrides on SIGHUP without restarting the
+	// spynode connection.
+	reloader := bootstrap.NewConfigReloader(cfg, appConfig, utxoMaintenance)
+	reloadSignals := make(chan os.Signal, 1)
+	signal.Notify(reloadSignals, syscall.SIGHUP)
+	go func() {
+		for range reloadSignals {
+			logger.Info(ctx, "Reloading config")
+			if _, err := reloader.Reload(ctx); err != nil {
+				logger.Error(ctx, "Failed to reload config : %s", err)
+			}
+		}
+	}()
+
 	// -------------------------------------------------------------------------
 	// Start Node Service
 
@@ -0,0 +1,228 @@
+package rebuild
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/scheduler"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/handlers"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrResponseCreated occurs when a replayed tx attempts to create a new response.
+	ErrResponseCreated = errors.New("Response created during rebuild")
+)
+
+// replayCodes are the response actions that modify contract state.
+var replayCodes = map[string]bool{
+	actions.CodeContractFormation: true,
+	actions.CodeAssetCreation:     true,
+	actions.CodeSettlement:        true,
+	actions.CodeFreeze:            true,
+	actions.CodeThaw:              true,
+	actions.CodeConfiscation:      true,
+	actions.CodeReconciliation:    true,
+	actions.CodeVote:              true,
+	actions.CodeBallotCounted:     true,
+	actions.CodeResult:            true,
+}
+
+// Rebuild replays a contract's responses into dbConn to rebuild contract, asset, holding and vote
+//   state. txs must contain the responses and the requests they reference. Responses are processed
+//   in the order of their timestamps, with the timestamp used as the processing time, so the
+//   result is deterministic. dbConn should not already contain state for the contract.
+// The asset, contract, holdings and vote caches are reset before and after the rebuild. Returns
+//   the number of responses replayed.
+func Rebuild(ctx context.Context, dbConn *db.DB, config *node.Config,
+	masterWallet wallet.WalletInterface, signer wallet.Signer, contractAddress bitcoin.RawAddress,
+	txs []*inspector.Transaction) (int, error) {
+
+	ResetCaches(ctx)
+	defer ResetCaches(ctx)
+
+	// Requests must be available to the response handlers.
+	for _, itx := range txs {
+		if err := transactions.AddTx(ctx, dbConn, itx); err != nil {
+			return 0, errors.Wrap(err, "add tx")
+		}
+	}
+
+	responses := ContractResponses(txs, contractAddress)
+
+	holdingsChannel := &holdings.CacheChannel{}
+	holdingsChannel.Open(100)
+
+	var wg sync.WaitGroup
+	var holdingsErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		holdingsErr = holdings.ProcessCacheItems(ctx, dbConn, holdingsChannel)
+	}()
+
+	// The scheduler is never run so jobs created by replayed responses are ignored.
+	api, err := handlers.API(ctx, masterWallet, signer, config, dbConn, filters.NewTracer(),
+		&scheduler.Scheduler{}, nil, nil, holdingsChannel)
+	if err != nil {
+		holdingsChannel.Close()
+		wg.Wait()
+		return 0, errors.Wrap(err, "create api")
+	}
+
+	api.SetResponder(func(ctx context.Context, tx *wire.MsgTx) error {
+		return ErrResponseCreated
+	})
+	api.SetReprocessor(func(ctx context.Context, itx *inspector.Transaction) error {
+		return nil
+	})
+
+	count, replayErr := replay(ctx, api, responses)
+
+	holdingsChannel.Close()
+	wg.Wait()
+
+	if replayErr != nil {
+		return count, replayErr
+	}
+	if holdingsErr != nil {
+		return count, errors.Wrap(holdingsErr, "process holdings")
+	}
+
+	if err := holdings.WriteCache(ctx, dbConn); err != nil {
+		return count, errors.Wrap(err, "write holdings")
+	}
+
+	return count, nil
+}
+
+func replay(ctx context.Context, api protomux.Handler,
+	responses []*inspector.Transaction) (int, error) {
+
+	for i, itx := range responses {
+		ts := itx.Timestamp()
+		replayCtx := node.ContextWithTimestamp(ctx, protocol.NewTimestamp(*ts))
+
+		node.Log(ctx, "Replaying %s : %s", itx.MsgProto.Code(), itx.Hash.String())
+		if err := api.Trigger(replayCtx, protomux.SEE, itx); err != nil {
+			return i, errors.Wrapf(err, "replay %s %s", itx.MsgProto.Code(), itx.Hash.String())
+		}
+	}
+
+	return len(responses), nil
+}
+
+// ContractResponses returns the state changing responses from the contract, in the order they
+//   were created.
+func ContractResponses(txs []*inspector.Transaction,
+	contractAddress bitcoin.RawAddress) []*inspector.Transaction {
+
+	var result inspector.TransactionList
+	for _, itx := range txs {
+		if !itx.IsTokenized() || !replayCodes[itx.MsgProto.Code()] || itx.Timestamp() == nil {
+			continue
+		}
+
+		for _, input := range itx.Inputs {
+			if input.Address.Equal(contractAddress) {
+				result = append(result, itx)
+				break
+			}
+		}
+	}
+
+	sort.Stable(&result)
+	return result
+}
+
+// ReadRawTxs reads hex encoded raw txs, one per line. Txs whose inputs are all spending outputs of
+//   other txs in the list are returned. Other txs are only used to provide inputs.
+func ReadRawTxs(ctx context.Context, r io.Reader, isTest bool) ([]*inspector.Transaction, error) {
+	var msgs []*wire.MsgTx
+	byHash := make(map[bitcoin.Hash32]*wire.MsgTx)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 100*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		b, err := hex.DecodeString(line)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode hex")
+		}
+
+		tx := &wire.MsgTx{}
+		if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+			return nil, errors.Wrap(err, "deserialize tx")
+		}
+
+		msgs = append(msgs, tx)
+		byHash[*tx.TxHash()] = tx
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read txs")
+	}
+
+	var result []*inspector.Transaction
+	for _, tx := range msgs {
+		utxos := make([]bitcoin.UTXO, 0, len(tx.TxIn))
+		complete := true
+		for _, txin := range tx.TxIn {
+			parent, exists := byHash[txin.PreviousOutPoint.Hash]
+			if !exists || int(txin.PreviousOutPoint.Index) >= len(parent.TxOut) {
+				complete = false
+				break
+			}
+			utxos = append(utxos, inspector.NewUTXOFromWire(parent, txin.PreviousOutPoint.Index))
+		}
+		if !complete {
+			continue
+		}
+
+		itx, err := inspector.NewTransactionFromWire(ctx, tx, isTest)
+		if err != nil {
+			return nil, errors.Wrap(err, "create itx")
+		}
+
+		if err := itx.PromoteFromUTXOs(ctx, utxos); err != nil {
+			return nil, errors.Wrap(err, "promote itx")
+		}
+
+		result = append(result, itx)
+	}
+
+	return result, nil
+}
+
+// ResetCaches clears the package level caches of state so it is reloaded from storage.
+func ResetCaches(ctx context.Context) {
+	asset.Reset(ctx)
+	contract.Reset(ctx)
+	holdings.Reset(ctx)
+	vote.Reset(ctx)
+}
@@ -0,0 +1,306 @@
+package rebuild
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/vote"
+
+	"github.com/pkg/errors"
+)
+
+// ignoredFields are record fields that depend on when the record was processed rather than on the
+//   chain, so they are not compared.
+var ignoredFields = map[string]bool{
+	"CreatedAt": true,
+	"UpdatedAt": true,
+}
+
+// State is the stored state of a contract.
+type State struct {
+	Contract *state.Contract
+
+	// Assets by asset code.
+	Assets map[string]*state.Asset
+
+	// Holdings by asset code and address.
+	Holdings map[string]*state.Holding
+
+	// Votes by vote txid.
+	Votes map[string]*state.Vote
+}
+
+// LoadState loads the state of a contract from storage. The package level caches are reset so the
+//   state comes only from dbConn.
+func LoadState(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	isTest bool) (*State, error) {
+
+	ResetCaches(ctx)
+	defer ResetCaches(ctx)
+
+	result := &State{
+		Assets:   make(map[string]*state.Asset),
+		Holdings: make(map[string]*state.Holding),
+		Votes:    make(map[string]*state.Vote),
+	}
+
+	ct, err := contract.Fetch(ctx, dbConn, contractAddress, isTest)
+	if err == contract.ErrNotFound {
+		return result, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch contract")
+	}
+	result.Contract = ct
+
+	assetCodes, err := asset.List(ctx, dbConn, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list assets")
+	}
+
+	for _, assetCode := range assetCodes {
+		as, err := asset.Fetch(ctx, dbConn, contractAddress, assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch asset")
+		}
+		result.Assets[assetCode.String()] = as
+
+		hs, err := holdings.FetchAll(ctx, dbConn, contractAddress, assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch holdings")
+		}
+
+		for _, h := range hs {
+			result.Holdings[holdingKey(assetCode.String(), h.Address)] = h
+		}
+	}
+
+	votes, err := vote.List(ctx, dbConn, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list votes")
+	}
+
+	for _, vt := range votes {
+		sort.Slice(vt.BallotList, func(i, j int) bool {
+			return bytes.Compare(vt.BallotList[i].Address.Bytes(),
+				vt.BallotList[j].Address.Bytes()) < 0
+		})
+		result.Votes[vt.VoteTxId.String()] = vt
+	}
+
+	return result, nil
+}
+
+// Save writes the state to storage, replacing any existing records with the same keys. Records in
+//   storage that are not in the state are not removed.
+func (s *State) Save(ctx context.Context, dbConn *db.DB, isTest bool) error {
+	if s.Contract == nil {
+		return errors.New("Missing contract")
+	}
+
+	ResetCaches(ctx)
+	defer ResetCaches(ctx)
+
+	if err := contract.Save(ctx, dbConn, s.Contract, isTest); err != nil {
+		return errors.Wrap(err, "save contract")
+	}
+
+	for _, as := range s.Assets {
+		if err := asset.Save(ctx, dbConn, s.Contract.Address, as); err != nil {
+			return errors.Wrap(err, "save asset")
+		}
+
+		for key, h := range s.Holdings {
+			if !strings.HasPrefix(key, as.Code.String()+"/") {
+				continue
+			}
+
+			ci, err := holdings.Save(ctx, dbConn, s.Contract.Address, as.Code, h)
+			if err != nil {
+				return errors.Wrap(err, "save holding")
+			}
+			if err := ci.Write(ctx, dbConn); err != nil {
+				return errors.Wrap(err, "write holding")
+			}
+		}
+	}
+
+	for _, vt := range s.Votes {
+		// Save rebuilds the ballot list from the ballot map.
+		vt.Ballots = make(map[bitcoin.Hash20]state.Ballot)
+		for _, b := range vt.BallotList {
+			hash, err := b.Address.Hash()
+			if err != nil {
+				return errors.Wrap(err, "ballot address hash")
+			}
+			vt.Ballots[*hash] = b
+		}
+
+		if err := vote.Save(ctx, dbConn, s.Contract.Address, vt); err != nil {
+			return errors.Wrap(err, "save vote")
+		}
+	}
+
+	return nil
+}
+
+// Diff returns a description of each difference between the current state and the rebuilt state.
+func Diff(current, rebuilt *State) ([]string, error) {
+	var result []string
+
+	lines, err := diffRecord("contract", current.Contract, rebuilt.Contract)
+	if err != nil {
+		return nil, errors.Wrap(err, "contract")
+	}
+	result = append(result, lines...)
+
+	for _, key := range unionKeys(current.Assets, rebuilt.Assets) {
+		lines, err := diffRecord("asset "+key, current.Assets[key], rebuilt.Assets[key])
+		if err != nil {
+			return nil, errors.Wrap(err, "asset")
+		}
+		result = append(result, lines...)
+	}
+
+	currentHoldings := make(map[string]*holdingView)
+	for key, h := range current.Holdings {
+		currentHoldings[key] = newHoldingView(h)
+	}
+	rebuiltHoldings := make(map[string]*holdingView)
+	for key, h := range rebuilt.Holdings {
+		rebuiltHoldings[key] = newHoldingView(h)
+	}
+	for _, key := range unionKeys(currentHoldings, rebuiltHoldings) {
+		lines, err := diffRecord("holding "+key, currentHoldings[key], rebuiltHoldings[key])
+		if err != nil {
+			return nil, errors.Wrap(err, "holding")
+		}
+		result = append(result, lines...)
+	}
+
+	for _, key := range unionKeys(current.Votes, rebuilt.Votes) {
+		lines, err := diffRecord("vote "+key, current.Votes[key], rebuilt.Votes[key])
+		if err != nil {
+			return nil, errors.Wrap(err, "vote")
+		}
+		result = append(result, lines...)
+	}
+
+	return result, nil
+}
+
+// holdingView is the comparable part of a holding. Holding statuses are in a map with keys that
+//   can't be encoded as JSON.
+type holdingView struct {
+	PendingBalance   uint64
+	FinalizedBalance uint64
+	HoldingStatuses  []*state.HoldingStatus `json:",omitempty"`
+}
+
+func newHoldingView(h *state.Holding) *holdingView {
+	result := &holdingView{
+		PendingBalance:   h.PendingBalance,
+		FinalizedBalance: h.FinalizedBalance,
+	}
+
+	for _, status := range h.HoldingStatuses {
+		result.HoldingStatuses = append(result.HoldingStatuses, status)
+	}
+	sort.Slice(result.HoldingStatuses, func(i, j int) bool {
+		return bytes.Compare(result.HoldingStatuses[i].TxId.Bytes(),
+			result.HoldingStatuses[j].TxId.Bytes()) < 0
+	})
+
+	return result
+}
+
+// diffRecord compares the top level fields of two records.
+func diffRecord(name string, current, rebuilt interface{}) ([]string, error) {
+	currentFields, err := recordFields(current)
+	if err != nil {
+		return nil, err
+	}
+	rebuiltFields, err := recordFields(rebuilt)
+	if err != nil {
+		return nil, err
+	}
+
+	if currentFields == nil && rebuiltFields == nil {
+		return nil, nil
+	}
+	if currentFields == nil {
+		return []string{fmt.Sprintf("%s : only in rebuilt state", name)}, nil
+	}
+	if rebuiltFields == nil {
+		return []string{fmt.Sprintf("%s : only in current state", name)}, nil
+	}
+
+	var result []string
+	for _, field := range unionKeys(currentFields, rebuiltFields) {
+		if ignoredFields[field] {
+			continue
+		}
+
+		c, r := currentFields[field], rebuiltFields[field]
+		if reflect.DeepEqual(c, r) {
+			continue
+		}
+
+		cjs, _ := json.Marshal(c)
+		rjs, _ := json.Marshal(r)
+		result = append(result, fmt.Sprintf("%s : %s : current %s, rebuilt %s", name, field,
+			cjs, rjs))
+	}
+
+	return result, nil
+}
+
+// recordFields returns the JSON fields of a record, or nil if the record is nil.
+func recordFields(record interface{}) (map[string]interface{}, error) {
+	if record == nil || reflect.ValueOf(record).IsNil() {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(b, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// unionKeys returns the sorted keys that are in either map. The maps must have string keys.
+func unionKeys(a, b interface{}) []string {
+	keys := make(map[string]bool)
+	for _, m := range []interface{}{a, b} {
+		for _, key := range reflect.ValueOf(m).MapKeys() {
+			keys[key.String()] = true
+		}
+	}
+
+	result := make([]string, 0, len(keys))
+	for key := range keys {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func holdingKey(assetCode string, address bitcoin.RawAddress) string {
+	return fmt.Sprintf("%s/%x", assetCode, address.Bytes())
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/state"
 	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/internal/revenue"
 	"github.com/tokenized/smart-contract/pkg/inspector"
 	"github.com/tokenized/specification/dist/golang/actions"
 	"github.com/tokenized/specification/dist/golang/protocol"
@@ -216,6 +217,25 @@ func createContract(t *testing.T) {
 	}
 
 	t.Logf("\t%s\tVerified issuer proposal", tests.Success)
+
+	// Verify fee ledger
+	entries, err := revenue.Fetch(ctx, test.MasterDB, test.ContractKey.Address,
+		protocol.NewTimestamp(0), protocol.NewTimestamp(0))
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch fee ledger : %v", tests.Failed, err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("\t%s\tWrong fee ledger entry count : %d", tests.Failed, len(entries))
+	}
+	for i, code := range []string{"M2", "C2"} {
+		if entries[i].ActionCode != code || entries[i].MiningFee == 0 {
+			t.Fatalf("\t%s\tWrong fee ledger entry %d : %s, mining fee %d", tests.Failed, i,
+				entries[i].ActionCode, entries[i].MiningFee)
+		}
+	}
+
+	t.Logf("\t%s\tVerified fee ledger", tests.Success)
 }
 
 func masterAddress(t *testing.T) {
@@ -401,6 +421,25 @@ func masterAddress(t *testing.T) {
 	}
 
 	t.Logf("\t%s\tVerified issuer proposal", tests.Success)
+
+	// Verify fee ledger
+	entries, err := revenue.Fetch(ctx, test.MasterDB, test.ContractKey.Address,
+		protocol.NewTimestamp(0), protocol.NewTimestamp(0))
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch fee ledger : %v", tests.Failed, err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("\t%s\tWrong fee ledger entry count : %d", tests.Failed, len(entries))
+	}
+	for i, code := range []string{"M2", "C2"} {
+		if entries[i].ActionCode != code || entries[i].MiningFee == 0 {
+			t.Fatalf("\t%s\tWrong fee ledger entry %d : %s, mining fee %d", tests.Failed, i,
+				entries[i].ActionCode, entries[i].MiningFee)
+		}
+	}
+
+	t.Logf("\t%s\tVerified fee ledger", tests.Success)
 }
 
 func oracleContract(t *testing.T) {
@@ -1748,3 +1787,72 @@ func mockOperatorContract(t testing.TB, ctx context.Context, key bitcoin.Key,
 		t.Fatalf("Failed to save operator contract address : %s", err)
 	}
 }
+
+func mockAuthorityContract(t testing.TB, ctx context.Context, key bitcoin.Key,
+	publicKey bitcoin.PublicKey, issuerType string, issuerRole uint32, issuerName string) {
+
+	cf := &actions.ContractFormation{
+		ContractType: actions.ContractTypeEntity,
+		ContractName: "Test Authority Oracle",
+		Issuer: &actions.EntityField{
+			Type: issuerType,
+			Administration: []*actions.AdministratorField{
+				&actions.AdministratorField{
+					Type: issuerRole,
+					Name: issuerName,
+				},
+			},
+		},
+		ContractFee: 1000,
+		Services: []*actions.ServiceField{
+			&actions.ServiceField{
+				Type:      actions.ServiceTypeAuthorityOracle,
+				URL:       "tokenized.com/authority",
+				PublicKey: publicKey.Bytes(),
+			},
+		},
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create authority contract address : %s", err)
+	}
+
+	if err := contract.SaveContractFormation(ctx, test.MasterDB, ra, cf, test.NodeConfig.IsTest); err != nil {
+		t.Fatalf("Failed to save authority contract address : %s", err)
+	}
+}
+
+// mockUpAuthority registers an authority oracle with the public key on the mock contract.
+func mockUpAuthority(t testing.TB, ctx context.Context, publicKey bitcoin.PublicKey) {
+	authorityContractKey, err := bitcoin.GenerateKey(test.NodeConfig.Net)
+	if err != nil {
+		t.Fatalf("Failed to generate authority contract key : %s", err)
+	}
+
+	authorityAddress, err := authorityContractKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create authority contract address : %s", err)
+	}
+
+	mockAuthorityContract(t, ctx, authorityContractKey, publicKey, actions.EntitiesGovernmentAgency,
+		actions.RolesChair, "District Court #345")
+
+	ct, err := contract.Retrieve(ctx, test.MasterDB, test.ContractKey.Address, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("Failed to retrieve contract : %s", err)
+	}
+
+	ct.Oracles = append(ct.Oracles, &actions.OracleField{
+		OracleTypes:    []uint32{actions.ServiceTypeAuthorityOracle},
+		EntityContract: authorityAddress.Bytes(),
+	})
+
+	if err := contract.ExpandOracles(ctx, test.MasterDB, ct, test.NodeConfig.IsTest); err != nil {
+		t.Fatalf("Failed to expand oracles : %s", err)
+	}
+
+	if err := contract.Save(ctx, test.MasterDB, ct, test.NodeConfig.IsTest); err != nil {
+		t.Fatalf("Failed to save contract : %s", err)
+	}
+}
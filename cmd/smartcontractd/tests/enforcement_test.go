@@ -6,6 +6,7 @@ import (
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/enforcement"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/tests"
@@ -13,6 +14,8 @@ import (
 	"github.com/tokenized/smart-contract/pkg/inspector"
 	"github.com/tokenized/specification/dist/golang/actions"
 	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
 )
 
 // TestEnforcement is the entry point for testing enforcement functions.
@@ -21,9 +24,12 @@ func TestEnforcement(t *testing.T) {
 
 	t.Run("freeze", freezeOrder)
 	t.Run("authority", freezeAuthorityOrder)
+	t.Run("unregisteredAuthority", unregisteredAuthorityOrder)
 	t.Run("thaw", thawOrder)
 	t.Run("confiscate", confiscateOrder)
 	t.Run("reconcile", reconcileOrder)
+	t.Run("registry", orderRegistry)
+	t.Run("underfundedRegistry", underfundedOrderRegistry)
 }
 
 func freezeOrder(t *testing.T) {
@@ -116,6 +122,7 @@ func freezeAuthorityOrder(t *testing.T) {
 		"John Bitcoin", true, true, false, false, false)
 	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
 	mockUpHolding(t, ctx, userKey.Address, 300)
+	mockUpAuthority(t, ctx, authorityKey.Key.PublicKey())
 
 	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100005, issuerKey.Address)
 
@@ -201,6 +208,71 @@ func freezeAuthorityOrder(t *testing.T) {
 	}
 }
 
+func unregisteredAuthorityOrder(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I", 1,
+		"John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 300)
+	mockUpAuthority(t, ctx, authorityKey.Key.PublicKey())
+
+	// Signed by a key that isn't the registered authority's, which is valid for its own key.
+	selfSignedKey, err := tests.GenerateKey(test.NodeConfig.Net)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to generate key : %v", tests.Failed, err)
+	}
+
+	orderData := actions.Order{
+		ComplianceAction:   actions.ComplianceActionFreeze,
+		AssetType:          testAssetType,
+		AssetCode:          testAssetCodes[0].Bytes(),
+		Message:            "Court order",
+		AuthorityName:      "District Court #345",
+		AuthorityPublicKey: selfSignedKey.Key.PublicKey().Bytes(),
+		SignatureAlgorithm: 1,
+	}
+
+	orderData.TargetAddresses = append(orderData.TargetAddresses, &actions.TargetAddressField{
+		Address:  userKey.Address.Bytes(),
+		Quantity: 200,
+	})
+
+	sigHash, err := protocol.OrderAuthoritySigHash(ctx, test.ContractKey.Address, &orderData)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed generate authority signature hash : %v", tests.Failed, err)
+	}
+
+	sig, err := selfSignedKey.Key.Sign(sigHash)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to sign authority sig hash : %v", tests.Failed, err)
+	}
+	orderData.OrderSignature = sig.Bytes()
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100005, issuerKey.Address)
+	if err := sendRequest(ctx, fundingTx, &orderData); err != node.ErrRejected {
+		t.Fatalf("\t%s\tUnregistered authority order not rejected : %v", tests.Failed, err)
+	}
+
+	response := checkResponse(t, "M2")
+	for _, output := range response.TxOut {
+		action, err := protocol.Deserialize(output.PkScript, test.NodeConfig.IsTest)
+		if err != nil {
+			continue
+		}
+		if rejection, ok := action.(*actions.Rejection); ok {
+			if rejection.RejectionCode != actions.RejectionsInvalidSignature {
+				t.Fatalf("\t%s\tWrong rejection code : %d", tests.Failed, rejection.RejectionCode)
+			}
+		}
+	}
+
+	t.Logf("\t%s\tOrder from unregistered authority rejected", tests.Success)
+}
+
 func thawOrder(t *testing.T) {
 	ctx := test.Context
 
@@ -496,6 +568,154 @@ func reconcileOrder(t *testing.T) {
 	t.Logf("\t%s\tVerified user balance : %d", tests.Success, userHolding.FinalizedBalance)
 }
 
+func orderRegistry(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I", 1,
+		"John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 300)
+
+	freezeData := actions.Order{
+		ComplianceAction:   actions.ComplianceActionFreeze,
+		AssetType:          testAssetType,
+		AssetCode:          testAssetCodes[0].Bytes(),
+		Message:            "Court order",
+		AuthorityName:      "District Court #345",
+		SupportingEvidence: []byte("Case 2020-345"),
+	}
+	freezeData.TargetAddresses = append(freezeData.TargetAddresses, &actions.TargetAddressField{
+		Address:  userKey.Address.Bytes(),
+		Quantity: 200,
+	})
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100005, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &freezeData, 2500); err != nil {
+		t.Fatalf("\t%s\tFailed to send freeze order : %v", tests.Failed, err)
+	}
+
+	orders, err := enforcement.List(ctx, test.MasterDB, test.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list orders : %v", tests.Failed, err)
+	}
+	if len(orders) != 1 || orders[0].Status != enforcement.StatusPending {
+		t.Fatalf("\t%s\tFreeze order not pending", tests.Failed)
+	}
+	freezeOrderTxId := orders[0].TxId
+
+	freezeTx := checkResponse(t, "E2")
+	freezeTxId := protocol.TxIdFromBytes(freezeTx.TxHash()[:])
+
+	o, err := enforcement.Fetch(ctx, test.MasterDB, test.ContractKey.Address, freezeOrderTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch freeze order : %v", tests.Failed, err)
+	}
+	if o.Status != enforcement.StatusApplied {
+		t.Fatalf("\t%s\tWrong freeze order status : %s", tests.Failed, o.Status)
+	}
+	if len(o.ResponseTxIds) != 1 || !o.ResponseTxIds[0].Equal(*freezeTxId) {
+		t.Fatalf("\t%s\tFreeze response not linked to order", tests.Failed)
+	}
+	if o.AuthorityName != freezeData.AuthorityName || o.SupportingEvidenceHash == nil {
+		t.Fatalf("\t%s\tFreeze order authority not recorded", tests.Failed)
+	}
+	if len(o.Targets) != 1 || !o.Targets[0].Address.Equal(userKey.Address) ||
+		o.Targets[0].Quantity != 200 {
+		t.Fatalf("\t%s\tFreeze order targets not recorded", tests.Failed)
+	}
+	t.Logf("\t%s\tFreeze order applied", tests.Success)
+
+	thawData := actions.Order{
+		ComplianceAction: actions.ComplianceActionThaw,
+		AssetType:        testAssetType,
+		AssetCode:        testAssetCodes[0].Bytes(),
+		FreezeTxId:       freezeTxId.Bytes(),
+		Message:          "Court order lifted",
+	}
+
+	fundingTx = tests.MockFundingTx(ctx, test.RPCNode, 100006, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &thawData, 2500); err != nil {
+		t.Fatalf("\t%s\tFailed to send thaw order : %v", tests.Failed, err)
+	}
+	thawTx := checkResponse(t, "E3")
+	thawTxId := protocol.TxIdFromBytes(thawTx.TxHash()[:])
+
+	o, err = enforcement.Fetch(ctx, test.MasterDB, test.ContractKey.Address, freezeOrderTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch freeze order : %v", tests.Failed, err)
+	}
+	if o.Status != enforcement.StatusThawed {
+		t.Fatalf("\t%s\tWrong freeze order status : %s", tests.Failed, o.Status)
+	}
+	if len(o.ResponseTxIds) != 2 || !o.ResponseTxIds[1].Equal(*thawTxId) {
+		t.Fatalf("\t%s\tThaw response not linked to freeze order", tests.Failed)
+	}
+
+	orders, err = enforcement.List(ctx, test.MasterDB, test.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list orders : %v", tests.Failed, err)
+	}
+	if len(orders) != 2 || orders[1].Status != enforcement.StatusApplied ||
+		!orders[1].FreezeTxId.Equal(*freezeTxId) {
+		t.Fatalf("\t%s\tThaw order not applied", tests.Failed)
+	}
+	if affected := enforcement.Filter(orders, "", userKey.Address); len(affected) != 1 {
+		t.Fatalf("\t%s\tWrong order count for user : %d", tests.Failed, len(affected))
+	}
+	t.Logf("\t%s\tFreeze order thawed", tests.Success)
+}
+
+// underfundedOrderRegistry checks that orders without a response aren't recorded.
+func underfundedOrderRegistry(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I", 1,
+		"John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 300)
+
+	freezeData := actions.Order{
+		ComplianceAction: actions.ComplianceActionFreeze,
+		AssetType:        testAssetType,
+		AssetCode:        testAssetCodes[0].Bytes(),
+		Message:          "Court order",
+	}
+	freezeData.TargetAddresses = append(freezeData.TargetAddresses, &actions.TargetAddressField{
+		Address:  userKey.Address.Bytes(),
+		Quantity: 200,
+	})
+
+	// Enough to fund a rejection, but not the freeze.
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100005, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &freezeData,
+		1000); errors.Cause(err) != node.ErrRejected {
+		t.Fatalf("\t%s\tUnderfunded freeze order not rejected : %v", tests.Failed, err)
+	}
+	checkResponse(t, "M2")
+
+	// Not enough to fund any response.
+	fundingTx = tests.MockFundingTx(ctx, test.RPCNode, 100006, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &freezeData,
+		100); errors.Cause(err) != node.ErrNoResponse {
+		t.Fatalf("\t%s\tUnfunded freeze order responded : %v", tests.Failed, err)
+	}
+
+	orders, err := enforcement.List(ctx, test.MasterDB, test.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list orders : %v", tests.Failed, err)
+	}
+	if len(orders) != 0 {
+		t.Fatalf("\t%s\tUnderfunded order recorded : %d orders", tests.Failed, len(orders))
+	}
+	t.Logf("\t%s\tUnderfunded orders not recorded", tests.Success)
+}
+
 func mockUpFreeze(ctx context.Context, t *testing.T, address bitcoin.RawAddress, quantity uint64) (*protocol.TxId, error) {
 	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 1000013, issuerKey.Address)
 
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/tokenized/pkg/wire"
 	"github.com/tokenized/smart-contract/internal/asset"
 	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/delegation"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/state"
@@ -32,6 +34,12 @@ func TestGovernance(t *testing.T) {
 	t.Run("result", voteResult)
 	t.Run("relativeResult", voteResultRelative)
 	t.Run("absoluteResult", voteResultAbsolute)
+	t.Run("noQuorumResult", voteResultNoQuorum)
+	t.Run("proxyBallot", proxyBallot)
+	t.Run("autoApplyResult", autoApplyResult)
+	t.Run("autoApplyAssetResult", autoApplyAssetResult)
+	t.Run("earlyClose", earlyClose)
+	t.Run("auditResult", auditResult)
 }
 
 func holderProposal(t *testing.T) {
@@ -554,6 +562,675 @@ func voteResultAbsolute(t *testing.T) {
 	t.Logf("\t%s\tVerified result : \"%s\"", tests.Success, vt.Result)
 }
 
+func voteResultNoQuorum(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+
+	policy := &contract.GovernancePolicy{QuorumPercentages: []uint32{30}}
+	if err := contract.SaveGovernancePolicy(ctx, test.MasterDB, test.ContractKey.Address,
+		policy); err != nil {
+		t.Fatalf("\t%s\tFailed to save governance policy : %v", tests.Failed, err)
+	}
+
+	err := mockUpVote(ctx, 0)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to mock up vote : %v", tests.Failed, err)
+	}
+
+	err = mockUpBallot(ctx, userKey.Address, 250, "A")
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to mock up ballot : %v", tests.Failed, err)
+	}
+
+	// Wait for vote expiration
+	time.Sleep(time.Second)
+
+	responseLock.Lock()
+	if len(responses) > 0 {
+		hash := responses[0].TxHash()
+		testVoteResultTxId = *protocol.TxIdFromBytes(hash[:])
+	}
+	responseLock.Unlock()
+
+	// Check the response
+	response := checkResponse(t, "G5")
+
+	// The result sent on chain can be told apart from a vote in which no option passed.
+	for _, output := range response.TxOut {
+		action, err := protocol.Deserialize(output.PkScript, test.NodeConfig.IsTest)
+		if err != nil {
+			continue
+		}
+		if result, ok := action.(*actions.Result); ok && result.Result != vote.ResultNoQuorum {
+			t.Fatalf("\t%s\tResult action incorrect : \"%s\" != \"%s\"", tests.Failed,
+				result.Result, vote.ResultNoQuorum)
+		}
+	}
+
+	// Verify result
+	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+
+	if vt.CompletedAt.Nano() == 0 {
+		t.Fatalf("\t%s\tVote not completed", tests.Failed)
+	}
+
+	if vt.Quorum != 30 {
+		t.Fatalf("\t%s\tVote quorum incorrect : %d != 30", tests.Failed, vt.Quorum)
+	}
+
+	if vt.Turnout != 250 {
+		t.Fatalf("\t%s\tVote turnout incorrect : %d != 250", tests.Failed, vt.Turnout)
+	}
+
+	t.Logf("\t%s\tVerified turnout : %d", tests.Success, vt.Turnout)
+
+	if !vt.NoQuorum {
+		t.Fatalf("\t%s\tVote not marked as no quorum", tests.Failed)
+	}
+
+	if vt.Result != vote.ResultNoQuorum {
+		t.Fatalf("\t%s\tVote result incorrect : \"%s\" != \"%s\"", tests.Failed, vt.Result,
+			vote.ResultNoQuorum)
+	}
+
+	t.Logf("\t%s\tVerified no quorum result", tests.Success)
+}
+
+// proxyBallot tests ballots cast by a proxy for a delegator, and the delegator overriding them.
+func proxyBallot(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+	mockUpHolding(t, ctx, user2Key.Address, 300)
+
+	// Delegate user2's votes to user
+	if err := sendDelegation(ctx, user2Key.Address, userKey.Address); err != nil {
+		t.Fatalf("\t%s\tFailed to send delegation : %v", tests.Failed, err)
+	}
+
+	delegations, err := delegation.List(ctx, test.MasterDB, test.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list delegations : %v", tests.Failed, err)
+	}
+	if len(delegations) != 1 || !delegations[0].Proxy.Equal(userKey.Address) {
+		t.Fatalf("\t%s\tDelegation not saved", tests.Failed)
+	}
+	t.Logf("\t%s\tVerified delegation", tests.Success)
+
+	// Ballots are cast after the vote is created, so the cut off is based on the current time.
+	voteCtx := context.WithValue(ctx, node.KeyValues,
+		&node.Values{Now: protocol.CurrentTimestamp()})
+	if err := mockUpVote(voteCtx, 0); err != nil {
+		t.Fatalf("\t%s\tFailed to mock up vote : %v", tests.Failed, err)
+	}
+
+	// The proxy casts its own ballot and the delegator's
+	if err := sendBallotCast(ctx, userKey.Address, "A"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	response := checkResponse(t, "G4")
+	if quantity := ballotCountedQuantity(t, response); quantity != 550 {
+		t.Fatalf("\t%s\tProxy ballot quantity incorrect : %d != 550", tests.Failed, quantity)
+	}
+	t.Logf("\t%s\tVerified proxy ballot quantity", tests.Success)
+
+	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	user2Hash, _ := user2Key.Address.Hash()
+	ballot := vt.Ballots[*user2Hash]
+	if ballot.Vote != "A" || !ballot.CastByProxy {
+		t.Fatalf("\t%s\tDelegated ballot not cast by proxy : %+v", tests.Failed, ballot)
+	}
+
+	// The delegator overrides the proxy's ballot
+	if err := sendBallotCast(ctx, user2Key.Address, "B"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	response = checkResponse(t, "G4")
+	if quantity := ballotCountedQuantity(t, response); quantity != 300 {
+		t.Fatalf("\t%s\tDelegator ballot quantity incorrect : %d != 300", tests.Failed, quantity)
+	}
+
+	vt, err = vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	ballot = vt.Ballots[*user2Hash]
+	if ballot.Vote != "B" || ballot.CastByProxy {
+		t.Fatalf("\t%s\tDelegator ballot not overridden : %+v", tests.Failed, ballot)
+	}
+	t.Logf("\t%s\tVerified delegator override", tests.Success)
+
+	// The proxy has nothing left to cast
+	if err := sendBallotCast(ctx, userKey.Address, "B"); errors.Cause(err) != node.ErrRejected {
+		t.Fatalf("\t%s\tProxy ballot not rejected : %v", tests.Failed, err)
+	}
+	checkResponse(t, "M2")
+}
+
+// autoApplyResult tests amendments of a passed proposal being applied when the vote completes.
+func autoApplyResult(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, true)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, false, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+
+	now := protocol.CurrentTimestamp()
+	proposalData := actions.Proposal{
+		Type:                1,
+		VoteSystem:          0,
+		VoteOptions:         "AB",
+		VoteMax:             1,
+		ProposalDescription: "Change contract name",
+		VoteCutOffTimestamp: now.Nano() + 500000000,
+	}
+
+	fip := actions.FieldIndexPath{actions.ContractFieldContractName}
+	fipBytes, _ := fip.Bytes()
+	proposalData.ProposedAmendments = append(proposalData.ProposedAmendments,
+		&actions.AmendmentField{
+			FieldIndexPath: fipBytes,
+			Data:           []byte("Test Name 2"),
+		})
+
+	autoApplyVote(t, &proposalData, "C2")
+
+	ct, err := contract.Retrieve(ctx, test.MasterDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve contract : %v", tests.Failed, err)
+	}
+
+	cf, err := contract.FetchContractFormation(ctx, test.MasterDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve contract formation : %v", tests.Failed, err)
+	}
+
+	if cf.ContractName != "Test Name 2" || ct.Revision != 1 {
+		t.Fatalf("\t%s\tAmendment not applied : \"%s\" revision %d", tests.Failed,
+			cf.ContractName, ct.Revision)
+	}
+
+	t.Logf("\t%s\tVerified contract name : %s", tests.Success, cf.ContractName)
+}
+
+// autoApplyAssetResult tests asset amendments of a passed proposal being applied when the vote
+//   completes, including a token quantity change to the administration's holding.
+func autoApplyAssetResult(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, true)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, false, true, true)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+
+	as, err := asset.Retrieve(ctx, test.MasterDB, test.ContractKey.Address, &testAssetCodes[0])
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve asset : %v", tests.Failed, err)
+	}
+	as.HolderProposal = true
+	if err := asset.Save(ctx, test.MasterDB, test.ContractKey.Address, as); err != nil {
+		t.Fatalf("\t%s\tFailed to save asset : %v", tests.Failed, err)
+	}
+
+	now := protocol.CurrentTimestamp()
+	proposalData := actions.Proposal{
+		Type:                1,
+		AssetType:           testAssetType,
+		AssetCode:           testAssetCodes[0].Bytes(),
+		VoteSystem:          0,
+		VoteOptions:         "AB",
+		VoteMax:             1,
+		ProposalDescription: "Increase token quantity",
+		VoteCutOffTimestamp: now.Nano() + 500000000,
+	}
+
+	newQuantity := uint64(1200)
+	var buf bytes.Buffer
+	if err := bitcoin.WriteBase128VarInt(&buf, newQuantity); err != nil {
+		t.Fatalf("\t%s\tFailed to serialize new quantity : %v", tests.Failed, err)
+	}
+
+	fip := actions.FieldIndexPath{actions.AssetFieldTokenQty}
+	fipBytes, _ := fip.Bytes()
+	proposalData.ProposedAmendments = append(proposalData.ProposedAmendments,
+		&actions.AmendmentField{
+			FieldIndexPath: fipBytes,
+			Data:           buf.Bytes(),
+		})
+
+	autoApplyVote(t, &proposalData, "A2")
+
+	as, err = asset.Retrieve(ctx, test.MasterDB, test.ContractKey.Address, &testAssetCodes[0])
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve asset : %v", tests.Failed, err)
+	}
+
+	if as.TokenQty != newQuantity || as.Revision != 1 {
+		t.Fatalf("\t%s\tAmendment not applied : quantity %d revision %d", tests.Failed,
+			as.TokenQty, as.Revision)
+	}
+
+	t.Logf("\t%s\tVerified token quantity : %d", tests.Success, as.TokenQty)
+
+	v := ctx.Value(node.KeyValues).(*node.Values)
+	h, err := holdings.GetHolding(ctx, test.MasterDB, test.ContractKey.Address,
+		&testAssetCodes[0], issuerKey.Address, v.Now)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to get administration holding : %v", tests.Failed, err)
+	}
+
+	if h.FinalizedBalance != newQuantity || h.PendingBalance != newQuantity {
+		t.Fatalf("\t%s\tAdministration balance incorrect : %d/%d != %d", tests.Failed,
+			h.FinalizedBalance, h.PendingBalance, newQuantity)
+	}
+
+	t.Logf("\t%s\tVerified administration balance : %d", tests.Success, h.FinalizedBalance)
+}
+
+// autoApplyVote sends a proposal from the user, votes for it and processes its result, then checks
+//   that the amendments are applied with a response of the response code.
+func autoApplyVote(t *testing.T, proposalData *actions.Proposal, responseCode string) {
+	ctx := test.Context
+
+	policy := &contract.GovernancePolicy{AutoApply: []bool{true}}
+	if err := contract.SaveGovernancePolicy(ctx, test.MasterDB, test.ContractKey.Address,
+		policy); err != nil {
+		t.Fatalf("\t%s\tFailed to save governance policy : %v", tests.Failed, err)
+	}
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100009, userKey.Address)
+
+	// Build proposal transaction
+	proposalTx := wire.NewMsgTx(1)
+	proposalTx.TxIn = append(proposalTx.TxIn, wire.NewTxIn(wire.NewOutPoint(fundingTx.TxHash(), 0),
+		make([]byte, 130)))
+
+	// To contract for vote, result, and amendment responses
+	script, _ := test.ContractKey.Address.LockingScript()
+	proposalTx.TxOut = append(proposalTx.TxOut, wire.NewTxOut(52000, script))
+	proposalTx.TxOut = append(proposalTx.TxOut, wire.NewTxOut(3000, script))
+	proposalTx.TxOut = append(proposalTx.TxOut, wire.NewTxOut(3000, script))
+
+	// Data output
+	script, err := protocol.Serialize(proposalData, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize proposal : %v", tests.Failed, err)
+	}
+	proposalTx.TxOut = append(proposalTx.TxOut, wire.NewTxOut(0, script))
+
+	proposalItx, err := inspector.NewTransactionFromWire(ctx, proposalTx, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create proposal itx : %v", tests.Failed, err)
+	}
+
+	if err := proposalItx.Promote(ctx, test.RPCNode); err != nil {
+		t.Fatalf("\t%s\tFailed to promote proposal itx : %v", tests.Failed, err)
+	}
+
+	test.RPCNode.SaveTX(ctx, proposalTx)
+
+	if err := a.Trigger(ctx, "SEE", proposalItx); err != nil {
+		t.Fatalf("\t%s\tFailed to accept proposal : %v", tests.Failed, err)
+	}
+
+	responseLock.Lock()
+	if len(responses) > 0 {
+		hash := responses[0].TxHash()
+		testVoteTxId = *protocol.TxIdFromBytes(hash[:])
+	}
+	responseLock.Unlock()
+
+	checkResponse(t, "G2")
+
+	if err := mockUpBallot(ctx, userKey.Address, 250, "A"); err != nil {
+		t.Fatalf("\t%s\tFailed to mock up ballot : %v", tests.Failed, err)
+	}
+
+	// Wait for vote expiration. The result creates the amendment response, so it is processed
+	//   here instead of by checkResponse.
+	var resultTx *wire.MsgTx
+	for i := 0; i < 50 && resultTx == nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+		resultTx = getResponse()
+	}
+	if resultTx == nil || responseType(resultTx) != "G5" {
+		t.Fatalf("\t%s\tResult not created", tests.Failed)
+	}
+
+	resultItx, err := inspector.NewTransactionFromWire(ctx, resultTx, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create result itx : %v", tests.Failed, err)
+	}
+
+	if err := resultItx.Promote(ctx, test.RPCNode); err != nil {
+		t.Fatalf("\t%s\tFailed to promote result itx : %v", tests.Failed, err)
+	}
+
+	test.RPCNode.SaveTX(ctx, resultTx)
+
+	if err := a.Trigger(ctx, "SEE", resultItx); err != nil {
+		t.Fatalf("\t%s\tFailed to process result : %v", tests.Failed, err)
+	}
+
+	amendmentTx := checkResponse(t, responseCode)
+	if !amendmentTx.TxIn[0].PreviousOutPoint.Hash.Equal(resultTx.TxHash()) {
+		t.Fatalf("\t%s\tAmendment response doesn't spend the result", tests.Failed)
+	}
+
+	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+
+	if vt.AppliedTxId == nil {
+		t.Fatalf("\t%s\tVote not marked applied", tests.Failed)
+	}
+
+	t.Logf("\t%s\tVerified vote applied : %s", tests.Success, vt.AppliedTxId.String())
+}
+
+func earlyClose(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 800)
+
+	policy := &contract.GovernancePolicy{EarlyClose: []bool{false, true}}
+	if err := contract.SaveGovernancePolicy(ctx, test.MasterDB, test.ContractKey.Address,
+		policy); err != nil {
+		t.Fatalf("\t%s\tFailed to save governance policy : %v", tests.Failed, err)
+	}
+
+	// Absolute vote with a cut off well after the test would wait for the result.
+	now := protocol.CurrentTimestamp()
+	voteCtx := context.WithValue(ctx, node.KeyValues,
+		&node.Values{Now: protocol.NewTimestamp(now.Nano() + 10000000000)})
+	if err := mockUpVote(voteCtx, 1); err != nil {
+		t.Fatalf("\t%s\tFailed to mock up vote : %v", tests.Failed, err)
+	}
+
+	// The issuer's ballot could still move "B" across the threshold.
+	if err := sendBallotCast(ctx, userKey.Address, "A"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	checkResponse(t, "G4")
+
+	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	if vt.ClosedEarlyAt.Nano() != 0 {
+		t.Fatalf("\t%s\tVote closed early before it was decided", tests.Failed)
+	}
+
+	if err := sendBallotCast(ctx, issuerKey.Address, "A"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	checkResponse(t, "G4")
+
+	vt, err = vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	if vt.ClosedEarlyAt.Nano() == 0 {
+		t.Fatalf("\t%s\tVote not closed early", tests.Failed)
+	}
+	t.Logf("\t%s\tVerified vote closed early : %s", tests.Success, vt.CloseReason)
+
+	// Wait for the finalizer that replaced the one at the cut off.
+	for i := 0; i < 50; i++ {
+		responseLock.Lock()
+		count := len(responses)
+		responseLock.Unlock()
+		if count > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	checkResponse(t, "G5")
+
+	vt, err = vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	if vt.CompletedAt.Nano() >= vt.Expires.Nano() || vt.Result != "A" {
+		t.Fatalf("\t%s\tWrong early result : \"%s\"", tests.Failed, vt.Result)
+	}
+	t.Logf("\t%s\tVerified early result : %s", tests.Success, vt.Result)
+}
+
+func auditResult(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+	mockUpHolding(t, ctx, user2Key.Address, 300)
+
+	voteCtx := context.WithValue(ctx, node.KeyValues,
+		&node.Values{Now: protocol.CurrentTimestamp()})
+	if err := mockUpVote(voteCtx, 0); err != nil {
+		t.Fatalf("\t%s\tFailed to mock up vote : %v", tests.Failed, err)
+	}
+
+	if err := sendBallotCast(ctx, userKey.Address, "A"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	checkResponse(t, "G4")
+
+	if err := sendBallotCast(ctx, user2Key.Address, "B"); err != nil {
+		t.Fatalf("\t%s\tFailed to send ballot : %v", tests.Failed, err)
+	}
+	checkResponse(t, "G4")
+
+	// Wait for vote expiration
+	for i := 0; i < 50; i++ {
+		responseLock.Lock()
+		count := len(responses)
+		responseLock.Unlock()
+		if count > 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	checkResponse(t, "G5")
+
+	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve vote : %v", tests.Failed, err)
+	}
+	if vt.BallotRoot == nil {
+		t.Fatalf("\t%s\tBallot root not recorded", tests.Failed)
+	}
+
+	hash, _ := bitcoin.NewHash32(vt.ProposalTxId.Bytes())
+	proposalTx, err := transactions.GetTx(ctx, test.MasterDB, hash, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve proposal : %v", tests.Failed, err)
+	}
+	proposal := proposalTx.MsgProto.(*actions.Proposal)
+
+	ct, err := contract.Retrieve(ctx, test.MasterDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve contract : %v", tests.Failed, err)
+	}
+	votingSystem := ct.VotingSystems[proposal.VoteSystem]
+
+	report, err := vote.NewAuditReport(vt, proposal, votingSystem)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create audit report : %v", tests.Failed, err)
+	}
+	if !report.BallotRoot.Equal(vt.BallotRoot) {
+		t.Fatalf("\t%s\tReport root doesn't match vote : %s != %s", tests.Failed,
+			report.BallotRoot.String(), vt.BallotRoot.String())
+	}
+
+	index, proof, err := report.Proof(user2Key.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create ballot proof : %v", tests.Failed, err)
+	}
+	if !vote.VerifyMerkleProof(report.Entries[index].Leaf, index, proof, *vt.BallotRoot) {
+		t.Fatalf("\t%s\tBallot proof didn't verify", tests.Failed)
+	}
+	t.Logf("\t%s\tVerified ballot root : %s", tests.Success, vt.BallotRoot.String())
+
+	mismatches, err := vote.VerifyAudit(ctx, test.MasterDB, vt, proposal, votingSystem,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to verify audit : %v", tests.Failed, err)
+	}
+	if len(mismatches) != 0 {
+		t.Fatalf("\t%s\tAudit mismatches : %v", tests.Failed, mismatches)
+	}
+
+	// A ballot changed after it was counted doesn't match its tx or the root.
+	user2Hash, _ := user2Key.Address.Hash()
+	ballot := vt.Ballots[*user2Hash]
+	ballot.Vote = "A"
+	vt.Ballots[*user2Hash] = ballot
+
+	mismatches, err = vote.VerifyAudit(ctx, test.MasterDB, vt, proposal, votingSystem,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to verify audit : %v", tests.Failed, err)
+	}
+	if len(mismatches) == 0 {
+		t.Fatalf("\t%s\tChanged ballot not found by audit", tests.Failed)
+	}
+	t.Logf("\t%s\tVerified audit mismatches : %v", tests.Success, mismatches)
+}
+
+// sendDelegation sends a message from the delegator to the contract that delegates its votes to
+//   the proxy.
+func sendDelegation(ctx context.Context, delegator, proxy bitcoin.RawAddress) error {
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100010, delegator)
+
+	payload := &delegation.Message{Proxy: proxy}
+	b, err := payload.Bytes()
+	if err != nil {
+		return err
+	}
+
+	messageData := actions.Message{
+		MessageCode:    delegation.MessageCode,
+		MessagePayload: b,
+	}
+
+	return sendRequest(ctx, fundingTx, &messageData)
+}
+
+// sendBallotCast sends a ballot for the test vote from the address.
+func sendBallotCast(ctx context.Context, address bitcoin.RawAddress, choice string) error {
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100010, address)
+
+	ballotData := actions.BallotCast{
+		VoteTxId: testVoteTxId.Bytes(),
+		Vote:     choice,
+	}
+
+	return sendRequest(ctx, fundingTx, &ballotData)
+}
+
+// sendRequest sends a request to the contract funded by the first output of the funding tx.
+func sendRequest(ctx context.Context, fundingTx *wire.MsgTx, action actions.Action) error {
+	return sendRequestValue(ctx, fundingTx, action, 2000)
+}
+
+// sendRequestValue sends a request to the contract that pays it value.
+func sendRequestValue(ctx context.Context, fundingTx *wire.MsgTx, action actions.Action,
+	value uint64) error {
+	itx, err := newRequest(ctx, fundingTx, action, value)
+	if err != nil {
+		return err
+	}
+
+	return a.Trigger(ctx, "SEE", itx)
+}
+
+// newRequest builds a request to the contract that pays it value and saves it to the node.
+func newRequest(ctx context.Context, fundingTx *wire.MsgTx, action actions.Action,
+	value uint64) (*inspector.Transaction, error) {
+	tx := wire.NewMsgTx(1)
+	tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(fundingTx.TxHash(), 0),
+		make([]byte, 130)))
+
+	script, _ := test.ContractKey.Address.LockingScript()
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+
+	script, err := protocol.Serialize(action, test.NodeConfig.IsTest)
+	if err != nil {
+		return nil, err
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(0, script))
+
+	itx, err := inspector.NewTransactionFromWire(ctx, tx, test.NodeConfig.IsTest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := itx.Promote(ctx, test.RPCNode); err != nil {
+		return nil, err
+	}
+
+	test.RPCNode.SaveTX(ctx, tx)
+
+	return itx, nil
+}
+
+// ballotCountedQuantity returns the quantity of a ballot counted response.
+func ballotCountedQuantity(t *testing.T, response *wire.MsgTx) uint64 {
+	for _, output := range response.TxOut {
+		action, err := protocol.Deserialize(output.PkScript, test.NodeConfig.IsTest)
+		if err != nil {
+			continue
+		}
+		if counted, ok := action.(*actions.BallotCounted); ok {
+			return counted.Quantity
+		}
+	}
+
+	t.Fatalf("\t%s\tBallot counted not found", tests.Failed)
+	return 0
+}
+
 func mockUpBallot(ctx context.Context, address bitcoin.RawAddress, quantity uint64, v string) error {
 	vt, err := vote.Fetch(ctx, test.MasterDB, test.ContractKey.Address, &testVoteTxId)
 	if err != nil {
@@ -659,6 +1336,7 @@ func mockUpVote(ctx context.Context, voteSystem uint32) error {
 	testVoteTxId = *protocol.TxIdFromBytes(voteItx.Hash[:])
 
 	test.RPCNode.SaveTX(ctx, voteTx)
+	transactions.AddTx(ctx, test.MasterDB, voteItx)
 
 	err = a.Trigger(ctx, "SEE", voteItx)
 	if err != nil {
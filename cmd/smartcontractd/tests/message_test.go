@@ -1,12 +1,330 @@
 package tests
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/notice"
+	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/internal/revenue"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/messages"
+	"github.com/tokenized/specification/dist/golang/protocol"
 )
 
 // TestMessages is the entry point for testing message functions.
 func TestMessages(t *testing.T) {
 	defer tests.Recover(t)
+
+	t.Run("notice", holderNotice)
+	t.Run("revertTransfer", revertTransfer)
+	t.Run("revertOrder", revertOrder)
+}
+
+func holderNotice(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+
+	// Three holders in batches of two.
+	batchSize := test.NodeConfig.NoticeBatchSize
+	test.NodeConfig.NoticeBatchSize = 2
+	defer func() { test.NodeConfig.NoticeBatchSize = batchSize }()
+
+	now := protocol.CurrentTimestamp()
+	publicMessage := messages.PublicMessage{
+		Timestamp: now.Nano(),
+		Subject:   "Annual General Meeting",
+		PublicMessage: &messages.DocumentField{
+			Name:     "agm.txt",
+			Type:     "text/plain",
+			Contents: []byte("The annual general meeting will be held online."),
+		},
+	}
+	publicPayload, err := publicMessage.Bytes()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize public message : %v", tests.Failed, err)
+	}
+	payload, err := (&notice.Message{Payload: publicPayload}).Bytes()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to encode notice : %v", tests.Failed, err)
+	}
+
+	noticeData := actions.Message{
+		ReceiverIndexes: []uint32{0},
+		MessageCode:     notice.MessageCode,
+		MessagePayload:  payload,
+	}
+
+	// No holders to send to.
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100009, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &noticeData, 20000); err != node.ErrRejected {
+		t.Fatalf("\t%s\tNotice without holders not rejected : %v", tests.Failed, err)
+	}
+	checkNoticeRejection(t, "No holders to send notice to")
+
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+	mockUpHolding(t, ctx, user2Key.Address, 300)
+
+	// Not from the administration.
+	fundingTx = tests.MockFundingTx(ctx, test.RPCNode, 100010, userKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &noticeData, 20000); err != node.ErrRejected {
+		t.Fatalf("\t%s\tNotice from holder not rejected : %v", tests.Failed, err)
+	}
+	checkResponse(t, "M2")
+
+	fundingTx = tests.MockFundingTx(ctx, test.RPCNode, 100010, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &noticeData, 20000); err != nil {
+		t.Fatalf("\t%s\tFailed to send notice : %v", tests.Failed, err)
+	}
+
+	received := 0
+	for i := 0; i < 2; i++ {
+		response := checkResponse(t, "M1")
+		for _, output := range response.TxOut {
+			action, err := protocol.Deserialize(output.PkScript, test.NodeConfig.IsTest)
+			if err != nil {
+				continue
+			}
+			if message, ok := action.(*actions.Message); ok {
+				if message.MessageCode != messages.CodePublicMessage {
+					t.Fatalf("\t%s\tWrong notice message code : %d", tests.Failed,
+						message.MessageCode)
+				}
+				received += len(message.ReceiverIndexes)
+			}
+		}
+	}
+	if received != 3 {
+		t.Fatalf("\t%s\tWrong notice recipient count : got %d, want 3", tests.Failed, received)
+	}
+
+	list, err := notice.List(ctx, test.MasterDB, test.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list notices : %v", tests.Failed, err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("\t%s\tWrong notice count : %d", tests.Failed, len(list))
+	}
+	if len(list[0].Recipients) != 3 || len(list[0].TxIds) != 2 {
+		t.Fatalf("\t%s\tWrong notice delivery record : %d recipients, %d txs", tests.Failed,
+			len(list[0].Recipients), len(list[0].TxIds))
+	}
+	t.Logf("\t%s\tNotice sent to %d holders", tests.Success, len(list[0].Recipients))
+
+	// The contract fee is recorded once, for the first tx.
+	ct, err := contract.Retrieve(ctx, test.MasterDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve contract : %v", tests.Failed, err)
+	}
+	entries, err := revenue.Fetch(ctx, test.MasterDB, test.ContractKey.Address,
+		protocol.NewTimestamp(0), protocol.NewTimestamp(0))
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch fee ledger : %v", tests.Failed, err)
+	}
+	noticeTxs, noticeFee := 0, uint64(0)
+	for _, entry := range entries {
+		if entry.ActionCode == actions.CodeMessage {
+			noticeTxs++
+			if entry.ContractFee != 0 {
+				if noticeFee != 0 {
+					t.Fatalf("\t%s\tNotice contract fee recorded more than once", tests.Failed)
+				}
+				noticeFee = entry.ContractFee
+			}
+		}
+	}
+	if noticeTxs != 2 || noticeFee != ct.ContractFee {
+		t.Fatalf("\t%s\tWrong notice fee ledger : %d txs, contract fee %d, want 2 txs, %d",
+			tests.Failed, noticeTxs, noticeFee, ct.ContractFee)
+	}
+	t.Logf("\t%s\tVerified notice contract fee", tests.Success)
+
+	// The same notice isn't sent twice.
+	fundingTx = tests.MockFundingTx(ctx, test.RPCNode, 100010, issuerKey.Address)
+	if err := sendRequestValue(ctx, fundingTx, &noticeData, 20000); err != node.ErrRejected {
+		t.Fatalf("\t%s\tDuplicate notice not rejected : %v", tests.Failed, err)
+	}
+	checkNoticeRejection(t, "Notice already sent")
+}
+
+// checkNoticeRejection checks that a notice was rejected as not permitted for the reason given.
+func checkNoticeRejection(t *testing.T, reason string) {
+	response := checkResponse(t, "M2")
+	for _, output := range response.TxOut {
+		action, err := protocol.Deserialize(output.PkScript, test.NodeConfig.IsTest)
+		if err != nil {
+			continue
+		}
+		if rejection, ok := action.(*actions.Rejection); ok {
+			if rejection.RejectionCode != actions.RejectionsContractNotPermitted ||
+				!strings.HasSuffix(rejection.Message, reason) {
+				t.Fatalf("\t%s\tWrong notice rejection : (%d) %s", tests.Failed,
+					rejection.RejectionCode, rejection.Message)
+			}
+			t.Logf("\t%s\tNotice rejected : %s", tests.Success, reason)
+			return
+		}
+	}
+	t.Fatalf("\t%s\tNotice rejection missing", tests.Failed)
+}
+
+// revertTransfer checks that reverting a transfer before its response is seen reverts the pending
+//   holding statuses.
+func revertTransfer(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+
+	transferData := actions.Transfer{
+		Assets: []*actions.AssetTransferField{
+			&actions.AssetTransferField{
+				ContractIndex: 0,
+				AssetType:     testAssetType,
+				AssetCode:     testAssetCodes[0].Bytes(),
+				AssetSenders: []*actions.QuantityIndexField{
+					&actions.QuantityIndexField{Index: 0, Quantity: 100},
+				},
+				AssetReceivers: []*actions.AssetReceiverField{
+					&actions.AssetReceiverField{Address: userKey.Address.Bytes(), Quantity: 100},
+				},
+			},
+		},
+	}
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100012, issuerKey.Address)
+	transferItx, err := newRequest(ctx, fundingTx, &transferData, 2500)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create transfer : %v", tests.Failed, err)
+	}
+
+	revertRequest(t, transferItx, "T2", issuerKey.Address, userKey.Address)
+
+	checkPendingBalance(t, issuerKey.Address, testTokenQty)
+	checkPendingBalance(t, userKey.Address, 0)
+}
+
+// revertOrder checks that reverting a confiscation order before its response is seen reverts the
+//   pending holding statuses.
+func revertOrder(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 250)
+
+	orderData := actions.Order{
+		ComplianceAction: actions.ComplianceActionConfiscation,
+		AssetType:        testAssetType,
+		AssetCode:        testAssetCodes[0].Bytes(),
+		DepositAddress:   issuerKey.Address.Bytes(),
+		Message:          "Court order",
+		TargetAddresses: []*actions.TargetAddressField{
+			&actions.TargetAddressField{Address: userKey.Address.Bytes(), Quantity: 50},
+		},
+	}
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, 100007, issuerKey.Address)
+	orderItx, err := newRequest(ctx, fundingTx, &orderData, 3200)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create order : %v", tests.Failed, err)
+	}
+
+	revertRequest(t, orderItx, "E4", userKey.Address, issuerKey.Address)
+
+	checkPendingBalance(t, userKey.Address, 250)
+	checkPendingBalance(t, issuerKey.Address, testTokenQty)
+}
+
+// revertRequest processes the request, drops its response before it is seen, then reverts the
+//   request and checks that the holding statuses it added to the addresses are removed.
+func revertRequest(t *testing.T, itx *inspector.Transaction, responseCode string,
+	addresses ...bitcoin.RawAddress) {
+	ctx := test.Context
+
+	if err := a.Trigger(ctx, "SEE", itx); err != nil {
+		t.Fatalf("\t%s\tFailed to accept request : %v", tests.Failed, err)
+	}
+	checkResponses(t, responseCode) // Dropped, so the response is never seen.
+
+	txid := protocol.TxIdFromBytes(itx.Hash[:])
+	for _, address := range addresses {
+		if !hasHoldingStatus(t, address, txid) {
+			t.Fatalf("\t%s\tRequest didn't add holding status", tests.Failed)
+		}
+	}
+
+	// The revert message is funded by the contract.
+	contractFundingTx := wire.NewMsgTx(1)
+	contractFundingTx.TxIn = append(contractFundingTx.TxIn,
+		wire.NewTxIn(wire.NewOutPoint(tests.RandomHash(), 0), make([]byte, 130)))
+	script, _ := test.ContractKey.Address.LockingScript()
+	contractFundingTx.TxOut = append(contractFundingTx.TxOut, wire.NewTxOut(10000, script))
+	test.UTXOs.Add(contractFundingTx, []bitcoin.RawAddress{test.ContractKey.Address})
+
+	if err := a.Trigger(ctx, "LOST", itx); err != nil {
+		t.Fatalf("\t%s\tFailed to revert request : %v", tests.Failed, err)
+	}
+	checkResponses(t, "M1")
+
+	for _, address := range addresses {
+		if hasHoldingStatus(t, address, txid) {
+			t.Fatalf("\t%s\tHolding status not reverted", tests.Failed)
+		}
+	}
+	t.Logf("\t%s\tHolding statuses reverted with request", tests.Success)
+}
+
+// hasHoldingStatus returns true if the address's holding has a status for the tx.
+func hasHoldingStatus(t *testing.T, address bitcoin.RawAddress, txid *protocol.TxId) bool {
+	ctx := test.Context
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	h, err := holdings.GetHolding(ctx, test.MasterDB, test.ContractKey.Address,
+		&testAssetCodes[0], address, v.Now)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to get holding : %v", tests.Failed, err)
+	}
+
+	_, exists := h.HoldingStatuses[*txid]
+	return exists
+}
+
+// checkPendingBalance checks the pending balance of the address's holding.
+func checkPendingBalance(t *testing.T, address bitcoin.RawAddress, balance uint64) {
+	ctx := test.Context
+	v := ctx.Value(node.KeyValues).(*node.Values)
+
+	h, err := holdings.GetHolding(ctx, test.MasterDB, test.ContractKey.Address,
+		&testAssetCodes[0], address, v.Now)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to get holding : %v", tests.Failed, err)
+	}
+
+	if h.PendingBalance != balance {
+		t.Fatalf("\t%s\tWrong pending balance : got %d, want %d", tests.Failed,
+			h.PendingBalance, balance)
+	}
+	t.Logf("\t%s\tPending balance verified : %d", tests.Success, balance)
 }
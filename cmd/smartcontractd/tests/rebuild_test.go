@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/rebuild"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/assets"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+// TestRebuild tests rebuilding contract state from the contract's txs.
+func TestRebuild(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+
+	var txs []*inspector.Transaction
+
+	// Contract offer
+	offerData := actions.ContractOffer{
+		ContractName:        "Test Name",
+		BodyOfAgreementType: 2,
+		BodyOfAgreement:     []byte("This is a test contract and not to be used for any official purpose."),
+		Issuer: &actions.EntityField{
+			Type:           "I",
+			Administration: []*actions.AdministratorField{&actions.AdministratorField{Type: 1, Name: "John Smith"}},
+		},
+		VotingSystems:  []*actions.VotingSystemField{&actions.VotingSystemField{Name: "Relative 50", VoteType: "R", ThresholdPercentage: 50, HolderProposalFee: 50000}},
+		HolderProposal: true,
+	}
+
+	permissions := actions.Permissions{
+		actions.Permission{
+			Permitted: true,
+		},
+	}
+	permissions[0].VotingSystemsAllowed = []bool{true}
+
+	var err error
+	offerData.ContractPermissions, err = permissions.Bytes()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize contract permissions : %v", tests.Failed, err)
+	}
+
+	offerItx := rebuildRequest(t, &offerData, 1000)
+	txs = append(txs, offerItx)
+	txs = append(txs, rebuildResponse(t, "C2"))
+
+	// Asset definition
+	assetData := actions.AssetDefinition{
+		AssetType:          assets.CodeShareCommon,
+		TransfersPermitted: true,
+		TokenQty:           1000,
+	}
+
+	assetPayloadData := assets.ShareCommon{
+		Ticker:      "TST  ",
+		Description: "Test common shares",
+	}
+	assetData.AssetPayload, err = assetPayloadData.Bytes()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize asset payload : %v", tests.Failed, err)
+	}
+
+	assetData.AssetPermissions, err = permissions.Bytes()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize asset permissions : %v", tests.Failed, err)
+	}
+
+	txs = append(txs, rebuildRequest(t, &assetData, 100000))
+	txs = append(txs, rebuildResponse(t, "A2"))
+
+	if err := holdings.WriteCache(ctx, test.MasterDB); err != nil {
+		t.Fatalf("\t%s\tFailed to write holdings : %v", tests.Failed, err)
+	}
+
+	// Rebuild into separate storage
+	rebuildDB, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp/rebuild",
+	})
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create rebuild storage : %v", tests.Failed, err)
+	}
+	defer rebuildDB.Clear(ctx, "")
+
+	count, err := rebuild.Rebuild(ctx, rebuildDB, &test.NodeConfig, test.Wallet,
+		test.Wallet, test.ContractKey.Address, txs)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to rebuild : %v", tests.Failed, err)
+	}
+	if count != 2 {
+		t.Fatalf("\t%s\tWrong replay count : got %d, want %d", tests.Failed, count, 2)
+	}
+
+	t.Logf("\t%s\tReplayed responses", tests.Success)
+
+	current, err := rebuild.LoadState(ctx, test.MasterDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to load current state : %v", tests.Failed, err)
+	}
+
+	rebuilt, err := rebuild.LoadState(ctx, rebuildDB, test.ContractKey.Address,
+		test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to load rebuilt state : %v", tests.Failed, err)
+	}
+
+	if len(rebuilt.Assets) != 1 || len(rebuilt.Holdings) != 1 {
+		t.Fatalf("\t%s\tWrong rebuilt state : %d assets, %d holdings", tests.Failed,
+			len(rebuilt.Assets), len(rebuilt.Holdings))
+	}
+
+	diffs, err := rebuild.Diff(current, rebuilt)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to diff state : %v", tests.Failed, err)
+	}
+	for _, diff := range diffs {
+		t.Errorf("\t%s\tState difference : %s", tests.Failed, diff)
+	}
+
+	t.Logf("\t%s\tVerified rebuilt state matches", tests.Success)
+
+	// A changed balance is reported.
+	for _, h := range rebuilt.Holdings {
+		h.FinalizedBalance--
+	}
+
+	diffs, err = rebuild.Diff(current, rebuilt)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to diff state : %v", tests.Failed, err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("\t%s\tWrong differences : %v", tests.Failed, diffs)
+	}
+
+	t.Logf("\t%s\tVerified difference : %s", tests.Success, diffs[0])
+}
+
+// rebuildRequest sends a request from the issuer to the contract.
+func rebuildRequest(t *testing.T, msg actions.Action, value uint64) *inspector.Transaction {
+	ctx := test.Context
+
+	fundingTx := tests.MockFundingTx(ctx, test.RPCNode, value+1000, issuerKey.Address)
+
+	tx := wire.NewMsgTx(1)
+	tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(fundingTx.TxHash(), 0),
+		make([]byte, 130)))
+
+	script, _ := test.ContractKey.Address.LockingScript()
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+
+	script, err := protocol.Serialize(msg, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to serialize request : %v", tests.Failed, err)
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(0, script))
+
+	itx, err := inspector.NewTransactionFromWire(ctx, tx, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create itx : %v", tests.Failed, err)
+	}
+
+	if err := itx.Promote(ctx, test.RPCNode); err != nil {
+		t.Fatalf("\t%s\tFailed to promote itx : %v", tests.Failed, err)
+	}
+
+	test.RPCNode.SaveTX(ctx, tx)
+
+	if err := a.Trigger(ctx, "SEE", itx); err != nil {
+		t.Fatalf("\t%s\tFailed to handle request : %v", tests.Failed, err)
+	}
+
+	return itx
+}
+
+// rebuildResponse processes the next response and returns it.
+func rebuildResponse(t *testing.T, responseCode string) *inspector.Transaction {
+	ctx := test.Context
+
+	response := checkResponse(t, responseCode)
+
+	itx, err := inspector.NewTransactionFromWire(ctx, response, test.NodeConfig.IsTest)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create response itx : %v", tests.Failed, err)
+	}
+
+	if err := itx.Promote(ctx, test.RPCNode); err != nil {
+		t.Fatalf("\t%s\tFailed to promote response itx : %v", tests.Failed, err)
+	}
+
+	return itx
+}
@@ -0,0 +1,225 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/assets"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// requestFee is added to the value of funding txs to pay the request's tx fee.
+	requestFee = 10000
+)
+
+// Output is an output of a request tx.
+type Output struct {
+	Address bitcoin.RawAddress
+	Value   uint64
+}
+
+// BuildRequest builds a tx with an input from a new funding tx for each sender, the outputs, and
+//   a final output containing the action. The input signatures aren't valid, which daemons don't
+//   check.
+func (n *Network) BuildRequest(senders []bitcoin.RawAddress, outputs []Output,
+	action actions.Action) (*wire.MsgTx, error) {
+
+	total := uint64(requestFee)
+	for _, output := range outputs {
+		total += output.Value
+	}
+
+	tx := wire.NewMsgTx(1)
+	for _, sender := range senders {
+		fundingTx, err := n.Fund(sender, total)
+		if err != nil {
+			return nil, errors.Wrap(err, "fund")
+		}
+		tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(fundingTx.TxHash(), 0),
+			make([]byte, 130)))
+	}
+
+	for _, output := range outputs {
+		script, err := output.Address.LockingScript()
+		if err != nil {
+			return nil, errors.Wrap(err, "locking script")
+		}
+		tx.TxOut = append(tx.TxOut, wire.NewTxOut(output.Value, script))
+	}
+
+	script, err := protocol.Serialize(action, n.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "serialize action")
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(0, script))
+
+	return tx, nil
+}
+
+// CreateContract has the daemon create a contract administered by the issuer, and returns the
+//   contract formation.
+func (n *Network) CreateContract(ctx context.Context, d *Daemon, issuer bitcoin.RawAddress,
+	name string) (*actions.ContractFormation, error) {
+
+	offer := &actions.ContractOffer{
+		ContractName:        name,
+		BodyOfAgreementType: 2,
+		BodyOfAgreement:     []byte("This is a simulated contract and means nothing."),
+		Issuer: &actions.EntityField{
+			Name: name + " Issuer",
+			Type: "I",
+			Administration: []*actions.AdministratorField{
+				&actions.AdministratorField{Type: 1, Name: "John Bitcoin"},
+			},
+		},
+		VotingSystems: []*actions.VotingSystemField{
+			&actions.VotingSystemField{Name: "Relative 50", VoteType: "R",
+				ThresholdPercentage: 50, HolderProposalFee: 50000},
+		},
+		AdministrationProposal: true,
+		HolderProposal:         true,
+	}
+
+	permissions := actions.Permissions{
+		actions.Permission{
+			Permitted:            true,
+			VotingSystemsAllowed: []bool{true},
+		},
+	}
+
+	var err error
+	offer.ContractPermissions, err = permissions.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "permissions")
+	}
+
+	response, err := n.request(ctx, d, []bitcoin.RawAddress{issuer},
+		[]Output{{Address: d.ContractKey.Address, Value: 100000}}, offer)
+	if err != nil {
+		return nil, err
+	}
+
+	formation, ok := response.MsgProto.(*actions.ContractFormation)
+	if !ok {
+		return nil, responseError(response)
+	}
+
+	return formation, nil
+}
+
+// CreateAsset has the daemon create an asset of its contract, with all tokens held by the issuer,
+//   and returns the asset code.
+func (n *Network) CreateAsset(ctx context.Context, d *Daemon, issuer bitcoin.RawAddress,
+	tokenQty uint64) (*protocol.AssetCode, error) {
+
+	ct, err := contract.Retrieve(ctx, d.MasterDB, d.ContractKey.Address, d.Config.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieve contract")
+	}
+
+	payload := assets.ShareCommon{
+		Ticker:      "SIM  ",
+		Description: "Simulated common shares",
+	}
+
+	definition := &actions.AssetDefinition{
+		AssetType:                  assets.CodeShareCommon,
+		TransfersPermitted:         true,
+		EnforcementOrdersPermitted: true,
+		VotingRights:               true,
+		AdministrationProposal:     true,
+		HolderProposal:             true,
+		TokenQty:                   tokenQty,
+	}
+
+	definition.AssetPayload, err = payload.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "payload")
+	}
+
+	permissions := actions.Permissions{
+		actions.Permission{
+			Permitted:            true,
+			VotingSystemsAllowed: make([]bool, len(ct.VotingSystems)),
+		},
+	}
+	for i := range permissions[0].VotingSystemsAllowed {
+		permissions[0].VotingSystemsAllowed[i] = true
+	}
+
+	definition.AssetPermissions, err = permissions.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "permissions")
+	}
+
+	response, err := n.request(ctx, d, []bitcoin.RawAddress{issuer},
+		[]Output{{Address: d.ContractKey.Address, Value: 100000}}, definition)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, ok := response.MsgProto.(*actions.AssetCreation)
+	if !ok {
+		return nil, responseError(response)
+	}
+
+	return protocol.AssetCodeFromBytes(creation.AssetCode), nil
+}
+
+// request broadcasts a request to the daemon, runs the network, and returns the daemon's response.
+func (n *Network) request(ctx context.Context, d *Daemon, senders []bitcoin.RawAddress,
+	outputs []Output, action actions.Action) (*inspector.Transaction, error) {
+
+	tx, err := n.BuildRequest(senders, outputs, action)
+	if err != nil {
+		return nil, errors.Wrap(err, "build request")
+	}
+
+	if _, err := n.Broadcast(ctx, tx); err != nil {
+		return nil, errors.Wrap(err, "broadcast")
+	}
+
+	if err := n.Run(ctx); err != nil {
+		return nil, errors.Wrap(err, "run")
+	}
+
+	response := d.ResponseTo(*tx.TxHash())
+	if response == nil {
+		return nil, fmt.Errorf("No response to %s", action.Code())
+	}
+
+	return response, nil
+}
+
+// ResponseTo returns the last tx broadcast by the daemon that spends an output of the request, or
+//   nil if there isn't one.
+func (d *Daemon) ResponseTo(requestTxId bitcoin.Hash32) *inspector.Transaction {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for i := len(d.responses) - 1; i >= 0; i-- {
+		for _, input := range d.responses[i].MsgTx.TxIn {
+			if input.PreviousOutPoint.Hash.Equal(&requestTxId) {
+				return d.responses[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// responseError returns an error describing an unexpected response.
+func responseError(itx *inspector.Transaction) error {
+	if rejection, ok := itx.MsgProto.(*actions.Rejection); ok {
+		return fmt.Errorf("Rejected (%d) : %s", rejection.RejectionCode, rejection.Message)
+	}
+	return fmt.Errorf("Unexpected response : %s", itx.MsgProto.Code())
+}
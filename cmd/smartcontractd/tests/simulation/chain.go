@@ -0,0 +1,393 @@
+package simulation
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// block is a mined block of the simulated chain.
+type block struct {
+	hash bitcoin.Hash32
+	time uint32
+	txs  []bitcoin.Hash32
+}
+
+// chain contains every tx seen by the network, the mempool, and the mined blocks. The network's
+//   lock protects it.
+type chain struct {
+	txs     map[bitcoin.Hash32]*wire.MsgTx
+	spends  map[wire.OutPoint]bitcoin.Hash32 // Spending txid of each spent outpoint
+	mempool []bitcoin.Hash32
+	blocks  []*block // Index is the height
+
+	// removed are the txs removed by reorgs and double spends. Their outputs can't be spent.
+	removed map[bitcoin.Hash32]bool
+}
+
+func newChain() chain {
+	return chain{
+		txs:     make(map[bitcoin.Hash32]*wire.MsgTx),
+		spends:  make(map[wire.OutPoint]bitcoin.Hash32),
+		blocks:  []*block{&block{hash: randomHash()}},
+		removed: make(map[bitcoin.Hash32]bool),
+	}
+}
+
+// Fund adds a tx paying the value to the address, as if it was already on chain, so it can be
+//   spent by requests.
+func (n *Network) Fund(address bitcoin.RawAddress, value uint64) (*wire.MsgTx, error) {
+	script, err := address.LockingScript()
+	if err != nil {
+		return nil, errors.Wrap(err, "locking script")
+	}
+
+	tx := wire.NewMsgTx(1)
+
+	// A random input makes every funding tx unique.
+	hash := randomHash()
+	tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(&hash, 0), nil))
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.txs[*tx.TxHash()] = tx
+	return tx, nil
+}
+
+// FundDaemon broadcasts a tx paying the value to the daemon's contract. The daemon adds it to its
+//   UTXOs, so it can fund responses that aren't funded by a request, like revert notifications.
+func (n *Network) FundDaemon(ctx context.Context, d *Daemon,
+	value uint64) (*wire.MsgTx, error) {
+
+	tx, err := n.Fund(d.ContractKey.Address, value)
+	if err != nil {
+		return nil, errors.Wrap(err, "fund")
+	}
+
+	// Spend the funding tx so the payment is in the mempool.
+	script, err := d.ContractKey.Address.LockingScript()
+	if err != nil {
+		return nil, errors.Wrap(err, "locking script")
+	}
+
+	paymentTx := wire.NewMsgTx(1)
+	paymentTx.TxIn = append(paymentTx.TxIn, wire.NewTxIn(wire.NewOutPoint(tx.TxHash(), 0),
+		make([]byte, 130)))
+	paymentTx.TxOut = append(paymentTx.TxOut, wire.NewTxOut(value, script))
+
+	if _, err := n.Broadcast(ctx, paymentTx); err != nil {
+		return nil, errors.Wrap(err, "broadcast")
+	}
+
+	return paymentTx, nil
+}
+
+// addToMempool saves a tx, checking that it doesn't double spend another or spend a removed tx. It
+//   returns false if the tx was already broadcast. The lock must be held.
+func (n *Network) addToMempool(tx *wire.MsgTx) (bool, error) {
+	txid := *tx.TxHash()
+	for _, input := range tx.TxIn {
+		if n.removed[input.PreviousOutPoint.Hash] {
+			return false, fmt.Errorf("Missing input %s:%d", input.PreviousOutPoint.Hash.String(),
+				input.PreviousOutPoint.Index)
+		}
+
+		spender, exists := n.spends[input.PreviousOutPoint]
+		if !exists {
+			continue
+		}
+		if spender.Equal(&txid) {
+			return false, nil // Already broadcast
+		}
+		return false, fmt.Errorf("Double spend of %s:%d by %s", input.PreviousOutPoint.Hash.String(),
+			input.PreviousOutPoint.Index, spender.String())
+	}
+
+	for _, input := range tx.TxIn {
+		n.spends[input.PreviousOutPoint] = txid
+	}
+	n.txs[txid] = tx.Copy()
+	n.mempool = append(n.mempool, txid)
+	return true, nil
+}
+
+// Mine adds a block containing the txs in the mempool.
+func (n *Network) Mine(ctx context.Context) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.blocks = append(n.blocks, &block{
+		hash: randomHash(),
+		time: uint32(n.now / 1000000000),
+		txs:  n.mempool,
+	})
+	n.mempool = nil
+}
+
+// Reorg replaces the last blocks with the same number of new blocks. The new blocks contain the
+//   same txs except the removed txs, which are replaced by conflicting txs, and reverted by the
+//   daemons that processed them. Txs that spend removed txs are also removed.
+func (n *Network) Reorg(ctx context.Context, depth int, removed ...bitcoin.Hash32) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if depth >= len(n.blocks) {
+		return errors.New("Reorg deeper than chain")
+	}
+
+	remove := n.withDescendants(removed)
+
+	height := len(n.blocks) - depth
+	for _, b := range n.blocks[height:] {
+		var txs []bitcoin.Hash32
+		for _, txid := range b.txs {
+			if !remove[txid] {
+				txs = append(txs, txid)
+			}
+		}
+
+		n.blocks[height] = &block{
+			hash: randomHash(),
+			time: b.time,
+			txs:  txs,
+		}
+		height++
+	}
+
+	return n.removeTxs(ctx, removed, protomux.LOST)
+}
+
+// DoubleSpend removes an unconfirmed tx, and any txs spending it, as if a conflicting tx was
+//   mined. Daemons that haven't processed them yet drop them, and the others cancel them.
+func (n *Network) DoubleSpend(ctx context.Context, txid bitcoin.Hash32) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, b := range n.blocks {
+		for _, blockTxId := range b.txs {
+			if blockTxId.Equal(&txid) {
+				return errors.New("Tx already mined")
+			}
+		}
+	}
+
+	return n.removeTxs(ctx, []bitcoin.Hash32{txid}, protomux.STOLE)
+}
+
+// withDescendants returns the txids and the txids of all txs that spend them, directly or
+//   indirectly. The lock must be held.
+func (n *Network) withDescendants(txids []bitcoin.Hash32) map[bitcoin.Hash32]bool {
+	result := make(map[bitcoin.Hash32]bool)
+	for _, txid := range txids {
+		result[txid] = true
+	}
+
+	for {
+		added := false
+		for outpoint, spender := range n.spends {
+			if result[outpoint.Hash] && !result[spender] {
+				result[spender] = true
+				added = true
+			}
+		}
+		if !added {
+			return result
+		}
+	}
+}
+
+// removeTxs removes txs, and the txs spending them, from the mempool. The inputs of the txs are
+//   spent by conflicting txs. The daemons are notified of each removed tx with the event. The txs
+//   remain available to GetTX so daemons can still look them up. The lock must be held.
+func (n *Network) removeTxs(ctx context.Context, txids []bitcoin.Hash32, event string) error {
+	remove := n.withDescendants(txids)
+
+	var mempool []bitcoin.Hash32
+	for _, txid := range n.mempool {
+		if !remove[txid] {
+			mempool = append(mempool, txid)
+		}
+	}
+	n.mempool = mempool
+
+	for outpoint, spender := range n.spends {
+		if remove[spender] {
+			delete(n.spends, outpoint)
+		}
+	}
+
+	for _, txid := range txids {
+		tx, exists := n.txs[txid]
+		if !exists {
+			return fmt.Errorf("Unknown tx : %s", txid.String())
+		}
+
+		conflict := randomHash()
+		for _, input := range tx.TxIn {
+			n.spends[input.PreviousOutPoint] = conflict
+		}
+	}
+
+	for txid := range remove {
+		n.removed[txid] = true
+		tx, exists := n.txs[txid]
+		if !exists {
+			return fmt.Errorf("Unknown tx : %s", txid.String())
+		}
+
+		if !n.isTokenized(tx) {
+			for _, d := range n.daemons {
+				d.UTXOs.Remove(tx, []bitcoin.RawAddress{d.ContractKey.Address})
+			}
+			continue
+		}
+
+		itx, err := n.promoteLocked(ctx, tx)
+		if err != nil {
+			return errors.Wrap(err, txid.String())
+		}
+
+		n.revert(itx, event)
+	}
+
+	return nil
+}
+
+// InMempool returns true if the tx is unconfirmed and hasn't been removed.
+func (n *Network) InMempool(txid bitcoin.Hash32) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for _, mempoolTxId := range n.mempool {
+		if mempoolTxId.Equal(&txid) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRemoved returns true if the tx was removed by a reorg or double spend.
+func (n *Network) IsRemoved(txid bitcoin.Hash32) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.removed[txid]
+}
+
+// SaveTX saves a tx so it can be retrieved, without broadcasting it. It implements
+//   inspector.NodeInterface.
+func (n *Network) SaveTX(ctx context.Context, tx *wire.MsgTx) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.txs[*tx.TxHash()] = tx.Copy()
+	return nil
+}
+
+// GetTX returns a tx seen by the network. It implements inspector.NodeInterface.
+func (n *Network) GetTX(ctx context.Context, txid *bitcoin.Hash32) (*wire.MsgTx, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.getTx(txid)
+}
+
+func (n *Network) getTx(txid *bitcoin.Hash32) (*wire.MsgTx, error) {
+	tx, exists := n.txs[*txid]
+	if !exists {
+		return nil, fmt.Errorf("Unknown tx : %s", txid.String())
+	}
+	return tx.Copy(), nil
+}
+
+// GetOutputs returns the outputs of txs seen by the network. It implements
+//   inspector.NodeInterface.
+func (n *Network) GetOutputs(ctx context.Context,
+	outpoints []wire.OutPoint) ([]bitcoin.UTXO, error) {
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.getOutputs(outpoints)
+}
+
+func (n *Network) getOutputs(outpoints []wire.OutPoint) ([]bitcoin.UTXO, error) {
+	results := make([]bitcoin.UTXO, len(outpoints))
+	for i, outpoint := range outpoints {
+		tx, exists := n.txs[outpoint.Hash]
+		if !exists {
+			return results, fmt.Errorf("Unknown tx : %s", outpoint.Hash.String())
+		}
+
+		if int(outpoint.Index) >= len(tx.TxOut) {
+			return results, fmt.Errorf("Invalid output index %d/%d : %s", outpoint.Index,
+				len(tx.TxOut), outpoint.Hash.String())
+		}
+
+		results[i] = bitcoin.UTXO{
+			Hash:          outpoint.Hash,
+			Index:         outpoint.Index,
+			Value:         tx.TxOut[outpoint.Index].Value,
+			LockingScript: tx.TxOut[outpoint.Index].PkScript,
+		}
+	}
+	return results, nil
+}
+
+// LastHeight returns the height of the last block. It implements node.BitcoinHeaders.
+func (n *Network) LastHeight(ctx context.Context) int {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return len(n.blocks) - 1
+}
+
+// Hash returns the hash of the block at the height. It implements node.BitcoinHeaders.
+func (n *Network) Hash(ctx context.Context, height int) (*bitcoin.Hash32, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if height < 0 || height >= len(n.blocks) {
+		return nil, errors.New("Above current height")
+	}
+
+	hash := n.blocks[height].hash
+	return &hash, nil
+}
+
+// Time returns the time of the block at the height. It implements node.BitcoinHeaders.
+func (n *Network) Time(ctx context.Context, height int) (uint32, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if height < 0 || height >= len(n.blocks) {
+		return 0, errors.New("Above current height")
+	}
+
+	return n.blocks[height].time, nil
+}
+
+// isTokenized returns true if the tx contains a tokenized action.
+func (n *Network) isTokenized(tx *wire.MsgTx) bool {
+	for _, output := range tx.TxOut {
+		if _, err := protocol.Deserialize(output.PkScript, n.IsTest); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func randomHash() bitcoin.Hash32 {
+	var result bitcoin.Hash32
+	rand.Read(result[:])
+	return result
+}
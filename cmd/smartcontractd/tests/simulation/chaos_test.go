@@ -0,0 +1,433 @@
+package simulation
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/internal/transfer"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/messages"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+// chaosStep builds the next request of an action. It returns nil when the request can't be built
+//   because an earlier request failed, like a ballot for a vote that was never created.
+type chaosStep func(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx
+
+// chaosAction is a sequence of requests that make up a protocol action.
+type chaosAction struct {
+	name  string
+	steps []chaosStep
+}
+
+// chaosFault is injected at one request of an action, after the request is broadcast. It must
+//   leave the network idle.
+type chaosFault struct {
+	name string
+
+	// reverted is true when the request is reverted by the daemons that processed it.
+	reverted bool
+
+	inject func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx)
+}
+
+var chaosActions = []chaosAction{
+	{
+		name:  "Transfer",
+		steps: []chaosStep{singleTransferStep(100)},
+	},
+	{
+		name:  "Exchange",
+		steps: []chaosStep{exchangeStep},
+	},
+	{
+		name:  "Confiscation",
+		steps: []chaosStep{singleTransferStep(100), confiscationStep},
+	},
+	{
+		name: "Vote",
+		steps: []chaosStep{singleTransferStep(100), proposalStep, ballotStep(1, "A"),
+			ballotStep(2, "B")},
+	},
+}
+
+var chaosFaults = []chaosFault{
+	{
+		name: "double spend before processing",
+		inject: func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+			doubleSpend(t, ctx, e, tx)
+			run(t, ctx, e)
+		},
+	},
+	{
+		name:     "double spend before response seen",
+		reverted: true,
+		inject: func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+			if _, err := e.network.Step(ctx); err != nil {
+				t.Fatalf("\t%s\tFailed to step network : %v", tests.Failed, err)
+			}
+			doubleSpend(t, ctx, e, tx)
+			run(t, ctx, e)
+		},
+	},
+	{
+		name:     "double spend after response",
+		reverted: true,
+		inject: func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+			run(t, ctx, e)
+			doubleSpend(t, ctx, e, tx)
+			run(t, ctx, e)
+		},
+	},
+	{
+		name:     "reorg after confirmation",
+		reverted: true,
+		inject: func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+			run(t, ctx, e)
+			e.network.Mine(ctx)
+			if err := e.network.Reorg(ctx, 1, *tx.TxHash()); err != nil {
+				t.Fatalf("\t%s\tFailed to reorg : %v", tests.Failed, err)
+			}
+			run(t, ctx, e)
+		},
+	},
+	{
+		name: "reorg keeping tx",
+		inject: func(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+			run(t, ctx, e)
+			e.network.Mine(ctx)
+			if err := e.network.Reorg(ctx, 1); err != nil {
+				t.Fatalf("\t%s\tFailed to reorg : %v", tests.Failed, err)
+			}
+			run(t, ctx, e)
+		},
+	},
+}
+
+// TestChaos injects each fault at each request of each action, then checks that the daemons'
+//   holdings and votes are consistent.
+func TestChaos(t *testing.T) {
+	for _, action := range chaosActions {
+		for stepIndex := range action.steps {
+			for _, fault := range chaosFaults {
+				action, stepIndex, fault := action, stepIndex, fault
+				name := action.name + "/" + string('1'+rune(stepIndex)) + "/" + fault.name
+				t.Run(name, func(t *testing.T) {
+					runChaos(t, action, stepIndex, fault)
+				})
+			}
+		}
+	}
+}
+
+func runChaos(t *testing.T, action chaosAction, faultIndex int, fault chaosFault) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	// Fund the daemons so they can send revert notifications. Each notification spends a whole
+	//   payment because the change of a contract's own responses isn't added to its UTXOs.
+	for _, d := range []*Daemon{e.daemon1, e.daemon2} {
+		for i := 0; i < 5; i++ {
+			if _, err := e.network.FundDaemon(ctx, d, 20000); err != nil {
+				t.Fatalf("\t%s\tFailed to fund daemon : %v", tests.Failed, err)
+			}
+		}
+	}
+
+	var faultTx *wire.MsgTx
+	for i, step := range action.steps {
+		tx := step(t, ctx, e)
+		if tx == nil {
+			t.Logf("\t%s\tSkipped step %d", tests.Success, i+1)
+			continue
+		}
+
+		if _, err := e.network.Broadcast(ctx, tx); err != nil {
+			t.Fatalf("\t%s\tFailed to broadcast step %d : %v", tests.Failed, i+1, err)
+		}
+
+		if i == faultIndex {
+			faultTx = tx
+			fault.inject(t, ctx, e, tx)
+			continue
+		}
+
+		run(t, ctx, e)
+	}
+
+	// Expire pending transfers and finalize votes.
+	if err := e.network.Advance(ctx, 10*time.Minute); err != nil {
+		t.Fatalf("\t%s\tFailed to advance network : %v", tests.Failed, err)
+	}
+
+	checkRevertNotifications(t, e, faultTx, fault.reverted)
+	checkHoldingsConsistent(t, ctx, e, e.daemon1, e.asset1)
+	checkHoldingsConsistent(t, ctx, e, e.daemon2, e.asset2)
+	checkTransfersComplete(t, ctx, e.daemon1)
+	checkTransfersComplete(t, ctx, e.daemon2)
+	checkVotesConsistent(t, ctx, e, e.daemon1)
+}
+
+// singleTransferStep transfers asset 1 from holder 1 to holder 2.
+func singleTransferStep(quantity uint64) chaosStep {
+	return func(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx {
+		transfer := &actions.Transfer{
+			Assets: []*actions.AssetTransferField{
+				&actions.AssetTransferField{
+					ContractIndex: 0,
+					AssetType:     "SHC",
+					AssetCode:     e.asset1.Bytes(),
+					AssetSenders: []*actions.QuantityIndexField{
+						&actions.QuantityIndexField{Index: 0, Quantity: quantity},
+					},
+					AssetReceivers: []*actions.AssetReceiverField{
+						&actions.AssetReceiverField{Address: e.holder2.Address.Bytes(),
+							Quantity: quantity},
+					},
+				},
+			},
+		}
+
+		return buildRequest(t, e, []bitcoin.RawAddress{e.holder1.Address},
+			[]Output{{Address: e.daemon1.ContractKey.Address, Value: 5000}}, transfer)
+	}
+}
+
+// exchangeStep exchanges assets between the contracts.
+func exchangeStep(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx {
+	return e.buildTransfer(t, 10, 20)
+}
+
+// confiscationStep has the administration of contract 1 confiscate tokens from holder 2.
+func confiscationStep(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx {
+	order := &actions.Order{
+		ComplianceAction: actions.ComplianceActionConfiscation,
+		AssetType:        "SHC",
+		AssetCode:        e.asset1.Bytes(),
+		DepositAddress:   e.holder1.Address.Bytes(),
+		Message:          "Court order",
+		TargetAddresses: []*actions.TargetAddressField{
+			&actions.TargetAddressField{Address: e.holder2.Address.Bytes(), Quantity: 50},
+		},
+	}
+
+	return buildRequest(t, e, []bitcoin.RawAddress{e.holder1.Address},
+		[]Output{{Address: e.daemon1.ContractKey.Address, Value: 5000}}, order)
+}
+
+// proposalStep has the administration of contract 1 propose a vote of the holders of asset 1.
+func proposalStep(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx {
+	now := e.network.Now()
+	proposal := &actions.Proposal{
+		Type:                0,
+		VoteSystem:          0,
+		AssetType:           "SHC",
+		AssetCode:           e.asset1.Bytes(),
+		VoteOptions:         "AB",
+		VoteMax:             1,
+		ProposalDescription: "Simulated proposal",
+		VoteCutOffTimestamp: now.Nano() + uint64(5*time.Minute),
+	}
+
+	return buildRequest(t, e, []bitcoin.RawAddress{e.holder1.Address},
+		[]Output{
+			{Address: e.daemon1.ContractKey.Address, Value: 5000}, // Funds vote
+			{Address: e.daemon1.ContractKey.Address, Value: 5000}, // Funds result
+		}, proposal)
+}
+
+// ballotStep casts a ballot from a holder for the vote of contract 1.
+func ballotStep(holder int, choice string) chaosStep {
+	return func(t *testing.T, ctx context.Context, e *exchange) *wire.MsgTx {
+		var voteTxId *protocol.TxId
+		for _, response := range e.daemon1.Responses() {
+			if _, ok := response.MsgProto.(*actions.Vote); ok &&
+				!e.network.IsRemoved(*response.Hash) {
+				voteTxId = protocol.TxIdFromBytes(response.Hash[:])
+			}
+		}
+		if voteTxId == nil {
+			return nil
+		}
+
+		key := e.holder1
+		if holder == 2 {
+			key = e.holder2
+		}
+
+		ballot := &actions.BallotCast{
+			VoteTxId: voteTxId.Bytes(),
+			Vote:     choice,
+		}
+
+		return buildRequest(t, e, []bitcoin.RawAddress{key.Address},
+			[]Output{{Address: e.daemon1.ContractKey.Address, Value: 5000}}, ballot)
+	}
+}
+
+func buildRequest(t *testing.T, e *exchange, senders []bitcoin.RawAddress, outputs []Output,
+	action actions.Action) *wire.MsgTx {
+
+	tx, err := e.network.BuildRequest(senders, outputs, action)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to build %s : %v", tests.Failed, action.Code(), err)
+	}
+	return tx
+}
+
+func run(t *testing.T, ctx context.Context, e *exchange) {
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+}
+
+func doubleSpend(t *testing.T, ctx context.Context, e *exchange, tx *wire.MsgTx) {
+	if err := e.network.DoubleSpend(ctx, *tx.TxHash()); err != nil {
+		t.Fatalf("\t%s\tFailed to double spend : %v", tests.Failed, err)
+	}
+}
+
+// checkRevertNotifications checks that the first contract notified its administration of the
+//   reverted request, and that nothing was reverted otherwise.
+func checkRevertNotifications(t *testing.T, e *exchange, faultTx *wire.MsgTx, reverted bool) {
+	found := false
+	for _, d := range []*Daemon{e.daemon1, e.daemon2} {
+		for _, response := range d.Responses() {
+			message, ok := response.MsgProto.(*actions.Message)
+			if !ok || message.MessageCode != messages.CodeRevertedTx {
+				continue
+			}
+
+			if !reverted {
+				t.Fatalf("\t%s\tUnexpected revert notification from %s", tests.Failed, d.Name)
+			}
+
+			payload, err := messages.Deserialize(message.MessageCode, message.MessagePayload)
+			if err != nil {
+				t.Fatalf("\t%s\tFailed to deserialize revert notification : %v", tests.Failed,
+					err)
+			}
+
+			revertedTx := wire.NewMsgTx(1)
+			if err := revertedTx.Deserialize(
+				bytes.NewReader(payload.(*messages.RevertedTx).Transaction)); err != nil {
+				t.Fatalf("\t%s\tFailed to deserialize reverted tx : %v", tests.Failed, err)
+			}
+
+			if d == e.daemon1 && revertedTx.TxHash().Equal(faultTx.TxHash()) {
+				found = true
+			}
+		}
+	}
+
+	if reverted && !found {
+		t.Fatalf("\t%s\tReverted request not notified", tests.Failed)
+	}
+
+	t.Logf("\t%s\tVerified revert notifications", tests.Success)
+}
+
+// checkHoldingsConsistent checks that the holdings of an asset total its token quantity and have
+//   no pending transfers.
+func checkHoldingsConsistent(t *testing.T, ctx context.Context, e *exchange, d *Daemon,
+	assetCode *protocol.AssetCode) {
+
+	as, err := asset.Retrieve(ctx, d.MasterDB, d.ContractKey.Address, assetCode)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to retrieve asset : %v", tests.Failed, err)
+	}
+
+	hs, err := holdings.FetchAll(ctx, d.MasterDB, d.ContractKey.Address, assetCode)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch holdings : %v", tests.Failed, err)
+	}
+
+	finalized := uint64(0)
+	pending := uint64(0)
+	for _, h := range hs {
+		finalized += h.FinalizedBalance
+		pending += h.PendingBalance
+
+		for _, status := range h.HoldingStatuses {
+			if status.Code != holdings.FreezeCode {
+				t.Fatalf("\t%s\t%s holding has pending status %c : %s", tests.Failed, d.Name,
+					status.Code, status.TxId.String())
+			}
+		}
+	}
+
+	if finalized != as.TokenQty || pending != as.TokenQty {
+		t.Fatalf("\t%s\t%s holdings don't total token quantity %d : finalized %d, pending %d",
+			tests.Failed, d.Name, as.TokenQty, finalized, pending)
+	}
+
+	t.Logf("\t%s\tVerified %s holdings total %d", tests.Success, d.Name, as.TokenQty)
+}
+
+// checkTransfersComplete checks that there are no pending transfers.
+func checkTransfersComplete(t *testing.T, ctx context.Context, d *Daemon) {
+	pendingTransfers, err := transfer.List(ctx, d.MasterDB, d.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list pending transfers : %v", tests.Failed, err)
+	}
+
+	if len(pendingTransfers) != 0 {
+		t.Fatalf("\t%s\t%s has %d pending transfers", tests.Failed, d.Name, len(pendingTransfers))
+	}
+}
+
+// checkVotesConsistent checks that the votes still on chain are complete and their tallies match
+//   the counted ballots.
+func checkVotesConsistent(t *testing.T, ctx context.Context, e *exchange, d *Daemon) {
+	votes, err := vote.List(ctx, d.MasterDB, d.ContractKey.Address)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to list votes : %v", tests.Failed, err)
+	}
+
+	for _, listed := range votes {
+		// A vote created by a reverted tx never completes, because its result can't be funded.
+		voteTxId, err := bitcoin.NewHash32(listed.VoteTxId.Bytes())
+		if err != nil {
+			t.Fatalf("\t%s\tFailed to convert vote txid : %v", tests.Failed, err)
+		}
+		if e.network.IsRemoved(*voteTxId) {
+			continue
+		}
+
+		// List doesn't include the ballots.
+		vt, err := vote.Fetch(ctx, d.MasterDB, d.ContractKey.Address, listed.VoteTxId)
+		if err != nil {
+			t.Fatalf("\t%s\tFailed to fetch vote : %v", tests.Failed, err)
+		}
+
+		if vt.CompletedAt.Nano() == 0 {
+			t.Fatalf("\t%s\tVote not complete : %s", tests.Failed, vt.VoteTxId.String())
+		}
+
+		tally := make([]uint64, len(vt.OptionTally))
+		for _, ballot := range vt.Ballots {
+			if len(ballot.Vote) == 0 {
+				continue
+			}
+			tally[ballot.Vote[0]-'A'] += ballot.Quantity
+		}
+
+		for i := range tally {
+			if tally[i] != vt.OptionTally[i] {
+				t.Fatalf("\t%s\tVote tally %c doesn't match ballots : got %d, want %d",
+					tests.Failed, 'A'+i, vt.OptionTally[i], tally[i])
+			}
+		}
+
+		t.Logf("\t%s\tVerified vote tally : %v", tests.Success, vt.OptionTally)
+	}
+}
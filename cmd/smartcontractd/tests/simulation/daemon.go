@@ -0,0 +1,268 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/scheduler"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/handlers"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/internal/utxos"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+	"github.com/tokenized/specification/dist/golang/actions"
+
+	"github.com/pkg/errors"
+)
+
+// Daemon is a simulated smart contract daemon with its own storage, wallet, and handlers. It
+//   processes the txs delivered by the network like the daemon's listener does, and broadcasts its
+//   responses to the network.
+type Daemon struct {
+	Name string
+
+	// Config is used by the handlers, so changes apply to txs processed after them.
+	Config node.Config
+
+	ContractKey     *wallet.Key
+	FeeKey          *wallet.Key
+	Wallet          *wallet.Wallet
+	MasterDB        *db.DB
+	UTXOs           *utxos.UTXOs
+	Scheduler       *scheduler.Scheduler
+	HoldingsChannel *holdings.CacheChannel
+	Tracer          *filters.Tracer
+	Handler         protomux.Handler
+
+	network *Network
+	ctx     context.Context
+	wait    sync.WaitGroup
+
+	lock      sync.Mutex
+	responses []*inspector.Transaction
+	seen      map[bitcoin.Hash32]bool // Txs processed
+}
+
+func newDaemon(ctx context.Context, n *Network, name, path string) (*Daemon, error) {
+	result := &Daemon{
+		Name: name,
+		Config: node.Config{
+			ContractProviderID: "TokenizedTest",
+			Version:            "TestVersion",
+			Net:                n.Net,
+			Fees:               fees.NewPolicy(1.0, 1.0, 0.5),
+			RequestTimeout:     uint64(time.Minute),
+			IsTest:             n.IsTest,
+		},
+		Wallet:          wallet.New(),
+		Scheduler:       &scheduler.Scheduler{},
+		HoldingsChannel: &holdings.CacheChannel{},
+		Tracer:          filters.NewTracer(),
+		network:         n,
+		ctx:             node.ContextWithLogTrace(ctx, name),
+		seen:            make(map[bitcoin.Hash32]bool),
+	}
+
+	var err error
+	result.ContractKey, err = tests.GenerateKey(n.Net)
+	if err != nil {
+		return nil, errors.Wrap(err, "contract key")
+	}
+
+	result.FeeKey, err = tests.GenerateKey(n.Net)
+	if err != nil {
+		return nil, errors.Wrap(err, "fee key")
+	}
+	result.Config.FeeAddress = result.FeeKey.Address
+
+	if err := result.Wallet.Add(result.ContractKey); err != nil {
+		return nil, errors.Wrap(err, "add contract key")
+	}
+
+	result.MasterDB, err = db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   path,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "create db")
+	}
+
+	if err := result.MasterDB.Clear(ctx, ""); err != nil {
+		return nil, errors.Wrap(err, "clear db")
+	}
+
+	result.UTXOs, err = utxos.Load(ctx, result.MasterDB)
+	if err != nil {
+		return nil, errors.Wrap(err, "load utxos")
+	}
+
+	// The scheduler isn't run. Transfer timeouts are triggered by the network's clock.
+	result.Handler, err = handlers.API(
+		result.ctx,
+		result.Wallet,
+		result.Wallet,
+		&result.Config,
+		result.MasterDB,
+		result.Tracer,
+		result.Scheduler,
+		n,
+		result.UTXOs,
+		result.HoldingsChannel,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "api")
+	}
+
+	result.Handler.SetResponder(result.respond)
+	result.Handler.SetReprocessor(result.reprocess)
+
+	result.HoldingsChannel.Open(100)
+	result.wait.Add(1)
+	go func() {
+		defer result.wait.Done()
+		if err := holdings.ProcessCacheItems(result.ctx, result.MasterDB,
+			result.HoldingsChannel); err != nil {
+			node.LogError(result.ctx, "Process holdings cache failed : %s", err)
+		}
+	}()
+
+	return result, nil
+}
+
+func (d *Daemon) tearDown() {
+	d.HoldingsChannel.Close()
+	d.wait.Wait()
+	d.MasterDB.Close()
+}
+
+// Responses returns the txs broadcast by the daemon, oldest first.
+func (d *Daemon) Responses() []*inspector.Transaction {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	result := make([]*inspector.Transaction, len(d.responses))
+	copy(result, d.responses)
+	return result
+}
+
+// LastResponse returns the last tx broadcast by the daemon, or nil if there are none.
+func (d *Daemon) LastResponse() *inspector.Transaction {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.responses) == 0 {
+		return nil
+	}
+	return d.responses[len(d.responses)-1]
+}
+
+// ClearResponses forgets the txs broadcast by the daemon.
+func (d *Daemon) ClearResponses() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.responses = nil
+}
+
+// respond broadcasts a response to the network. It is the handler's responder.
+func (d *Daemon) respond(ctx context.Context, tx *wire.MsgTx) error {
+	itx, err := d.network.broadcast(ctx, d, tx)
+	if err != nil {
+		return errors.Wrap(err, "broadcast")
+	}
+
+	d.lock.Lock()
+	d.responses = append(d.responses, itx)
+	d.lock.Unlock()
+	return nil
+}
+
+// reprocess queues a tx to be processed again. It is the handler's reprocessor.
+func (d *Daemon) reprocess(ctx context.Context, itx *inspector.Transaction) error {
+	d.network.reprocess(d, itx)
+	return nil
+}
+
+// hasSeen returns true if the daemon processed the tx.
+func (d *Daemon) hasSeen(txid bitcoin.Hash32) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	return d.seen[txid]
+}
+
+// isRelevant returns true if the daemon's listener would process the tx. That is a tx paying the
+//   contract, or a response from the contract.
+func (d *Daemon) isRelevant(itx *inspector.Transaction) bool {
+	for _, output := range itx.Outputs {
+		if output.Address.Equal(d.ContractKey.Address) {
+			return true
+		}
+	}
+
+	if itx.IsOutgoingMessageType() {
+		for _, input := range itx.Inputs {
+			if input.Address.Equal(d.ContractKey.Address) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// process handles a delivery at the network's current time.
+func (d *Daemon) process(delivery *Delivery) error {
+	ctx := node.ContextWithLogTrace(d.ctx, fmt.Sprintf("%s %s", d.Name,
+		delivery.Itx.Hash.String()))
+	ctx = node.ContextWithTimestamp(ctx, d.network.Now())
+
+	node.Log(ctx, "Processing %s", delivery)
+
+	switch delivery.Event {
+	case protomux.LOST, protomux.STOLE:
+		d.Tracer.RevertTx(ctx, delivery.Itx.Hash)
+		d.UTXOs.Remove(delivery.Itx.MsgTx, []bitcoin.RawAddress{d.ContractKey.Address})
+
+	case protomux.SEE:
+		d.Tracer.AddTx(ctx, delivery.Itx.MsgTx)
+
+		if cf, ok := delivery.Itx.MsgProto.(*actions.ContractFormation); ok {
+			if err := contract.SaveContractFormation(ctx, d.MasterDB,
+				delivery.Itx.Inputs[0].Address, cf, d.Config.IsTest); err != nil {
+				return errors.Wrap(err, "save contract formation")
+			}
+		}
+
+		if !d.isRelevant(delivery.Itx) {
+			return nil
+		}
+
+		d.lock.Lock()
+		d.seen[*delivery.Itx.Hash] = true
+		d.lock.Unlock()
+	}
+
+	// Handler errors are logged like the listener does, so they don't stop the network.
+	if err := d.Handler.Trigger(ctx, delivery.Event, delivery.Itx); err != nil {
+		switch errors.Cause(err) {
+		case node.ErrNoResponse, node.ErrRejected, node.ErrInsufficientFunds:
+			node.Log(ctx, "Failed to handle tx : %s", err)
+		default:
+			node.LogError(ctx, "Failed to handle tx : %s", err)
+		}
+	}
+
+	return nil
+}
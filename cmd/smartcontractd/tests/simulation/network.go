@@ -0,0 +1,504 @@
+// Package simulation runs multiple independent smart contract daemons in one process, connected
+//   through an in-memory network, so flows between contracts can be tested deterministically.
+//
+// Txs broadcast by daemons, or by the test, are queued as deliveries to the daemons they are
+//   relevant to. Nothing is processed until the test steps the network, so the order of
+//   processing is controlled by the test. Rules can drop or delay deliveries, and pending
+//   deliveries can be delivered out of order. The network's clock only moves when advanced, which
+//   also expires pending transfers. The network is also the chain, so blocks can be mined and
+//   reorged, and txs can be double spent.
+//
+// Daemons share the process wide caches of the internal packages, which are keyed by contract, so
+//   each daemon must have its own contracts.
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/internal/transfer"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxSteps limits the deliveries processed by Run so flows that never finish fail instead of
+	//   hanging.
+	maxSteps = 10000
+)
+
+var (
+	// ErrNotIdle occurs when the network still has deliveries after the maximum number of steps.
+	ErrNotIdle = errors.New("Network not idle")
+)
+
+// Delivery is a tx queued to be processed by a daemon.
+type Delivery struct {
+	Itx *inspector.Transaction
+
+	// Event is the protomux event triggered, like SEE for broadcast txs, END for reprocessing,
+	//   LOST for reorgs, and STOLE for double spends.
+	Event string
+
+	// From is the daemon that broadcast the tx. Nil for txs broadcast by the test.
+	From *Daemon
+
+	To *Daemon
+
+	// Delay is the simulated time after queueing that the delivery is due. Rules can change it.
+	Delay time.Duration
+
+	due      uint64 // Nanoseconds
+	sequence uint64
+}
+
+func (d *Delivery) String() string {
+	from := "test"
+	if d.From != nil {
+		from = d.From.Name
+	}
+	code := ""
+	if d.Itx.MsgProto != nil {
+		code = d.Itx.MsgProto.Code()
+	}
+	return fmt.Sprintf("%s %s %s -> %s : %s", d.Event, code, from, d.To.Name, d.Itx.Hash.String())
+}
+
+// Rule is applied to each delivery when it is queued. It returns false to drop the delivery and
+//   can delay it by setting Delay.
+type Rule func(d *Delivery) bool
+
+// Network is an in-memory bitcoin network and chain connecting simulated daemons.
+type Network struct {
+	IsTest bool
+	Net    bitcoin.Network
+
+	ctx  context.Context
+	path string
+
+	lock     sync.Mutex
+	now      uint64 // Nanoseconds
+	daemons  []*Daemon
+	rules    []Rule
+	queue    []*Delivery
+	sequence uint64
+
+	// expired are the pending transfers and votes already reprocessed, keyed by daemon name and
+	//   txid, so ones that aren't removed by reprocessing aren't reprocessed again.
+	expired map[string]bool
+
+	chain
+}
+
+// NewNetwork returns an empty network. Daemon storage is created under the path. The clock
+//   starts at the current time.
+func NewNetwork(ctx context.Context, path string) *Network {
+	return &Network{
+		IsTest:  true,
+		Net:     bitcoin.MainNet,
+		ctx:     ctx,
+		path:    path,
+		now:     currentTime(),
+		expired: make(map[string]bool),
+		chain:   newChain(),
+	}
+}
+
+// AddDaemon creates a daemon with a new contract key and connects it to the network.
+func (n *Network) AddDaemon(ctx context.Context, name string) (*Daemon, error) {
+	d, err := newDaemon(ctx, n, name, filepath.Join(n.path, name))
+	if err != nil {
+		return nil, errors.Wrap(err, name)
+	}
+
+	n.lock.Lock()
+	n.daemons = append(n.daemons, d)
+	n.lock.Unlock()
+	return d, nil
+}
+
+// TearDown stops all daemons.
+func (n *Network) TearDown() {
+	n.lock.Lock()
+	daemons := n.daemons
+	n.daemons = nil
+	n.lock.Unlock()
+
+	for _, d := range daemons {
+		d.tearDown()
+	}
+}
+
+// Now returns the network's simulated time.
+func (n *Network) Now() protocol.Timestamp {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return protocol.NewTimestamp(n.now)
+}
+
+// AddRule adds a rule applied to deliveries queued after it is added.
+func (n *Network) AddRule(rule Rule) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.rules = append(n.rules, rule)
+}
+
+// ClearRules removes all rules. Deliveries already queued are not affected.
+func (n *Network) ClearRules() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.rules = nil
+}
+
+// Broadcast adds a tx to the mempool and queues it for the daemons it is relevant to.
+func (n *Network) Broadcast(ctx context.Context, tx *wire.MsgTx) (*inspector.Transaction, error) {
+	return n.broadcast(ctx, nil, tx)
+}
+
+func (n *Network) broadcast(ctx context.Context, from *Daemon,
+	tx *wire.MsgTx) (*inspector.Transaction, error) {
+
+	itx, err := n.promote(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	added, err := n.addToMempool(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !added {
+		return itx, nil
+	}
+
+	if !itx.IsTokenized() {
+		// Daemons add payments to their contracts to their UTXOs, like the listener does.
+		for _, d := range n.daemons {
+			d.UTXOs.Add(tx, []bitcoin.RawAddress{d.ContractKey.Address})
+		}
+		return itx, nil
+	}
+
+	_, isFormation := itx.MsgProto.(*actions.ContractFormation)
+	for _, d := range n.daemons {
+		// Every daemon sees contract formations so it knows the other contracts. Daemons also see
+		//   their own responses so their tracers can follow them.
+		if isFormation || d == from || d.isRelevant(itx) {
+			n.enqueue(&Delivery{Itx: itx, Event: protomux.SEE, From: from, To: d})
+		}
+	}
+
+	return itx, nil
+}
+
+// reprocess queues a tx to be reprocessed by the daemon that requested it.
+func (n *Network) reprocess(d *Daemon, itx *inspector.Transaction) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.enqueue(&Delivery{Itx: itx, Event: protomux.END, From: d, To: d})
+}
+
+// revert drops pending deliveries of a tx that is no longer valid, like the listener cancels
+//   pending txs, and queues an event for every daemon that already processed it. The lock must be
+//   held.
+func (n *Network) revert(itx *inspector.Transaction, event string) {
+	var queue []*Delivery
+	for _, delivery := range n.queue {
+		if delivery.Event == protomux.SEE && delivery.Itx.Hash.Equal(itx.Hash) {
+			node.Log(n.ctx, "Cancelled delivery : %s", delivery)
+			continue
+		}
+		queue = append(queue, delivery)
+	}
+	n.queue = queue
+
+	for _, d := range n.daemons {
+		if d.hasSeen(*itx.Hash) {
+			n.enqueue(&Delivery{Itx: itx, Event: event, To: d})
+		}
+	}
+}
+
+// enqueue applies the rules to a delivery and adds it to the queue. The lock must be held.
+func (n *Network) enqueue(delivery *Delivery) {
+	for _, rule := range n.rules {
+		if !rule(delivery) {
+			node.Log(n.ctx, "Dropped delivery : %s", delivery)
+			return
+		}
+	}
+
+	delivery.due = n.now + uint64(delivery.Delay)
+	delivery.sequence = n.sequence
+	n.sequence++
+
+	n.queue = append(n.queue, delivery)
+	sort.SliceStable(n.queue, func(i, j int) bool {
+		if n.queue[i].due != n.queue[j].due {
+			return n.queue[i].due < n.queue[j].due
+		}
+		return n.queue[i].sequence < n.queue[j].sequence
+	})
+}
+
+// Pending returns the queued deliveries in the order they will be delivered.
+func (n *Network) Pending() []*Delivery {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	result := make([]*Delivery, len(n.queue))
+	copy(result, n.queue)
+	return result
+}
+
+// Drop removes a queued delivery so it is never delivered.
+func (n *Network) Drop(delivery *Delivery) bool {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return n.remove(delivery)
+}
+
+// Deliver removes a queued delivery and processes it now, even if it isn't due or isn't first.
+func (n *Network) Deliver(ctx context.Context, delivery *Delivery) error {
+	n.lock.Lock()
+	found := n.remove(delivery)
+	n.lock.Unlock()
+
+	if !found {
+		return errors.New("Delivery not pending")
+	}
+
+	return delivery.To.process(delivery)
+}
+
+// remove removes a delivery from the queue. The lock must be held.
+func (n *Network) remove(delivery *Delivery) bool {
+	for i, queued := range n.queue {
+		if queued == delivery {
+			n.queue = append(n.queue[:i], n.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Step processes the next delivery that is due. It returns false when none are due.
+func (n *Network) Step(ctx context.Context) (bool, error) {
+	n.lock.Lock()
+	if len(n.queue) == 0 || n.queue[0].due > n.now {
+		n.lock.Unlock()
+		return false, nil
+	}
+	delivery := n.queue[0]
+	n.queue = n.queue[1:]
+	n.lock.Unlock()
+
+	// The lock isn't held while processing because the daemon broadcasts its responses.
+	if err := delivery.To.process(delivery); err != nil {
+		return true, errors.Wrap(err, delivery.String())
+	}
+
+	return true, nil
+}
+
+// Run processes deliveries until none are due.
+func (n *Network) Run(ctx context.Context) error {
+	for i := 0; i < maxSteps; i++ {
+		processed, err := n.Step(ctx)
+		if err != nil {
+			return err
+		}
+		if !processed {
+			return nil
+		}
+	}
+
+	return ErrNotIdle
+}
+
+// Advance moves the clock forward, processing deliveries, expiring pending transfers, and
+//   finalizing votes as they become due.
+func (n *Network) Advance(ctx context.Context, duration time.Duration) error {
+	n.lock.Lock()
+	end := n.now + uint64(duration)
+	n.lock.Unlock()
+
+	for {
+		if err := n.Run(ctx); err != nil {
+			return err
+		}
+
+		next, itx, d, err := n.nextExpiry(ctx)
+		if err != nil {
+			return errors.Wrap(err, "next expiry")
+		}
+
+		n.lock.Lock()
+		if len(n.queue) > 0 && (d == nil || n.queue[0].due <= next) {
+			next = n.queue[0].due
+			d = nil
+		}
+
+		if next == 0 || next > end {
+			n.now = end
+			n.lock.Unlock()
+			return n.Run(ctx)
+		}
+
+		if next > n.now {
+			n.now = next
+		}
+		if d != nil {
+			node.Log(n.ctx, "Expired %s for %s : %s", itx.MsgProto.Code(), d.Name,
+				itx.Hash.String())
+			n.expired[d.Name+itx.Hash.String()] = true
+			n.enqueue(&Delivery{Itx: itx, Event: protomux.END, From: d, To: d})
+		}
+		n.lock.Unlock()
+	}
+}
+
+// nextExpiry returns the earliest expiry of all daemons, with the tx to reprocess and the daemon.
+//   Expiries are pending transfer timeouts, reprocessing the transfer tx, and vote cut offs,
+//   reprocessing the vote tx. The time is zero when there are no expiries that haven't already
+//   been reprocessed.
+func (n *Network) nextExpiry(ctx context.Context) (uint64, *inspector.Transaction, *Daemon,
+	error) {
+
+	n.lock.Lock()
+	daemons := n.daemons
+	expired := make(map[string]bool, len(n.expired))
+	for key := range n.expired {
+		expired[key] = true
+	}
+	n.lock.Unlock()
+
+	var result uint64
+	var resultTxId *bitcoin.Hash32
+	var resultDaemon *Daemon
+	check := func(d *Daemon, txid *protocol.TxId, expires protocol.Timestamp) error {
+		hash, err := bitcoin.NewHash32(txid.Bytes())
+		if err != nil {
+			return errors.Wrap(err, "txid")
+		}
+		if expired[d.Name+hash.String()] {
+			return nil
+		}
+
+		if result == 0 || expires.Nano() < result {
+			result = expires.Nano()
+			resultTxId = hash
+			resultDaemon = d
+		}
+		return nil
+	}
+
+	for _, d := range daemons {
+		pendingTransfers, err := transfer.List(ctx, d.MasterDB, d.ContractKey.Address)
+		if err != nil {
+			return 0, nil, nil, errors.Wrap(err, "list transfers")
+		}
+
+		for _, pendingTransfer := range pendingTransfers {
+			if err := check(d, pendingTransfer.TransferTxId, pendingTransfer.Timeout); err != nil {
+				return 0, nil, nil, errors.Wrap(err, "transfer")
+			}
+		}
+
+		votes, err := vote.List(ctx, d.MasterDB, d.ContractKey.Address)
+		if err != nil {
+			return 0, nil, nil, errors.Wrap(err, "list votes")
+		}
+
+		for _, vt := range votes {
+			if vt.CompletedAt.Nano() != 0 {
+				continue
+			}
+			if err := check(d, vt.VoteTxId, vt.Expires); err != nil {
+				return 0, nil, nil, errors.Wrap(err, "vote")
+			}
+		}
+	}
+
+	if result == 0 {
+		return 0, nil, nil, nil
+	}
+
+	tx, err := n.GetTX(ctx, resultTxId)
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "get tx")
+	}
+
+	itx, err := n.promote(ctx, tx)
+	if err != nil {
+		return 0, nil, nil, errors.Wrap(err, "promote tx")
+	}
+
+	return result, itx, resultDaemon, nil
+}
+
+// promote converts a tx to an inspector tx with its inputs populated from the chain.
+func (n *Network) promote(ctx context.Context, tx *wire.MsgTx) (*inspector.Transaction, error) {
+	itx, err := inspector.NewTransactionFromWire(ctx, tx, n.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "new itx")
+	}
+
+	if err := itx.Promote(ctx, n); err != nil {
+		return nil, errors.Wrap(err, "promote itx")
+	}
+
+	return itx, nil
+}
+
+// promoteLocked is promote for when the lock is held.
+func (n *Network) promoteLocked(ctx context.Context,
+	tx *wire.MsgTx) (*inspector.Transaction, error) {
+
+	itx, err := inspector.NewTransactionFromWire(ctx, tx, n.IsTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "new itx")
+	}
+
+	outpoints := make([]wire.OutPoint, 0, len(tx.TxIn))
+	for _, input := range tx.TxIn {
+		outpoints = append(outpoints, input.PreviousOutPoint)
+	}
+
+	utxos, err := n.getOutputs(outpoints)
+	if err != nil {
+		return nil, errors.Wrap(err, "get outputs")
+	}
+
+	if err := itx.PromoteFromUTXOs(ctx, utxos); err != nil {
+		return nil, errors.Wrap(err, "promote itx")
+	}
+
+	return itx, nil
+}
+
+func currentTime() uint64 {
+	now := protocol.CurrentTimestamp()
+	return now.Nano()
+}
@@ -0,0 +1,438 @@
+package simulation
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/contract"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/node"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/pkg/inspector"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+// exchange is two daemons with a contract and asset each, and a holder of each asset.
+type exchange struct {
+	network *Network
+	daemon1 *Daemon
+	daemon2 *Daemon
+	holder1 *wallet.Key
+	holder2 *wallet.Key
+	asset1  *protocol.AssetCode
+	asset2  *protocol.AssetCode
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	os.RemoveAll("./tmp")
+	os.Remove("./test.log")
+	os.Exit(code)
+}
+
+func TestExchange(t *testing.T) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	transferTx := e.broadcastTransfer(t, ctx, 10, 20)
+
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	response := e.daemon1.ResponseTo(*transferTx.TxHash())
+	if response == nil {
+		t.Fatalf("\t%s\tNo transfer response", tests.Failed)
+	}
+	if _, ok := response.MsgProto.(*actions.Settlement); !ok {
+		t.Fatalf("\t%s\tTransfer not settled : %v", tests.Failed, responseError(response))
+	}
+
+	t.Logf("\t%s\tTransfer settled between daemons", tests.Success)
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder1, 990)
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder2, 10)
+	e.checkBalance(t, ctx, e.daemon2, e.asset2, e.holder2, 1980)
+	e.checkBalance(t, ctx, e.daemon2, e.asset2, e.holder1, 20)
+}
+
+func TestDelay(t *testing.T) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	// Delay everything sent to the second daemon.
+	e.network.AddRule(func(d *Delivery) bool {
+		if d.To == e.daemon2 {
+			d.Delay = 30 * time.Second
+		}
+		return true
+	})
+
+	transferTx := e.broadcastTransfer(t, ctx, 10, 20)
+
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	delayed := false
+	for _, d := range e.network.Pending() {
+		if d.To == e.daemon2 && d.Itx.MsgProto.Code() == actions.CodeMessage {
+			delayed = true
+		}
+	}
+	if !delayed {
+		t.Fatalf("\t%s\tSettlement request not delayed", tests.Failed)
+	}
+
+	if _, ok := e.daemon1.ResponseTo(*transferTx.TxHash()).MsgProto.(*actions.Message); !ok {
+		t.Fatalf("\t%s\tFirst contract didn't request settlement", tests.Failed)
+	}
+
+	t.Logf("\t%s\tSettlement request delayed", tests.Success)
+
+	if err := e.network.Advance(ctx, 2*time.Minute); err != nil {
+		t.Fatalf("\t%s\tFailed to advance network : %v", tests.Failed, err)
+	}
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder2, 10)
+	e.checkBalance(t, ctx, e.daemon2, e.asset2, e.holder1, 20)
+}
+
+func TestTimeout(t *testing.T) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	// Drop the settlement request so the second contract never responds.
+	e.network.AddRule(func(d *Delivery) bool {
+		return d.To != e.daemon2 || d.Itx.MsgProto.Code() != actions.CodeMessage
+	})
+
+	transferTx := e.broadcastTransfer(t, ctx, 10, 20)
+
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	if err := e.network.Advance(ctx, 30*time.Second); err != nil {
+		t.Fatalf("\t%s\tFailed to advance network : %v", tests.Failed, err)
+	}
+
+	if _, ok := e.lastResponse(t, e.daemon1).MsgProto.(*actions.Message); !ok {
+		t.Fatalf("\t%s\tTransfer timed out early", tests.Failed)
+	}
+
+	if err := e.network.Advance(ctx, time.Minute); err != nil {
+		t.Fatalf("\t%s\tFailed to advance network : %v", tests.Failed, err)
+	}
+
+	rejection, ok := e.lastResponse(t, e.daemon1).MsgProto.(*actions.Rejection)
+	if !ok {
+		t.Fatalf("\t%s\tTransfer didn't time out", tests.Failed)
+	}
+	if rejection.RejectionCode != actions.RejectionsTimeout {
+		t.Fatalf("\t%s\tWrong reject code : got %d, want %d", tests.Failed,
+			rejection.RejectionCode, actions.RejectionsTimeout)
+	}
+
+	t.Logf("\t%s\tTransfer timed out : %s", tests.Success, transferTx.TxHash().String())
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder1, 1000)
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder2, 0)
+}
+
+func TestRejection(t *testing.T) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	if err := contract.SaveTransferPolicy(ctx, e.daemon2.MasterDB, e.daemon2.ContractKey.Address,
+		&contract.TransferPolicy{RefuseMultiContract: true}); err != nil {
+		t.Fatalf("\t%s\tFailed to save transfer policy : %v", tests.Failed, err)
+	}
+
+	e.broadcastTransfer(t, ctx, 10, 20)
+
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	rejection, ok := e.lastResponse(t, e.daemon1).MsgProto.(*actions.Rejection)
+	if !ok {
+		t.Fatalf("\t%s\tTransfer not rejected by first contract", tests.Failed)
+	}
+	if rejection.RejectionCode != actions.RejectionsContractNotPermitted {
+		t.Fatalf("\t%s\tWrong reject code : got %d, want %d", tests.Failed,
+			rejection.RejectionCode, actions.RejectionsContractNotPermitted)
+	}
+
+	t.Logf("\t%s\tRejection relayed to first contract", tests.Success)
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder1, 1000)
+	e.checkBalance(t, ctx, e.daemon2, e.asset2, e.holder2, 2000)
+}
+
+func TestOrdering(t *testing.T) {
+	ctx := newContext()
+	e := newExchange(t, ctx)
+	defer e.network.TearDown()
+
+	// Hold everything sent to the first daemon until it is delivered manually.
+	e.network.AddRule(func(d *Delivery) bool {
+		if d.To == e.daemon1 {
+			d.Delay = time.Hour
+		}
+		return true
+	})
+
+	first := e.broadcastTransfer(t, ctx, 10, 20)
+	second := e.broadcastTransfer(t, ctx, 5, 5)
+	e.network.ClearRules()
+
+	var pending []*Delivery
+	for _, d := range e.network.Pending() {
+		if d.To == e.daemon1 {
+			pending = append(pending, d)
+		}
+	}
+	if len(pending) != 2 {
+		t.Fatalf("\t%s\tWrong pending count : got %d, want 2", tests.Failed, len(pending))
+	}
+
+	// Deliver the second transfer first.
+	if err := e.network.Deliver(ctx, pending[1]); err != nil {
+		t.Fatalf("\t%s\tFailed to deliver : %v", tests.Failed, err)
+	}
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	if e.daemon1.ResponseTo(*first.TxHash()) != nil {
+		t.Fatalf("\t%s\tHeld transfer processed", tests.Failed)
+	}
+	if _, ok := e.daemon1.ResponseTo(*second.TxHash()).MsgProto.(*actions.Settlement); !ok {
+		t.Fatalf("\t%s\tSecond transfer not settled", tests.Failed)
+	}
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder2, 5)
+
+	t.Logf("\t%s\tSecond transfer settled first", tests.Success)
+
+	if err := e.network.Deliver(ctx, pending[0]); err != nil {
+		t.Fatalf("\t%s\tFailed to deliver : %v", tests.Failed, err)
+	}
+	if err := e.network.Run(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to run network : %v", tests.Failed, err)
+	}
+
+	e.checkBalance(t, ctx, e.daemon1, e.asset1, e.holder2, 15)
+	e.checkBalance(t, ctx, e.daemon2, e.asset2, e.holder1, 25)
+}
+
+func TestChain(t *testing.T) {
+	ctx := newContext()
+	network := NewNetwork(ctx, "./tmp")
+
+	key, err := tests.GenerateKey(network.Net)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to generate key : %v", tests.Failed, err)
+	}
+
+	fundingTx, err := network.Fund(key.Address, 1000)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fund : %v", tests.Failed, err)
+	}
+
+	spend := func(value uint64) *wire.MsgTx {
+		tx := wire.NewMsgTx(1)
+		tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(fundingTx.TxHash(), 0), nil))
+		script, _ := key.Address.LockingScript()
+		tx.TxOut = append(tx.TxOut, wire.NewTxOut(value, script))
+		return tx
+	}
+
+	tx := spend(900)
+	if _, err := network.Broadcast(ctx, tx); err != nil {
+		t.Fatalf("\t%s\tFailed to broadcast : %v", tests.Failed, err)
+	}
+	if _, err := network.Broadcast(ctx, spend(800)); err == nil {
+		t.Fatalf("\t%s\tDouble spend accepted", tests.Failed)
+	}
+
+	network.Mine(ctx)
+	height := network.LastHeight(ctx)
+	hash, _ := network.Hash(ctx, height)
+	if network.InMempool(*tx.TxHash()) || height != 1 {
+		t.Fatalf("\t%s\tTx not mined", tests.Failed)
+	}
+
+	if err := network.DoubleSpend(ctx, *tx.TxHash()); err == nil {
+		t.Fatalf("\t%s\tMined tx double spent", tests.Failed)
+	}
+
+	if err := network.Reorg(ctx, 1, *tx.TxHash()); err != nil {
+		t.Fatalf("\t%s\tFailed to reorg : %v", tests.Failed, err)
+	}
+
+	reorgHash, _ := network.Hash(ctx, height)
+	if network.LastHeight(ctx) != height || reorgHash.Equal(hash) {
+		t.Fatalf("\t%s\tBlock not replaced", tests.Failed)
+	}
+
+	// The removed tx was replaced by a conflicting tx, so its inputs and outputs can't be spent.
+	if _, err := network.Broadcast(ctx, spend(800)); err == nil {
+		t.Fatalf("\t%s\tSpent input of removed tx", tests.Failed)
+	}
+
+	childTx := wire.NewMsgTx(1)
+	childTx.TxIn = append(childTx.TxIn, wire.NewTxIn(wire.NewOutPoint(tx.TxHash(), 0), nil))
+	childTx.TxOut = append(childTx.TxOut, wire.NewTxOut(800, tx.TxOut[0].PkScript))
+	if _, err := network.Broadcast(ctx, childTx); err == nil {
+		t.Fatalf("\t%s\tSpent output of removed tx", tests.Failed)
+	}
+
+	t.Logf("\t%s\tReorg removed tx", tests.Success)
+}
+
+func newContext() context.Context {
+	return node.ContextWithLogger(tests.NewContext(), true, true, "./test.log")
+}
+
+// newExchange creates two daemons, each with a contract and an asset held by its issuer.
+func newExchange(t *testing.T, ctx context.Context) *exchange {
+	network := NewNetwork(ctx, "./tmp")
+	result := &exchange{network: network}
+
+	var err error
+	result.daemon1, err = network.AddDaemon(ctx, "daemon1")
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to add daemon : %v", tests.Failed, err)
+	}
+
+	result.daemon2, err = network.AddDaemon(ctx, "daemon2")
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to add daemon : %v", tests.Failed, err)
+	}
+
+	result.holder1, err = tests.GenerateKey(network.Net)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to generate key : %v", tests.Failed, err)
+	}
+
+	result.holder2, err = tests.GenerateKey(network.Net)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to generate key : %v", tests.Failed, err)
+	}
+
+	if _, err := network.CreateContract(ctx, result.daemon1, result.holder1.Address,
+		"Contract 1"); err != nil {
+		t.Fatalf("\t%s\tFailed to create contract 1 : %v", tests.Failed, err)
+	}
+
+	if _, err := network.CreateContract(ctx, result.daemon2, result.holder2.Address,
+		"Contract 2"); err != nil {
+		t.Fatalf("\t%s\tFailed to create contract 2 : %v", tests.Failed, err)
+	}
+
+	result.asset1, err = network.CreateAsset(ctx, result.daemon1, result.holder1.Address, 1000)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create asset 1 : %v", tests.Failed, err)
+	}
+
+	result.asset2, err = network.CreateAsset(ctx, result.daemon2, result.holder2.Address, 2000)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create asset 2 : %v", tests.Failed, err)
+	}
+
+	return result
+}
+
+// broadcastTransfer broadcasts an exchange of asset 1 from holder 1 for asset 2 from holder 2.
+func (e *exchange) broadcastTransfer(t *testing.T, ctx context.Context,
+	quantity1, quantity2 uint64) *wire.MsgTx {
+
+	tx := e.buildTransfer(t, quantity1, quantity2)
+	if _, err := e.network.Broadcast(ctx, tx); err != nil {
+		t.Fatalf("\t%s\tFailed to broadcast transfer : %v", tests.Failed, err)
+	}
+
+	return tx
+}
+
+// buildTransfer builds an exchange of asset 1 from holder 1 for asset 2 from holder 2.
+func (e *exchange) buildTransfer(t *testing.T, quantity1, quantity2 uint64) *wire.MsgTx {
+	transfer := &actions.Transfer{
+		Assets: []*actions.AssetTransferField{
+			&actions.AssetTransferField{
+				ContractIndex: 0,
+				AssetType:     "SHC",
+				AssetCode:     e.asset1.Bytes(),
+				AssetSenders: []*actions.QuantityIndexField{
+					&actions.QuantityIndexField{Index: 0, Quantity: quantity1},
+				},
+				AssetReceivers: []*actions.AssetReceiverField{
+					&actions.AssetReceiverField{Address: e.holder2.Address.Bytes(),
+						Quantity: quantity1},
+				},
+			},
+			&actions.AssetTransferField{
+				ContractIndex: 1,
+				AssetType:     "SHC",
+				AssetCode:     e.asset2.Bytes(),
+				AssetSenders: []*actions.QuantityIndexField{
+					&actions.QuantityIndexField{Index: 1, Quantity: quantity2},
+				},
+				AssetReceivers: []*actions.AssetReceiverField{
+					&actions.AssetReceiverField{Address: e.holder1.Address.Bytes(),
+						Quantity: quantity2},
+				},
+			},
+		},
+	}
+
+	tx, err := e.network.BuildRequest(
+		[]bitcoin.RawAddress{e.holder1.Address, e.holder2.Address},
+		[]Output{
+			{Address: e.daemon1.ContractKey.Address, Value: 3000},
+			{Address: e.daemon2.ContractKey.Address, Value: 1000},
+			{Address: e.daemon1.ContractKey.Address, Value: 5000}, // Boomerang
+		}, transfer)
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to build transfer : %v", tests.Failed, err)
+	}
+
+	return tx
+}
+
+func (e *exchange) lastResponse(t *testing.T, d *Daemon) *inspector.Transaction {
+	response := d.LastResponse()
+	if response == nil {
+		t.Fatalf("\t%s\tNo response from %s", tests.Failed, d.Name)
+	}
+	return response
+}
+
+func (e *exchange) checkBalance(t *testing.T, ctx context.Context, d *Daemon,
+	assetCode *protocol.AssetCode, holder *wallet.Key, want uint64) {
+
+	h, err := holdings.GetHolding(ctx, d.MasterDB, d.ContractKey.Address, assetCode,
+		holder.Address, e.network.Now())
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to get holding : %v", tests.Failed, err)
+	}
+
+	if h.FinalizedBalance != want {
+		t.Fatalf("\t%s\tWrong %s balance : got %d, want %d", tests.Failed, d.Name,
+			h.FinalizedBalance, want)
+	}
+
+	t.Logf("\t%s\tVerified %s balance : %d", tests.Success, d.Name, want)
+}
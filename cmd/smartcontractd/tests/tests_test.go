@@ -76,6 +76,7 @@ func testMain(m *testing.M) int {
 	a, err = handlers.API(
 		test.Context,
 		test.Wallet,
+		test.Wallet,
 		&test.NodeConfig,
 		test.MasterDB,
 		tracer,
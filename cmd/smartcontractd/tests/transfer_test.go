@@ -14,9 +14,11 @@ import (
 	"github.com/tokenized/pkg/wire"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
 	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/contract"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/smart-contract/internal/transfer"
 	"github.com/tokenized/smart-contract/pkg/inspector"
 	"github.com/tokenized/smart-contract/pkg/wallet"
 	"github.com/tokenized/specification/dist/golang/actions"
@@ -33,6 +35,7 @@ func TestTransfers(t *testing.T) {
 	t.Run("bitcoinExchange", bitcoinExchange)
 	t.Run("multiExchangeLock", multiExchangeLock)
 	t.Run("multiExchangeTimeout", multiExchangeTimeout)
+	t.Run("multiExchangePolicy", multiExchangePolicy)
 	t.Run("oracle", oracleTransfer)
 	t.Run("oracleBad", oracleTransferBad)
 	t.Run("permitted", permitted)
@@ -2387,6 +2390,230 @@ func multiExchangeTimeout(t *testing.T) {
 	}
 }
 
+func multiExchangePolicy(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+
+	test.HoldingsChannel.Open(100)
+	go func() {
+		if err := holdings.ProcessCacheItems(ctx, test.MasterDB, test.HoldingsChannel); err != nil {
+			node.LogError(ctx, "Process holdings cache failed : %s", err)
+		}
+		node.LogVerbose(ctx, "Process holdings cache thread finished")
+	}()
+	defer test.HoldingsChannel.Close()
+
+	mockUpContract(t, ctx, "Test Contract", "This is a mock contract and means nothing.", "I",
+		1, "John Bitcoin", true, true, false, false, false)
+	mockUpAsset(t, ctx, true, true, true, 1000, 0, &sampleAssetPayload, true, false, false)
+	mockUpHolding(t, ctx, userKey.Address, 100)
+
+	mockUpContract2(t, ctx, "Test Contract 2", "This is a mock contract and means nothing.", "I",
+		1, "Karl Bitcoin", true, true, false, false, false)
+	mockUpAsset2(t, ctx, true, true, true, 1500, &sampleAssetPayload2, true, false, false)
+	mockUpHolding2(t, ctx, user2Key.Address, 200)
+
+	// buildTransfer creates a transfer of asset 1 from user1 to user2 and asset 2 from user2 to
+	// user1.
+	buildTransfer := func(fundingValue uint64) *inspector.Transaction {
+		funding1Tx := tests.MockFundingTx(ctx, test.RPCNode, fundingValue, userKey.Address)
+		funding2Tx := tests.MockFundingTx(ctx, test.RPCNode, fundingValue, user2Key.Address)
+
+		transferData := actions.Transfer{
+			Assets: []*actions.AssetTransferField{
+				&actions.AssetTransferField{
+					ContractIndex: 0,
+					AssetType:     testAssetType,
+					AssetCode:     testAssetCodes[0].Bytes(),
+					AssetSenders: []*actions.QuantityIndexField{
+						&actions.QuantityIndexField{Index: 0, Quantity: 10},
+					},
+					AssetReceivers: []*actions.AssetReceiverField{
+						&actions.AssetReceiverField{Address: user2Key.Address.Bytes(),
+							Quantity: 10},
+					},
+				},
+				&actions.AssetTransferField{
+					ContractIndex: 1,
+					AssetType:     testAsset2Type,
+					AssetCode:     testAsset2Code.Bytes(),
+					AssetSenders: []*actions.QuantityIndexField{
+						&actions.QuantityIndexField{Index: 1, Quantity: 20},
+					},
+					AssetReceivers: []*actions.AssetReceiverField{
+						&actions.AssetReceiverField{Address: userKey.Address.Bytes(),
+							Quantity: 20},
+					},
+				},
+			},
+		}
+
+		transferTx := wire.NewMsgTx(1)
+		transferTx.TxIn = append(transferTx.TxIn,
+			wire.NewTxIn(wire.NewOutPoint(funding1Tx.TxHash(), 0), make([]byte, 130)))
+		transferTx.TxIn = append(transferTx.TxIn,
+			wire.NewTxIn(wire.NewOutPoint(funding2Tx.TxHash(), 0), make([]byte, 130)))
+
+		script1, _ := test.ContractKey.Address.LockingScript()
+		script2, _ := test.Contract2Key.Address.LockingScript()
+		transferTx.TxOut = append(transferTx.TxOut, wire.NewTxOut(3000, script1))
+		transferTx.TxOut = append(transferTx.TxOut, wire.NewTxOut(1000, script2))
+		transferTx.TxOut = append(transferTx.TxOut, wire.NewTxOut(5000, script1)) // Boomerang
+
+		script, err := protocol.Serialize(&transferData, test.NodeConfig.IsTest)
+		if err != nil {
+			t.Fatalf("\t%s\tFailed to serialize transfer : %v", tests.Failed, err)
+		}
+		transferTx.TxOut = append(transferTx.TxOut, wire.NewTxOut(0, script))
+
+		transferItx, err := inspector.NewTransactionFromWire(ctx, transferTx,
+			test.NodeConfig.IsTest)
+		if err != nil {
+			t.Fatalf("\t%s\tFailed to create transfer itx : %v", tests.Failed, err)
+		}
+
+		if err := transferItx.Promote(ctx, test.RPCNode); err != nil {
+			t.Fatalf("\t%s\tFailed to promote transfer itx : %v", tests.Failed, err)
+		}
+
+		test.RPCNode.SaveTX(ctx, transferTx)
+		return transferItx
+	}
+
+	// nextResponse returns the first response and clears the responses.
+	nextResponse := func() *inspector.Transaction {
+		if len(responses) == 0 {
+			t.Fatalf("\t%s\tFailed to create response", tests.Failed)
+		}
+
+		response := responses[0]
+		responses = nil
+
+		responseItx, err := inspector.NewTransactionFromWire(ctx, response,
+			test.NodeConfig.IsTest)
+		if err != nil {
+			t.Fatalf("\t%s\tFailed to create response itx : %v", tests.Failed, err)
+		}
+
+		if err := responseItx.Promote(ctx, test.RPCNode); err != nil {
+			t.Fatalf("\t%s\tFailed to promote response itx : %v", tests.Failed, err)
+		}
+
+		test.RPCNode.SaveTX(ctx, response)
+		return responseItx
+	}
+
+	/********************************* First contract refuses ************************************/
+	if err := contract.SaveTransferPolicy(ctx, test.MasterDB, test.ContractKey.Address,
+		&contract.TransferPolicy{RefuseMultiContract: true}); err != nil {
+		t.Fatalf("\t%s\tFailed to save transfer policy : %v", tests.Failed, err)
+	}
+
+	a.Trigger(ctx, "SEE", buildTransfer(100012))
+
+	rejection, ok := nextResponse().MsgProto.(*actions.Rejection)
+	if !ok {
+		t.Fatalf("\t%s\tResponse itx is not Rejection", tests.Failed)
+	}
+
+	if rejection.RejectionCode != actions.RejectionsContractNotPermitted {
+		t.Fatalf("\t%s\tWrong reject code : got %d, want %d", tests.Failed,
+			rejection.RejectionCode, actions.RejectionsContractNotPermitted)
+	}
+
+	t.Logf("\t%s\tMulti-contract transfer refused by first contract", tests.Success)
+
+	/*************************** Second contract doesn't allow first ******************************/
+	requestTimeout := 5 * time.Hour
+	if err := contract.SaveTransferPolicy(ctx, test.MasterDB, test.ContractKey.Address,
+		&contract.TransferPolicy{RequestTimeout: uint64(requestTimeout)}); err != nil {
+		t.Fatalf("\t%s\tFailed to save transfer policy : %v", tests.Failed, err)
+	}
+
+	if err := contract.SaveTransferPolicy(ctx, test.MasterDB, test.Contract2Key.Address,
+		&contract.TransferPolicy{AllowedContracts: []bitcoin.RawAddress{userKey.Address}},
+	); err != nil {
+		t.Fatalf("\t%s\tFailed to save transfer policy : %v", tests.Failed, err)
+	}
+
+	transferItx := buildTransfer(100013)
+	if err := a.Trigger(ctx, "SEE", transferItx); err != nil {
+		t.Fatalf("\t%s\tFailed to accept transfer : %v", tests.Failed, err)
+	}
+
+	pendingTransfer, err := transfer.Fetch(ctx, test.MasterDB, test.ContractKey.Address,
+		protocol.TxIdFromBytes(transferItx.Hash[:]))
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch pending transfer : %v", tests.Failed, err)
+	}
+
+	now := protocol.CurrentTimestamp()
+	remaining := time.Duration(pendingTransfer.Timeout.Nano() - now.Nano())
+	if remaining < requestTimeout-time.Minute || remaining > requestTimeout {
+		t.Fatalf("\t%s\tPolicy request timeout not used : %s", tests.Failed, remaining)
+	}
+
+	t.Logf("\t%s\tPolicy request timeout used : %s", tests.Success, remaining)
+
+	settlementRequestItx := nextResponse()
+	if settlementRequestItx.MsgProto.Code() != actions.CodeMessage {
+		t.Fatalf("\t%s\tResponse itx is not M1", tests.Failed)
+	}
+
+	a.Trigger(ctx, "SEE", settlementRequestItx)
+
+	rejection, ok = nextResponse().MsgProto.(*actions.Rejection)
+	if !ok {
+		t.Fatalf("\t%s\tSettlement request response is not Rejection", tests.Failed)
+	}
+
+	if rejection.RejectionCode != actions.RejectionsContractNotPermitted {
+		t.Fatalf("\t%s\tWrong reject code : got %d, want %d", tests.Failed,
+			rejection.RejectionCode, actions.RejectionsContractNotPermitted)
+	}
+
+	t.Logf("\t%s\tSettlement request refused by second contract", tests.Success)
+
+	/********************************** Interactions recorded *************************************/
+	interactions, err := contract.FetchInteractions(ctx, test.MasterDB, test.ContractKey.Address,
+		protocol.Timestamp{}, protocol.Timestamp{})
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch interactions : %v", tests.Failed, err)
+	}
+
+	if len(interactions) != 2 {
+		t.Fatalf("\t%s\tWrong first contract interaction count : got %d, want 2", tests.Failed,
+			len(interactions))
+	}
+	for i, interaction := range interactions {
+		if interaction.Kind != contract.InteractionTransfer ||
+			!interaction.Counterparty.Equal(test.Contract2Key.Address) {
+			t.Fatalf("\t%s\tWrong first contract interaction : %+v", tests.Failed, interaction)
+		}
+		if interaction.Permitted != (i == 1) {
+			t.Fatalf("\t%s\tWrong first contract interaction permitted : %+v", tests.Failed,
+				interaction)
+		}
+	}
+
+	interactions, err = contract.FetchInteractions(ctx, test.MasterDB, test.Contract2Key.Address,
+		protocol.Timestamp{}, protocol.Timestamp{})
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to fetch interactions : %v", tests.Failed, err)
+	}
+
+	if len(interactions) != 1 || interactions[0].Permitted ||
+		interactions[0].Kind != contract.InteractionSettlementRequest ||
+		!interactions[0].Counterparty.Equal(test.ContractKey.Address) {
+		t.Fatalf("\t%s\tWrong second contract interactions : %+v", tests.Failed, interactions)
+	}
+
+	t.Logf("\t%s\tInteractions recorded", tests.Success)
+}
+
 func oracleTransfer(t *testing.T) {
 	ctx := test.Context
 
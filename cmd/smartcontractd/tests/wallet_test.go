@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/tokenized/pkg/scheduler"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/filters"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/listeners"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+)
+
+// TestWallet is the entry point for testing wallet loading.
+func TestWallet(t *testing.T) {
+	defer tests.Recover(t)
+
+	t.Run("firstStart", walletFirstStart)
+	t.Run("restart", walletRestart)
+}
+
+// walletFirstStart checks that the configured contract key is watched when no wallet is stored.
+func walletFirstStart(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+	test.MasterDB.Remove(ctx, listeners.WalletStorageKey)
+
+	server, txFilter := newWalletServer()
+	if err := server.LoadWallet(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to load wallet : %v", tests.Failed, err)
+	}
+
+	if !txFilter.IsRelevant(ctx, contractPaymentTx(t)) {
+		t.Fatalf("\t%s\tContract tx not relevant on first start", tests.Failed)
+	}
+	t.Logf("\t%s\tContract key watched on first start", tests.Success)
+}
+
+// walletRestart checks that the contract key is watched when the wallet is loaded from storage.
+func walletRestart(t *testing.T) {
+	ctx := test.Context
+
+	if err := resetTest(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to reset test : %v", tests.Failed, err)
+	}
+
+	server, _ := newWalletServer()
+	if err := server.SaveWallet(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to save wallet : %v", tests.Failed, err)
+	}
+	defer test.MasterDB.Remove(ctx, listeners.WalletStorageKey)
+
+	server, txFilter := newWalletServer()
+	if err := server.LoadWallet(ctx); err != nil {
+		t.Fatalf("\t%s\tFailed to load wallet : %v", tests.Failed, err)
+	}
+
+	if !txFilter.IsRelevant(ctx, contractPaymentTx(t)) {
+		t.Fatalf("\t%s\tContract tx not relevant after restart", tests.Failed)
+	}
+	t.Logf("\t%s\tContract key watched after restart", tests.Success)
+}
+
+func newWalletServer() (*listeners.Server, *filters.TxFilter) {
+	tracer := filters.NewTracer()
+	txFilter := filters.NewTxFilter(tracer, true)
+	server := listeners.NewServer(test.Wallet, a, &test.NodeConfig, test.MasterDB,
+		test.RPCNode, nil, test.Headers, &scheduler.Scheduler{}, tracer, test.UTXOs, txFilter,
+		&holdings.CacheChannel{})
+	return server, txFilter
+}
+
+// contractPaymentTx returns a tx paying the contract.
+func contractPaymentTx(t *testing.T) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	tx.TxIn = append(tx.TxIn, wire.NewTxIn(wire.NewOutPoint(tests.RandomHash(), 0),
+		make([]byte, 130)))
+
+	script, err := test.ContractKey.Address.LockingScript()
+	if err != nil {
+		t.Fatalf("\t%s\tFailed to create locking script : %v", tests.Failed, err)
+	}
+	tx.TxOut = append(tx.TxOut, wire.NewTxOut(1000, script))
+
+	return tx
+}
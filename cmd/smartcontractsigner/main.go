@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/cmd/smartcontractd/bootstrap"
+	"github.com/tokenized/smart-contract/pkg/signer"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// Reference Signer
+//
+// Holds contract keys and signs txs for smartcontractd when it is configured with
+//   NODE_SIGNER_ADDRESS. It is intended for testing. A production signer should keep keys in an
+//   HSM and apply stricter policy.
+
+// Config is the signer configuration, read from SIGNER_ prefixed environment variables.
+type Config struct {
+	Listen  string   `default:"unix:./tmp/signer.sock" envconfig:"LISTEN"`
+	Keys    []string `envconfig:"KEYS"` // WIF private keys, comma separated
+	Network string   `default:"mainnet" envconfig:"BITCOIN_CHAIN"`
+
+	// Policy. The contract fee address must be allowed since responses pay fees to it.
+	MaxValue         uint64   `envconfig:"MAX_VALUE"`
+	AllowedAddresses []string `envconfig:"ALLOWED_ADDRESSES"`
+}
+
+func main() {
+	ctx := bootstrap.NewContextWithDevelopmentLogger()
+
+	var cfg Config
+	if err := envconfig.Process("SIGNER", &cfg); err != nil {
+		logger.Fatal(ctx, "Config : %s", err)
+	}
+
+	bitcoinNet := bitcoin.NetworkFromString(cfg.Network)
+
+	w := wallet.New()
+	for _, wif := range cfg.Keys {
+		if err := w.Register(wif, bitcoinNet); err != nil {
+			logger.Fatal(ctx, "Register key : %s", err)
+		}
+	}
+	if len(w.ListAll()) == 0 {
+		logger.Fatal(ctx, "No keys configured")
+	}
+
+	policy := signer.Policy{MaxValue: cfg.MaxValue}
+	for _, s := range cfg.AllowedAddresses {
+		address, err := bitcoin.DecodeAddress(s)
+		if err != nil {
+			logger.Fatal(ctx, "Allowed address %s : %s", s, err)
+		}
+		policy.AllowedAddresses = append(policy.AllowedAddresses,
+			bitcoin.NewRawAddressFromAddress(address))
+	}
+
+	network, address := signer.ParseAddress(cfg.Listen)
+	if network == "unix" {
+		os.Remove(address) // Remove stale socket
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		logger.Fatal(ctx, "Listen : %s", err)
+	}
+
+	osSignals := make(chan os.Signal, 1)
+	signal.Notify(osSignals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-osSignals
+		logger.Info(ctx, "Shutting down")
+		listener.Close()
+	}()
+
+	logger.Info(ctx, "Signing for %d keys on %s", len(w.ListAll()), cfg.Listen)
+	server := signer.NewServer(w, policy)
+	if err := server.Serve(ctx, listener); err != nil {
+		logger.Info(ctx, "Stopped : %s", err)
+	}
+}
@@ -12,6 +12,7 @@ require (
 	github.com/tokenized/pkg v0.2.2
 	github.com/tokenized/specification v0.3.1
 	go.opencensus.io v0.22.2
+	golang.org/x/crypto v0.0.0-20200510223506-06a226fb4e37
 	golang.org/x/lint v0.0.0-20191125180803-fdd1cda4f05f // indirect
 	golang.org/x/tools v0.0.0-20200107184032-11e9d9cc0042 // indirect
 )
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"strings"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/smart-contract/internal/platform/db"
@@ -76,6 +78,33 @@ func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddres
 	return &asset, nil
 }
 
+// List returns the codes of all assets in storage for a contract.
+func List(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) ([]*protocol.AssetCode, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	path := fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(), storageSubKey)
+	keys, err := dbConn.List(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "list assets")
+	}
+
+	result := make([]*protocol.AssetCode, 0, len(keys))
+	for _, key := range keys {
+		b, err := hex.DecodeString(key[strings.LastIndex(key, "/")+1:])
+		if err != nil || len(b) != 32 {
+			continue // Not an asset
+		}
+		result = append(result, protocol.AssetCodeFromBytes(b))
+	}
+
+	return result, nil
+}
+
 func Reset(ctx context.Context) {
 	cache = nil
 }
@@ -0,0 +1,246 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/transactions"
+	"github.com/tokenized/smart-contract/internal/transfer"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	CheckTokenQty        = "token_qty"
+	CheckHoldingStatuses = "holding_statuses"
+	CheckAssetCodes      = "asset_codes"
+	CheckTransfers       = "pending_transfers"
+	CheckVotes           = "votes"
+)
+
+// Violation is a broken state invariant.
+type Violation struct {
+	Check       string `json:"Check"`
+	Description string `json:"Description"`
+	Repair      string `json:"Repair,omitempty"`
+}
+
+func (v *Violation) String() string {
+	if len(v.Repair) == 0 {
+		return fmt.Sprintf("[%s] %s", v.Check, v.Description)
+	}
+	return fmt.Sprintf("[%s] %s\n  Repair : %s", v.Check, v.Description, v.Repair)
+}
+
+// Audit checks the stored state of a contract for consistency and returns any violations found.
+func Audit(ctx context.Context, dbConn *db.DB, ct *state.Contract, isTest bool,
+	now protocol.Timestamp) ([]*Violation, error) {
+
+	var result []*Violation
+
+	v, err := auditAssetCodes(ctx, dbConn, ct)
+	if err != nil {
+		return nil, errors.Wrap(err, "asset codes")
+	}
+	result = append(result, v...)
+
+	for _, assetCode := range ct.AssetCodes {
+		as, err := asset.Fetch(ctx, dbConn, ct.Address, assetCode)
+		if err != nil {
+			if err == asset.ErrNotFound {
+				continue // Reported by asset codes check
+			}
+			return nil, errors.Wrap(err, "fetch asset")
+		}
+
+		v, err := auditHoldings(ctx, dbConn, ct.Address, as, isTest)
+		if err != nil {
+			return nil, errors.Wrap(err, "holdings")
+		}
+		result = append(result, v...)
+	}
+
+	v, err = auditTransfers(ctx, dbConn, ct.Address, isTest)
+	if err != nil {
+		return nil, errors.Wrap(err, "transfers")
+	}
+	result = append(result, v...)
+
+	v, err = auditVotes(ctx, dbConn, ct.Address, now)
+	if err != nil {
+		return nil, errors.Wrap(err, "votes")
+	}
+	result = append(result, v...)
+
+	return result, nil
+}
+
+// auditAssetCodes verifies the contract's asset codes match the assets in storage.
+func auditAssetCodes(ctx context.Context, dbConn *db.DB,
+	ct *state.Contract) ([]*Violation, error) {
+
+	stored, err := asset.List(ctx, dbConn, ct.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*Violation
+	storedCodes := make(map[protocol.AssetCode]bool)
+	for _, assetCode := range stored {
+		storedCodes[*assetCode] = true
+	}
+
+	contractCodes := make(map[protocol.AssetCode]bool)
+	for _, assetCode := range ct.AssetCodes {
+		contractCodes[*assetCode] = true
+		if !storedCodes[*assetCode] {
+			result = append(result, &Violation{
+				Check:       CheckAssetCodes,
+				Description: fmt.Sprintf("Contract asset %s is not in storage", assetCode.String()),
+				Repair:      "Rebuild state from chain or remove the asset code from the contract",
+			})
+		}
+	}
+
+	for _, assetCode := range stored {
+		if !contractCodes[*assetCode] {
+			result = append(result, &Violation{
+				Check:       CheckAssetCodes,
+				Description: fmt.Sprintf("Stored asset %s is not in contract asset codes", assetCode.String()),
+				Repair:      "Add the asset code to the contract or remove the stored asset",
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// auditHoldings verifies the holdings of an asset add up to its token quantity and that all
+//   holding statuses reference stored txs.
+func auditHoldings(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	as *state.Asset, isTest bool) ([]*Violation, error) {
+
+	hs, err := holdings.FetchAll(ctx, dbConn, contractAddress, as.Code)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch holdings")
+	}
+
+	var result []*Violation
+	total := uint64(0)
+	for _, h := range hs {
+		total += h.FinalizedBalance
+
+		for _, status := range h.HoldingStatuses {
+			exists, err := txExists(ctx, dbConn, status.TxId, isTest)
+			if err != nil {
+				return nil, errors.Wrap(err, "check tx")
+			}
+			if exists {
+				continue
+			}
+
+			result = append(result, &Violation{
+				Check: CheckHoldingStatuses,
+				Description: fmt.Sprintf("Asset %s holding %x has status %c for missing tx %s",
+					as.Code.String(), h.Address.Bytes(), status.Code, status.TxId.String()),
+				Repair: "Revert the holding status for the tx",
+			})
+		}
+	}
+
+	if total != as.TokenQty {
+		result = append(result, &Violation{
+			Check: CheckTokenQty,
+			Description: fmt.Sprintf("Asset %s finalized balances total %d, token quantity is %d",
+				as.Code.String(), total, as.TokenQty),
+			Repair: "Rebuild holdings from chain",
+		})
+	}
+
+	return result, nil
+}
+
+// auditTransfers verifies every pending transfer has a stored tx.
+func auditTransfers(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	isTest bool) ([]*Violation, error) {
+
+	transfers, err := transfer.List(ctx, dbConn, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list transfers")
+	}
+
+	var result []*Violation
+	for _, pt := range transfers {
+		exists, err := txExists(ctx, dbConn, pt.TransferTxId, isTest)
+		if err != nil {
+			return nil, errors.Wrap(err, "check tx")
+		}
+		if exists {
+			continue
+		}
+
+		result = append(result, &Violation{
+			Check: CheckTransfers,
+			Description: fmt.Sprintf("Pending transfer %s has no stored tx",
+				pt.TransferTxId.String()),
+			Repair: "Remove the pending transfer and revert related holding statuses",
+		})
+	}
+
+	return result, nil
+}
+
+// auditVotes verifies that votes that are not completed have not expired.
+func auditVotes(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	now protocol.Timestamp) ([]*Violation, error) {
+
+	votes, err := vote.List(ctx, dbConn, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list votes")
+	}
+
+	var result []*Violation
+	for _, vt := range votes {
+		if vt.CompletedAt.Nano() != 0 || vt.Expires.Nano() > now.Nano() {
+			continue
+		}
+
+		result = append(result, &Violation{
+			Check: CheckVotes,
+			Description: fmt.Sprintf("Vote %s expired at %s but was not completed",
+				vt.VoteTxId.String(), vt.Expires.String()),
+			Repair: "Restart the daemon to schedule vote finalization",
+		})
+	}
+
+	return result, nil
+}
+
+func txExists(ctx context.Context, dbConn *db.DB, txid *protocol.TxId,
+	isTest bool) (bool, error) {
+
+	if txid == nil {
+		return false, nil
+	}
+
+	hash, err := bitcoin.NewHash32(txid.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := transactions.GetTx(ctx, dbConn, hash, isTest); err != nil {
+		if err == transactions.ErrNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
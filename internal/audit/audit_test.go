@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/asset"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/vote"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+	defer dbConn.Clear(ctx, "")
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contractAddress, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	now := protocol.CurrentTimestamp()
+	assetCode := protocol.AssetCodeFromContract(contractAddress, 0)
+	as := &state.Asset{
+		Code:      assetCode,
+		AssetType: "SHC",
+		TokenQty:  1000,
+	}
+	if err := asset.Save(ctx, dbConn, contractAddress, as); err != nil {
+		t.Fatalf("Failed to save asset : %s", err)
+	}
+
+	h, err := holdings.GetHolding(ctx, dbConn, contractAddress, assetCode, contractAddress, now)
+	if err != nil {
+		t.Fatalf("Failed to get holding : %s", err)
+	}
+	h.FinalizedBalance = 1000
+	h.PendingBalance = 1000
+	ci, err := holdings.Save(ctx, dbConn, contractAddress, assetCode, h)
+	if err != nil {
+		t.Fatalf("Failed to save holding : %s", err)
+	}
+	if err := ci.Write(ctx, dbConn); err != nil {
+		t.Fatalf("Failed to write holding : %s", err)
+	}
+
+	ct := &state.Contract{
+		Address:    contractAddress,
+		AssetCodes: []*protocol.AssetCode{assetCode},
+	}
+
+	violations, err := Audit(ctx, dbConn, ct, true, now)
+	if err != nil {
+		t.Fatalf("Failed to audit : %s", err)
+	}
+	if len(violations) != 0 {
+		t.Fatalf("Unexpected violations : %v", violations)
+	}
+
+	// Break invariants
+	as.TokenQty = 2000
+	if err := asset.Save(ctx, dbConn, contractAddress, as); err != nil {
+		t.Fatalf("Failed to save asset : %s", err)
+	}
+
+	vt := &state.Vote{
+		VoteTxId: protocol.TxIdFromBytes(make([]byte, 32)),
+		Expires:  protocol.NewTimestamp(now.Nano() - 1000),
+	}
+	if err := vote.Save(ctx, dbConn, contractAddress, vt); err != nil {
+		t.Fatalf("Failed to save vote : %s", err)
+	}
+
+	violations, err = Audit(ctx, dbConn, ct, true, now)
+	if err != nil {
+		t.Fatalf("Failed to audit : %s", err)
+	}
+
+	checks := make(map[string]bool)
+	for _, v := range violations {
+		t.Logf("Violation : %s", v.String())
+		checks[v.Check] = true
+	}
+	if len(violations) != 2 || !checks[CheckTokenQty] || !checks[CheckVotes] {
+		t.Fatalf("Wrong violations : %v", violations)
+	}
+}
@@ -44,6 +44,9 @@ func SaveContractFormation(ctx context.Context, dbConn *db.DB, ra bitcoin.RawAdd
 	if err := updateExpandedOracles(ctx, ra, cf); err != nil {
 		return errors.Wrap(err, "update expanded oracles")
 	}
+	if err := updateExpandedAuthorities(ctx, ra, cf); err != nil {
+		return errors.Wrap(err, "update expanded authorities")
+	}
 
 	b, err = protocol.Serialize(cf, isTest)
 	if err != nil {
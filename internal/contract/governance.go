@@ -0,0 +1,105 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const governanceStorageKey = "governance"
+
+// GovernancePolicy is the operator managed policy of a contract for votes. It isn't part of the
+//   contract's on chain state.
+type GovernancePolicy struct {
+	// QuorumPercentages are the percentages of the vote's token quantity that must be voted for a
+	//   vote to have a result, by voting system index. Missing or zero values have no quorum.
+	QuorumPercentages []uint32 `json:"QuorumPercentages,omitempty"`
+
+	// AutoApply is whether the amendments of passed proposals are applied without an amendment
+	//   request, by voting system index. Missing values aren't applied automatically.
+	AutoApply []bool `json:"AutoApply,omitempty"`
+
+	// EarlyClose is whether absolute votes complete as soon as the ballots not cast can't change
+	//   the result, by voting system index. Missing values run until the cut off.
+	EarlyClose []bool `json:"EarlyClose,omitempty"`
+
+	UpdatedAt protocol.Timestamp `json:"UpdatedAt,omitempty"`
+}
+
+// SaveGovernancePolicy writes the governance policy of a contract.
+func SaveGovernancePolicy(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	policy *GovernancePolicy) error {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return errors.Wrap(err, "marshal policy")
+	}
+
+	return dbConn.Put(ctx, buildGovernanceStoragePath(contractHash), b)
+}
+
+// FetchGovernancePolicy returns the governance policy of a contract. A contract without a stored
+//   policy has an empty policy with no quorums.
+func FetchGovernancePolicy(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) (*GovernancePolicy, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	result := &GovernancePolicy{}
+	b, err := dbConn.Fetch(ctx, buildGovernanceStoragePath(contractHash))
+	if err != nil {
+		if errors.Cause(err) == db.ErrNotFound {
+			return result, nil
+		}
+		return nil, errors.Wrap(err, "fetch policy")
+	}
+
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal policy")
+	}
+
+	return result, nil
+}
+
+// Quorum returns the quorum percentage of a voting system, or zero when it has none.
+func (p *GovernancePolicy) Quorum(votingSystem uint32) uint32 {
+	if int(votingSystem) >= len(p.QuorumPercentages) {
+		return 0
+	}
+	return p.QuorumPercentages[votingSystem]
+}
+
+// AutoApplies returns true if the amendments of passed proposals of a voting system are applied
+//   automatically.
+func (p *GovernancePolicy) AutoApplies(votingSystem uint32) bool {
+	if int(votingSystem) >= len(p.AutoApply) {
+		return false
+	}
+	return p.AutoApply[votingSystem]
+}
+
+// ClosesEarly returns true if the votes of a voting system complete when the result is decided.
+func (p *GovernancePolicy) ClosesEarly(votingSystem uint32) bool {
+	if int(votingSystem) >= len(p.EarlyClose) {
+		return false
+	}
+	return p.EarlyClose[votingSystem]
+}
+
+func buildGovernanceStoragePath(contractHash *bitcoin.Hash20) string {
+	return fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(), governanceStorageKey)
+}
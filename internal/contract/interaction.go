@@ -0,0 +1,130 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	interactionStorageKey = "interactions"
+
+	// InteractionTransfer is a transfer request received by the first contract.
+	InteractionTransfer = "Transfer"
+
+	// InteractionSettlementRequest is a settlement request received from another contract.
+	InteractionSettlementRequest = "SettlementRequest"
+
+	// InteractionSignatureRequest is a settlement signature request received from another
+	//   contract.
+	InteractionSignatureRequest = "SignatureRequest"
+)
+
+// Interaction is the audit record of a contract checking another contract involved in a
+//   transfer against its transfer policy.
+type Interaction struct {
+	ContractAddress bitcoin.RawAddress `json:"ContractAddress,omitempty"`
+	Counterparty    bitcoin.RawAddress `json:"Counterparty,omitempty"`
+	Kind            string             `json:"Kind,omitempty"`
+
+	// TxId is the hash of the transfer tx.
+	TxId *bitcoin.Hash32 `json:"TxId,omitempty"`
+
+	Permitted bool   `json:"Permitted"`
+	Reason    string `json:"Reason,omitempty"`
+
+	Timestamp protocol.Timestamp `json:"Timestamp,omitempty"`
+}
+
+// SaveInteraction writes an interaction record.
+func SaveInteraction(ctx context.Context, dbConn *db.DB, interaction *Interaction) error {
+	if interaction.TxId == nil {
+		return errors.New("Missing txid")
+	}
+
+	contractHash, err := interaction.ContractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+
+	counterpartyHash, err := interaction.Counterparty.Hash()
+	if err != nil {
+		return errors.Wrap(err, "counterparty hash")
+	}
+
+	b, err := json.Marshal(interaction)
+	if err != nil {
+		return errors.Wrap(err, "marshal interaction")
+	}
+
+	return dbConn.Put(ctx, buildInteractionStoragePath(contractHash, interaction.Timestamp,
+		interaction.TxId, counterpartyHash), b)
+}
+
+// FetchInteractions returns the interaction records of a contract with timestamps in
+//   [start, end), oldest first. A zero end means no end.
+func FetchInteractions(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	start, end protocol.Timestamp) ([]*Interaction, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	keys, err := dbConn.List(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
+		interactionStorageKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "list interactions")
+	}
+
+	var result []*Interaction
+	for _, key := range keys {
+		name := key[strings.LastIndex(key, "/")+1:]
+		underscore := strings.Index(name, "_")
+		if underscore == -1 {
+			continue // not an interaction
+		}
+
+		ts, err := strconv.ParseUint(name[:underscore], 10, 64)
+		if err != nil {
+			continue // not an interaction
+		}
+		if ts < start.Nano() || (end.Nano() != 0 && ts >= end.Nano()) {
+			continue
+		}
+
+		b, err := dbConn.Fetch(ctx, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch interaction")
+		}
+
+		interaction := &Interaction{}
+		if err := json.Unmarshal(b, interaction); err != nil {
+			return nil, errors.Wrap(err, "unmarshal interaction")
+		}
+		result = append(result, interaction)
+	}
+
+	// Keys are zero padded timestamps, but the storage doesn't guarantee list order.
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Nano() < result[j].Timestamp.Nano()
+	})
+	return result, nil
+}
+
+// buildInteractionStoragePath returns the storage path for an interaction record. The timestamp
+//   is zero padded so keys sort chronologically.
+func buildInteractionStoragePath(contractHash *bitcoin.Hash20, timestamp protocol.Timestamp,
+	txid *bitcoin.Hash32, counterpartyHash *bitcoin.Hash20) string {
+	return fmt.Sprintf("%s/%s/%s/%020d_%s_%s", storageKey, contractHash.String(),
+		interactionStorageKey, timestamp.Nano(), txid.String(), counterpartyHash.String())
+}
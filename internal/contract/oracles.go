@@ -67,6 +67,68 @@ func ExpandOracles(ctx context.Context, dbConn *db.DB, c *state.Contract, isTest
 			c.FullOracles = append(c.FullOracles, state.Oracle{})
 		}
 	}
+
+	return expandAuthorities(ctx, dbConn, c, isTest)
+}
+
+// expandAuthorities pulls the public keys of the authority oracles used in the contract from their
+//   contract formations.
+func expandAuthorities(ctx context.Context, dbConn *db.DB, c *state.Contract, isTest bool) error {
+	c.FullAuthorities = nil
+	for _, oracle := range c.Oracles {
+		isAuthority := false
+		for _, t := range oracle.OracleTypes {
+			if t == actions.ServiceTypeAuthorityOracle {
+				isAuthority = true
+				break
+			}
+		}
+
+		if !isAuthority {
+			continue
+		}
+
+		ra, err := bitcoin.DecodeRawAddress(oracle.EntityContract)
+		if err != nil {
+			return errors.Wrap(err, "authority address")
+		}
+
+		cf, err := FetchContractFormation(ctx, dbConn, ra, isTest)
+		if err != nil {
+			return errors.Wrap(err, "fetch authority")
+		}
+
+		for _, service := range cf.Services {
+			if service.Type != actions.ServiceTypeAuthorityOracle {
+				continue
+			}
+
+			publicKey, err := bitcoin.PublicKeyFromBytes(service.PublicKey)
+			if err != nil {
+				return errors.Wrap(err, "authority key")
+			}
+
+			c.FullAuthorities = append(c.FullAuthorities, state.Oracle{
+				Address:   ra,
+				URL:       service.URL,
+				PublicKey: publicKey,
+			})
+			break
+		}
+	}
+
+	logger.Info(ctx, "Expanded %d authority oracle public keys", len(c.FullAuthorities))
+	return nil
+}
+
+// Authority returns the authority oracle of the contract with the public key, or nil if the key
+//   isn't the current key of one of the contract's authority oracles.
+func Authority(c *state.Contract, publicKey bitcoin.PublicKey) *state.Oracle {
+	for i, authority := range c.FullAuthorities {
+		if authority.PublicKey.Equal(publicKey) {
+			return &c.FullAuthorities[i]
+		}
+	}
 	return nil
 }
 
@@ -105,3 +167,40 @@ func updateExpandedOracles(ctx context.Context, ra bitcoin.RawAddress, cf *actio
 
 	return nil
 }
+
+// updateExpandedAuthorities updates expanded authority oracles that are in the cache.
+func updateExpandedAuthorities(ctx context.Context, ra bitcoin.RawAddress,
+	cf *actions.ContractFormation) error {
+
+	var service *actions.ServiceField
+	for _, s := range cf.Services {
+		if s.Type == actions.ServiceTypeAuthorityOracle {
+			service = s
+			break
+		}
+	}
+
+	if service == nil {
+		return nil
+	}
+
+	publicKey, err := bitcoin.PublicKeyFromBytes(service.PublicKey)
+	if err != nil {
+		return errors.Wrap(err, "parse public key")
+	}
+
+	cacheLock.Lock()
+	defer cacheLock.Unlock()
+	for _, c := range cache {
+		for i, authority := range c.FullAuthorities {
+			if !ra.Equal(authority.Address) {
+				continue
+			}
+
+			c.FullAuthorities[i].PublicKey = publicKey
+			c.FullAuthorities[i].URL = service.URL
+		}
+	}
+
+	return nil
+}
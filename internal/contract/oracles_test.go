@@ -0,0 +1,85 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/specification/dist/golang/actions"
+)
+
+func TestExpandAuthorities(t *testing.T) {
+	ctx := context.Background()
+	dbConn := tests.NewMasterDB(t)
+
+	authorityAddress := generateAddress(t)
+	authorityKey := generateKey(t)
+
+	cf := &actions.ContractFormation{
+		ContractName: "Test Authority",
+		ContractType: actions.ContractTypeEntity,
+		Services: []*actions.ServiceField{
+			{
+				Type:      actions.ServiceTypeAuthorityOracle,
+				URL:       "authority.test",
+				PublicKey: authorityKey.PublicKey().Bytes(),
+			},
+		},
+		Timestamp: 1,
+	}
+	if err := SaveContractFormation(ctx, dbConn, authorityAddress, cf, true); err != nil {
+		t.Fatalf("Failed to save authority contract formation : %s", err)
+	}
+
+	ct := &state.Contract{
+		Address: generateAddress(t),
+		Oracles: []*actions.OracleField{
+			{
+				OracleTypes:    []uint32{actions.ServiceTypeAuthorityOracle},
+				EntityContract: authorityAddress.Bytes(),
+			},
+		},
+	}
+	if err := ExpandOracles(ctx, dbConn, ct, true); err != nil {
+		t.Fatalf("Failed to expand oracles : %s", err)
+	}
+	if err := Save(ctx, dbConn, ct, true); err != nil {
+		t.Fatalf("Failed to save contract : %s", err)
+	}
+
+	authority := Authority(ct, authorityKey.PublicKey())
+	if authority == nil {
+		t.Fatalf("Authority not found")
+	}
+	if !authority.Address.Equal(authorityAddress) {
+		t.Errorf("Wrong authority address")
+	}
+	if Authority(ct, generateKey(t).PublicKey()) != nil {
+		t.Errorf("Unregistered key should not be an authority")
+	}
+
+	// The authority changes its key in a new contract formation.
+	newKey := generateKey(t)
+	cf.Services[0].PublicKey = newKey.PublicKey().Bytes()
+	cf.Timestamp = 2
+	if err := SaveContractFormation(ctx, dbConn, authorityAddress, cf, true); err != nil {
+		t.Fatalf("Failed to save authority contract formation : %s", err)
+	}
+
+	if Authority(ct, authorityKey.PublicKey()) != nil {
+		t.Errorf("Previous authority key should not be valid")
+	}
+	if Authority(ct, newKey.PublicKey()) == nil {
+		t.Errorf("New authority key not found")
+	}
+}
+
+func generateKey(t *testing.T) bitcoin.Key {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	return key
+}
@@ -0,0 +1,187 @@
+package contract
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const policyStorageKey = "policy"
+
+// TransferPolicy is the operator managed policy of a contract for transfers involving other
+//   contracts. It isn't part of the contract's on chain state.
+type TransferPolicy struct {
+	// RequestTimeout is nanoseconds until a request to other contracts times out and the transfer
+	//   is rejected. Zero uses the node's request timeout.
+	RequestTimeout uint64 `json:"RequestTimeout,omitempty"`
+
+	// RefuseMultiContract rejects all transfers involving other contracts.
+	RefuseMultiContract bool `json:"RefuseMultiContract,omitempty"`
+
+	// AllowedContracts, AllowedEntities, and AllowedOperators are the only counterparties
+	//   permitted in transfers. A counterparty matching any of them is permitted. When all are
+	//   empty any counterparty that isn't denied is permitted.
+	AllowedContracts []bitcoin.RawAddress `json:"AllowedContracts,omitempty"`
+
+	// AllowedEntities are identifiers of counterparty issuers. They match the LEI, name, or domain
+	//   name of the issuer in the counterparty's contract formation, ignoring case.
+	AllowedEntities []string `json:"AllowedEntities,omitempty"`
+
+	// AllowedOperators match the operator address in the counterparty's contract formation.
+	AllowedOperators []bitcoin.RawAddress `json:"AllowedOperators,omitempty"`
+
+	// DeniedContracts, DeniedEntities, and DeniedOperators are counterparties that are never
+	//   permitted, even if they are also allowed.
+	DeniedContracts []bitcoin.RawAddress `json:"DeniedContracts,omitempty"`
+	DeniedEntities  []string             `json:"DeniedEntities,omitempty"`
+	DeniedOperators []bitcoin.RawAddress `json:"DeniedOperators,omitempty"`
+
+	UpdatedAt protocol.Timestamp `json:"UpdatedAt,omitempty"`
+}
+
+// SaveTransferPolicy writes the transfer policy of a contract.
+func SaveTransferPolicy(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	policy *TransferPolicy) error {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+
+	b, err := json.Marshal(policy)
+	if err != nil {
+		return errors.Wrap(err, "marshal policy")
+	}
+
+	return dbConn.Put(ctx, buildPolicyStoragePath(contractHash), b)
+}
+
+// FetchTransferPolicy returns the transfer policy of a contract. A contract without a stored
+//   policy has an empty policy that permits any transfer.
+func FetchTransferPolicy(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) (*TransferPolicy, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	result := &TransferPolicy{}
+	b, err := dbConn.Fetch(ctx, buildPolicyStoragePath(contractHash))
+	if err != nil {
+		if errors.Cause(err) == db.ErrNotFound {
+			return result, nil
+		}
+		return nil, errors.Wrap(err, "fetch policy")
+	}
+
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal policy")
+	}
+
+	return result, nil
+}
+
+// NeedsFormation returns true if checking counterparties requires their contract formations.
+func (p *TransferPolicy) NeedsFormation() bool {
+	return len(p.AllowedEntities) > 0 || len(p.AllowedOperators) > 0 ||
+		len(p.DeniedEntities) > 0 || len(p.DeniedOperators) > 0
+}
+
+// PermitsCounterparty returns true if the policy permits a transfer involving the other contract.
+//   cf is the counterparty's contract formation, or nil when it isn't known. A counterparty that
+//   can't be matched to an allowed contract, entity, or operator is unknown and isn't permitted.
+//   When it isn't permitted the reason is returned.
+func (p *TransferPolicy) PermitsCounterparty(contractAddress bitcoin.RawAddress,
+	cf *actions.ContractFormation) (bool, string) {
+
+	if p.RefuseMultiContract {
+		return false, "Multi-contract transfers refused"
+	}
+
+	if containsAddress(p.DeniedContracts, contractAddress) {
+		return false, "Contract denied"
+	}
+
+	if cf != nil {
+		if matchesEntity(p.DeniedEntities, cf.Issuer) {
+			return false, "Issuer entity denied"
+		}
+		if operatorMatches(p.DeniedOperators, cf) {
+			return false, "Operator denied"
+		}
+	}
+
+	if len(p.AllowedContracts) == 0 && len(p.AllowedEntities) == 0 &&
+		len(p.AllowedOperators) == 0 {
+		return true, ""
+	}
+
+	if containsAddress(p.AllowedContracts, contractAddress) {
+		return true, ""
+	}
+
+	if cf == nil {
+		if len(p.AllowedEntities) > 0 || len(p.AllowedOperators) > 0 {
+			return false, "Unknown counterparty : contract formation not found"
+		}
+		return false, "Unknown counterparty"
+	}
+
+	if matchesEntity(p.AllowedEntities, cf.Issuer) || operatorMatches(p.AllowedOperators, cf) {
+		return true, ""
+	}
+
+	return false, "Unknown counterparty"
+}
+
+func containsAddress(list []bitcoin.RawAddress, ra bitcoin.RawAddress) bool {
+	for _, item := range list {
+		if item.Equal(ra) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesEntity returns true if any identifier matches the LEI, name, or domain name of the
+//   entity.
+func matchesEntity(identifiers []string, entity *actions.EntityField) bool {
+	if entity == nil {
+		return false
+	}
+
+	for _, identifier := range identifiers {
+		for _, value := range []string{entity.LEI, entity.Name, entity.DomainName} {
+			if len(value) > 0 && strings.EqualFold(identifier, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func operatorMatches(list []bitcoin.RawAddress, cf *actions.ContractFormation) bool {
+	if len(cf.OperatorAddress) == 0 {
+		return false
+	}
+
+	ra, err := bitcoin.DecodeRawAddress(cf.OperatorAddress)
+	if err != nil {
+		return false
+	}
+
+	return containsAddress(list, ra)
+}
+
+func buildPolicyStoragePath(contractHash *bitcoin.Hash20) string {
+	return fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(), policyStorageKey)
+}
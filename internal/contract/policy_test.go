@@ -0,0 +1,131 @@
+package contract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/tests"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestPermitsCounterparty(t *testing.T) {
+	counterparty := generateAddress(t)
+	operator := generateAddress(t)
+	other := generateAddress(t)
+
+	cf := &actions.ContractFormation{
+		Issuer: &actions.EntityField{
+			Name: "Test Issuer",
+			LEI:  "5493001KJTIIGC8Y1R12",
+		},
+		OperatorAddress: operator.Bytes(),
+	}
+
+	tt := []struct {
+		name      string
+		policy    TransferPolicy
+		cf        *actions.ContractFormation
+		permitted bool
+	}{
+		{"empty", TransferPolicy{}, nil, true},
+		{"refuse", TransferPolicy{RefuseMultiContract: true}, cf, false},
+		{"allowed contract",
+			TransferPolicy{AllowedContracts: []bitcoin.RawAddress{counterparty}}, nil, true},
+		{"other contract allowed",
+			TransferPolicy{AllowedContracts: []bitcoin.RawAddress{other}}, cf, false},
+		{"denied contract",
+			TransferPolicy{DeniedContracts: []bitcoin.RawAddress{counterparty}}, cf, false},
+		{"deny wins",
+			TransferPolicy{
+				AllowedContracts: []bitcoin.RawAddress{counterparty},
+				DeniedEntities:   []string{"test issuer"},
+			}, cf, false},
+		{"allowed entity", TransferPolicy{AllowedEntities: []string{"5493001KJTIIGC8Y1R12"}}, cf,
+			true},
+		{"unknown formation", TransferPolicy{AllowedEntities: []string{"Test Issuer"}}, nil,
+			false},
+		{"allowed operator",
+			TransferPolicy{AllowedOperators: []bitcoin.RawAddress{operator}}, cf, true},
+		{"denied operator",
+			TransferPolicy{DeniedOperators: []bitcoin.RawAddress{operator}}, cf, false},
+		{"other denied", TransferPolicy{DeniedOperators: []bitcoin.RawAddress{other}}, cf, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			permitted, reason := tc.policy.PermitsCounterparty(counterparty, tc.cf)
+			if permitted != tc.permitted {
+				t.Fatalf("Wrong result : got %t, want %t (%s)", permitted, tc.permitted, reason)
+			}
+			if !permitted && len(reason) == 0 {
+				t.Fatalf("Missing reason")
+			}
+		})
+	}
+}
+
+func TestInteractions(t *testing.T) {
+	ctx := context.Background()
+	dbConn := tests.NewMasterDB(t)
+
+	contractAddress := generateAddress(t)
+	counterparty := generateAddress(t)
+
+	var txid bitcoin.Hash32
+	txid[0] = 1
+
+	for i, kind := range []string{InteractionSettlementRequest, InteractionTransfer} {
+		interaction := &Interaction{
+			ContractAddress: contractAddress,
+			Counterparty:    counterparty,
+			Kind:            kind,
+			TxId:            &txid,
+			Permitted:       i == 0,
+			Timestamp:       protocol.NewTimestamp(uint64(1000 * (i + 1))),
+		}
+		if err := SaveInteraction(ctx, dbConn, interaction); err != nil {
+			t.Fatalf("Failed to save interaction : %s", err)
+		}
+	}
+
+	interactions, err := FetchInteractions(ctx, dbConn, contractAddress, protocol.Timestamp{},
+		protocol.Timestamp{})
+	if err != nil {
+		t.Fatalf("Failed to fetch interactions : %s", err)
+	}
+
+	if len(interactions) != 2 {
+		t.Fatalf("Wrong interaction count : got %d, want 2", len(interactions))
+	}
+	if interactions[0].Kind != InteractionSettlementRequest || !interactions[0].Permitted {
+		t.Errorf("Wrong first interaction : %+v", interactions[0])
+	}
+	if !interactions[1].Counterparty.Equal(counterparty) || interactions[1].Permitted {
+		t.Errorf("Wrong second interaction : %+v", interactions[1])
+	}
+
+	interactions, err = FetchInteractions(ctx, dbConn, contractAddress, protocol.NewTimestamp(1500),
+		protocol.Timestamp{})
+	if err != nil {
+		t.Fatalf("Failed to fetch interactions : %s", err)
+	}
+	if len(interactions) != 1 {
+		t.Errorf("Wrong interaction count after start : got %d, want 1", len(interactions))
+	}
+}
+
+func generateAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return ra
+}
@@ -0,0 +1,203 @@
+package delegation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const storageKey = "contracts"
+const storageSubKey = "delegations"
+
+// allAssets is the storage scope of delegations for all assets of the contract.
+const allAssets = "all"
+
+// MessageCode identifies a message payload that sets or revokes a delegation. Delegations aren't
+//   in the protocol specification, so the code is outside of the ranges it uses.
+const MessageCode = uint32(9001)
+
+var (
+	// ErrNotFound abstracts the standard not found error.
+	ErrNotFound = errors.New("Delegation not found")
+)
+
+// Message is the payload of a message from a holder to their contract that assigns their votes
+//   to a proxy. The holder is the address of the message's first input, so the holder signs it.
+//   It is encoded as JSON.
+type Message struct {
+	// Proxy is the address that can vote for the holder. Empty revokes the delegation.
+	Proxy bitcoin.RawAddress `json:"Proxy,omitempty"`
+
+	// AssetCode limits the delegation to votes of one asset. Empty is all assets of the contract.
+	AssetCode *protocol.AssetCode `json:"AssetCode,omitempty"`
+
+	// Expires is when the delegation ends. Zero never expires.
+	Expires protocol.Timestamp `json:"Expires,omitempty"`
+}
+
+// Bytes returns the encoded message.
+func (m *Message) Bytes() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DeserializeMessage decodes a message payload.
+func DeserializeMessage(b []byte) (*Message, error) {
+	result := &Message{}
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal message")
+	}
+	return result, nil
+}
+
+// Save writes a delegation, replacing the delegator's delegation for the same assets.
+func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	d *state.Delegation) error {
+
+	key, err := buildStoragePath(contractAddress, d.Delegator, d.AssetCode)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(d)
+	if err != nil {
+		return errors.Wrap(err, "marshal delegation")
+	}
+
+	return dbConn.Put(ctx, key, data)
+}
+
+// Remove deletes the delegator's delegation for the assets. assetCode is nil for the delegation
+//   for all assets.
+func Remove(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	delegator bitcoin.RawAddress, assetCode *protocol.AssetCode) error {
+
+	key, err := buildStoragePath(contractAddress, delegator, assetCode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := dbConn.Fetch(ctx, key); err != nil {
+		if errors.Cause(err) == db.ErrNotFound {
+			return ErrNotFound
+		}
+		return err
+	}
+
+	return dbConn.Remove(ctx, key)
+}
+
+// List returns all delegations of a contract, including expired delegations.
+func List(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) ([]*state.Delegation, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	data, err := dbConn.Search(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
+		storageSubKey))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*state.Delegation, 0, len(data))
+	for _, b := range data {
+		d := &state.Delegation{}
+		if err := json.Unmarshal(b, d); err != nil {
+			return nil, errors.Wrap(err, "unmarshal delegation")
+		}
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+// Registry is the delegations of a contract that haven't expired, by delegator.
+type Registry struct {
+	delegations map[bitcoin.Hash20][]*state.Delegation
+}
+
+// NewRegistry returns a registry of the delegations that haven't expired at now.
+func NewRegistry(delegations []*state.Delegation, now protocol.Timestamp) (*Registry, error) {
+	result := &Registry{
+		delegations: make(map[bitcoin.Hash20][]*state.Delegation),
+	}
+
+	for _, d := range delegations {
+		if d.Expires.Nano() != 0 && d.Expires.Nano() <= now.Nano() {
+			continue
+		}
+
+		hash, err := d.Delegator.Hash()
+		if err != nil {
+			return nil, errors.Wrap(err, "delegator hash")
+		}
+		result.delegations[*hash] = append(result.delegations[*hash], d)
+	}
+
+	return result, nil
+}
+
+// Load returns a registry of the delegations of a contract that haven't expired at now.
+func Load(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	now protocol.Timestamp) (*Registry, error) {
+
+	delegations, err := List(ctx, dbConn, contractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "list")
+	}
+
+	return NewRegistry(delegations, now)
+}
+
+// Proxy returns the proxy of a delegator for votes of an asset, or an empty address when there
+//   isn't one. A delegation for the asset takes precedence over a delegation for all assets.
+//   assetCode is nil for votes that aren't for one asset, which only use delegations for all
+//   assets.
+func (r *Registry) Proxy(delegator bitcoin.Hash20, assetCode *protocol.AssetCode) bitcoin.RawAddress {
+	var result bitcoin.RawAddress
+	for _, d := range r.delegations[delegator] {
+		if d.AssetCode == nil {
+			if result.IsEmpty() {
+				result = d.Proxy
+			}
+			continue
+		}
+
+		if assetCode != nil && d.AssetCode.Equal(*assetCode) {
+			return d.Proxy
+		}
+	}
+
+	return result
+}
+
+func buildStoragePath(contractAddress, delegator bitcoin.RawAddress,
+	assetCode *protocol.AssetCode) (string, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return "", errors.Wrap(err, "contract hash")
+	}
+
+	delegatorHash, err := delegator.Hash()
+	if err != nil {
+		return "", errors.Wrap(err, "delegator hash")
+	}
+
+	scope := allAssets
+	if assetCode != nil {
+		scope = assetCode.String()
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s_%s", storageKey, contractHash.String(), storageSubKey,
+		delegatorHash.String(), scope), nil
+}
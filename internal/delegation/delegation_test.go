@@ -0,0 +1,102 @@
+package delegation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestRegistry(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+	defer dbConn.Clear(ctx, "")
+
+	contractAddress := generateAddress(t)
+	delegator := generateAddress(t)
+	allProxy := generateAddress(t)
+	assetProxy := generateAddress(t)
+	expired := generateAddress(t)
+
+	assetCode := protocol.AssetCodeFromContract(contractAddress, 0)
+	otherCode := protocol.AssetCodeFromContract(contractAddress, 1)
+	now := protocol.NewTimestamp(1000)
+
+	delegations := []*state.Delegation{
+		{Delegator: delegator, Proxy: allProxy},
+		{Delegator: delegator, Proxy: assetProxy, AssetCode: assetCode},
+		{Delegator: expired, Proxy: allProxy, Expires: protocol.NewTimestamp(500)},
+	}
+	for _, d := range delegations {
+		if err := Save(ctx, dbConn, contractAddress, d); err != nil {
+			t.Fatalf("Failed to save delegation : %s", err)
+		}
+	}
+
+	list, err := List(ctx, dbConn, contractAddress)
+	if err != nil {
+		t.Fatalf("Failed to list delegations : %s", err)
+	}
+	if len(list) != len(delegations) {
+		t.Fatalf("Wrong delegation count : got %d, want %d", len(list), len(delegations))
+	}
+
+	registry, err := Load(ctx, dbConn, contractAddress, now)
+	if err != nil {
+		t.Fatalf("Failed to load registry : %s", err)
+	}
+
+	delegatorHash, _ := delegator.Hash()
+	expiredHash, _ := expired.Hash()
+
+	if proxy := registry.Proxy(*delegatorHash, assetCode); !proxy.Equal(assetProxy) {
+		t.Errorf("Asset delegation should take precedence")
+	}
+	if proxy := registry.Proxy(*delegatorHash, otherCode); !proxy.Equal(allProxy) {
+		t.Errorf("Other asset should use delegation for all assets")
+	}
+	if proxy := registry.Proxy(*delegatorHash, nil); !proxy.Equal(allProxy) {
+		t.Errorf("Contract vote should use delegation for all assets")
+	}
+	if proxy := registry.Proxy(*expiredHash, nil); !proxy.IsEmpty() {
+		t.Errorf("Expired delegation should be skipped")
+	}
+
+	if err := Remove(ctx, dbConn, contractAddress, delegator, assetCode); err != nil {
+		t.Fatalf("Failed to remove delegation : %s", err)
+	}
+	if err := Remove(ctx, dbConn, contractAddress, delegator, assetCode); err != ErrNotFound {
+		t.Fatalf("Wrong error removing missing delegation : %v", err)
+	}
+
+	registry, err = Load(ctx, dbConn, contractAddress, now)
+	if err != nil {
+		t.Fatalf("Failed to load registry : %s", err)
+	}
+	if proxy := registry.Proxy(*delegatorHash, assetCode); !proxy.Equal(allProxy) {
+		t.Errorf("Removed asset delegation should fall back to all assets")
+	}
+}
+
+func generateAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return ra
+}
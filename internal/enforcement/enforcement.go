@@ -0,0 +1,316 @@
+package enforcement
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const storageKey = "contracts"
+const storageSubKey = "orders"
+
+const (
+	// StatusPending is an order that was accepted, but whose response hasn't been processed.
+	StatusPending = "pending"
+
+	// StatusApplied is an order whose response has been processed.
+	StatusApplied = "applied"
+
+	// StatusThawed is a freeze order that was reverted by a thaw.
+	StatusThawed = "thawed"
+)
+
+var (
+	// ErrNotFound abstracts the standard not found error.
+	ErrNotFound = errors.New("Order not found")
+)
+
+// NewOrder returns the pending record of an order accepted by the contract.
+func NewOrder(txid *protocol.TxId, order *actions.Order, now protocol.Timestamp) (*state.Order,
+	error) {
+
+	result := &state.Order{
+		TxId:                     txid,
+		ComplianceAction:         order.ComplianceAction,
+		FreezePeriod:             protocol.NewTimestamp(order.FreezePeriod),
+		AuthorityName:            order.AuthorityName,
+		AuthorityPublicKey:       order.AuthorityPublicKey,
+		SupportingEvidenceFormat: order.SupportingEvidenceFormat,
+		Message:                  order.Message,
+		Status:                   StatusPending,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+
+	if len(order.AssetCode) != 0 {
+		result.AssetCode = protocol.AssetCodeFromBytes(order.AssetCode)
+	}
+
+	if len(order.FreezeTxId) != 0 {
+		result.FreezeTxId = protocol.TxIdFromBytes(order.FreezeTxId)
+	}
+
+	if len(order.DepositAddress) != 0 {
+		ra, err := bitcoin.DecodeRawAddress(order.DepositAddress)
+		if err != nil {
+			return nil, errors.Wrap(err, "deposit address")
+		}
+		result.DepositAddress = ra
+	}
+
+	if len(order.SupportingEvidence) != 0 {
+		hash, err := bitcoin.NewHash32(bitcoin.DoubleSha256(order.SupportingEvidence))
+		if err != nil {
+			return nil, errors.Wrap(err, "evidence hash")
+		}
+		result.SupportingEvidenceHash = hash
+	}
+
+	for _, target := range order.TargetAddresses {
+		ra, err := bitcoin.DecodeRawAddress(target.Address)
+		if err != nil {
+			return nil, errors.Wrap(err, "target address")
+		}
+		result.Targets = append(result.Targets, state.OrderTarget{
+			Address:  ra,
+			Quantity: target.Quantity,
+		})
+	}
+
+	return result, nil
+}
+
+// Applied records that a response applied an order.
+func Applied(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	orderTxId, responseTxId *protocol.TxId, now protocol.Timestamp) error {
+	return addResponse(ctx, dbConn, contractAddress, orderTxId, responseTxId, StatusApplied, now)
+}
+
+// Thawed records that a thaw response reverted a freeze order.
+func Thawed(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	freezeOrderTxId, thawTxId *protocol.TxId, now protocol.Timestamp) error {
+	return addResponse(ctx, dbConn, contractAddress, freezeOrderTxId, thawTxId, StatusThawed, now)
+}
+
+// addResponse links a response to an order and updates its status. Reprocessing a response
+//   doesn't link it again.
+func addResponse(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	orderTxId, responseTxId *protocol.TxId, status string, now protocol.Timestamp) error {
+
+	o, err := Fetch(ctx, dbConn, contractAddress, orderTxId)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, txid := range o.ResponseTxIds {
+		if txid.Equal(*responseTxId) {
+			linked = true
+			break
+		}
+	}
+	if !linked {
+		o.ResponseTxIds = append(o.ResponseTxIds, responseTxId)
+	}
+
+	if o.Status != StatusThawed {
+		o.Status = status
+	}
+	o.UpdatedAt = now
+
+	return Save(ctx, dbConn, contractAddress, o)
+}
+
+// Save writes the record of an order.
+func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	o *state.Order) error {
+
+	key, err := buildStoragePath(contractAddress, o.TxId)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return errors.Wrap(err, "marshal order")
+	}
+
+	return dbConn.Put(ctx, key, data)
+}
+
+// Fetch returns the record of the order with the txid.
+func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	txid *protocol.TxId) (*state.Order, error) {
+
+	key, err := buildStoragePath(contractAddress, txid)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := dbConn.Fetch(ctx, key)
+	if err != nil {
+		if errors.Cause(err) == db.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "fetch order")
+	}
+
+	result := &state.Order{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal order")
+	}
+
+	return result, nil
+}
+
+// List returns the records of all orders of a contract, oldest first.
+func List(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) ([]*state.Order, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	data, err := dbConn.Search(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
+		storageSubKey))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*state.Order, 0, len(data))
+	for _, b := range data {
+		o := &state.Order{}
+		if err := json.Unmarshal(b, o); err != nil {
+			return nil, errors.Wrap(err, "unmarshal order")
+		}
+		result = append(result, o)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Nano() < result[j].CreatedAt.Nano()
+	})
+
+	return result, nil
+}
+
+// Filter returns the orders with the status that affect the address. An empty status or address
+//   matches all orders.
+func Filter(orders []*state.Order, status string, address bitcoin.RawAddress) []*state.Order {
+	var result []*state.Order
+	for _, o := range orders {
+		if len(status) != 0 && o.Status != status {
+			continue
+		}
+
+		if !address.IsEmpty() && !affects(o, address) {
+			continue
+		}
+
+		result = append(result, o)
+	}
+	return result
+}
+
+// affects returns true if the address is a target or the deposit address of the order.
+func affects(o *state.Order, address bitcoin.RawAddress) bool {
+	if o.DepositAddress.Equal(address) {
+		return true
+	}
+	for _, target := range o.Targets {
+		if target.Address.Equal(address) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteCSV writes the orders with a row for each target address.
+func WriteCSV(w io.Writer, orders []*state.Order, net bitcoin.Network) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"TxId", "Action", "AssetCode", "Status", "AuthorityName",
+		"SupportingEvidenceHash", "DepositAddress", "Address", "Quantity", "ResponseTxIds",
+		"CreatedAt"}
+	if err := cw.Write(header); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+
+	for _, o := range orders {
+		assetCode := ""
+		if o.AssetCode != nil {
+			assetCode = o.AssetCode.String()
+		}
+
+		evidenceHash := ""
+		if o.SupportingEvidenceHash != nil {
+			evidenceHash = o.SupportingEvidenceHash.String()
+		}
+
+		depositAddress := ""
+		if !o.DepositAddress.IsEmpty() {
+			depositAddress = bitcoin.NewAddressFromRawAddress(o.DepositAddress, net).String()
+		}
+
+		responseTxIds := make([]string, 0, len(o.ResponseTxIds))
+		for _, txid := range o.ResponseTxIds {
+			responseTxIds = append(responseTxIds, txid.String())
+		}
+
+		targets := o.Targets
+		if len(targets) == 0 {
+			targets = []state.OrderTarget{{}} // Still write a row for the order
+		}
+
+		for _, target := range targets {
+			address := ""
+			quantity := ""
+			if !target.Address.IsEmpty() {
+				address = bitcoin.NewAddressFromRawAddress(target.Address, net).String()
+				quantity = strconv.FormatUint(target.Quantity, 10)
+			}
+
+			row := []string{
+				o.TxId.String(),
+				o.ComplianceAction,
+				assetCode,
+				o.Status,
+				o.AuthorityName,
+				evidenceHash,
+				depositAddress,
+				address,
+				quantity,
+				strings.Join(responseTxIds, " "),
+				o.CreatedAt.String(),
+			}
+			if err := cw.Write(row); err != nil {
+				return errors.Wrap(err, "write order")
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func buildStoragePath(contractAddress bitcoin.RawAddress, txid *protocol.TxId) (string, error) {
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return "", errors.Wrap(err, "contract hash")
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", storageKey, contractHash.String(), storageSubKey,
+		txid.String()), nil
+}
@@ -0,0 +1,78 @@
+package enforcement
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestOrders(t *testing.T) {
+	target := generateAddress(t)
+	deposit := generateAddress(t)
+	other := generateAddress(t)
+
+	confiscation := &actions.Order{
+		ComplianceAction: actions.ComplianceActionConfiscation,
+		AssetCode:        protocol.AssetCodeFromContract(generateAddress(t), 0).Bytes(),
+		TargetAddresses: []*actions.TargetAddressField{
+			{Address: target.Bytes(), Quantity: 100},
+		},
+		DepositAddress:     deposit.Bytes(),
+		AuthorityName:      "District Court #345",
+		SupportingEvidence: []byte("Case 2020-345"),
+	}
+
+	o, err := NewOrder(protocol.TxIdFromBytes(make([]byte, 32)), confiscation,
+		protocol.NewTimestamp(1000))
+	if err != nil {
+		t.Fatalf("Failed to create order : %s", err)
+	}
+	if o.Status != StatusPending {
+		t.Errorf("Wrong status : got %s, want %s", o.Status, StatusPending)
+	}
+	if o.SupportingEvidenceHash == nil {
+		t.Errorf("Supporting evidence hash missing")
+	}
+
+	orders := []*state.Order{o}
+	if len(Filter(orders, "", target)) != 1 {
+		t.Errorf("Target address should match")
+	}
+	if len(Filter(orders, "", deposit)) != 1 {
+		t.Errorf("Deposit address should match")
+	}
+	if len(Filter(orders, "", other)) != 0 {
+		t.Errorf("Other address should not match")
+	}
+	if len(Filter(orders, StatusApplied, bitcoin.RawAddress{})) != 0 {
+		t.Errorf("Pending order should not match applied status")
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, orders, bitcoin.MainNet); err != nil {
+		t.Fatalf("Failed to write csv : %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Errorf("Wrong csv line count : got %d, want 2", len(lines))
+	}
+}
+
+func generateAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return ra
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/delegation"
 	"github.com/tokenized/smart-contract/internal/platform/db"
 	"github.com/tokenized/smart-contract/internal/platform/state"
 
@@ -73,7 +74,9 @@ func VotingBalance(as *state.Asset, h *state.Holding, applyMultiplier bool,
 	return h.FinalizedBalance
 }
 
-// AppendBallots adds ballot quantities to the ballot map.
+// AppendBallots adds ballot quantities to the ballot map. Ballots are assigned the proxy the
+//   holder delegated votes of the asset to. When a holder's ballot includes assets delegated to
+//   different proxies, only a delegation for all assets applies.
 func AppendBallots(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
 	as *state.Asset, ballots *map[bitcoin.Hash20]state.Ballot, applyMultiplier bool,
 	now protocol.Timestamp) error {
@@ -87,6 +90,11 @@ func AppendBallots(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.R
 		return errors.Wrap(err, "fetch all holdings")
 	}
 
+	delegations, err := delegation.Load(ctx, dbConn, contractAddress, now)
+	if err != nil {
+		return errors.Wrap(err, "load delegations")
+	}
+
 	for _, h := range holdings {
 		hash, err := h.Address.Hash()
 		if err != nil {
@@ -98,15 +106,21 @@ func AppendBallots(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.R
 			quantity *= uint64(as.VoteMultiplier)
 		}
 
+		proxy := delegations.Proxy(*hash, as.Code)
+
 		_, exists := (*ballots)[*hash]
 		if exists {
 			ballot := (*ballots)[*hash]
 			ballot.Quantity += quantity
+			if !ballot.Proxy.Equal(proxy) {
+				ballot.Proxy = delegations.Proxy(*hash, nil)
+			}
 			(*ballots)[*hash] = ballot
 		} else {
 			(*ballots)[*hash] = state.Ballot{
 				Address:  h.Address,
 				Quantity: quantity,
+				Proxy:    proxy,
 			}
 		}
 	}
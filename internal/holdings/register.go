@@ -0,0 +1,233 @@
+package holdings
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const registerSubKey = "registers"
+
+var (
+	// ErrSnapshotNotFound occurs when there is no register snapshot at or before a time.
+	ErrSnapshotNotFound = errors.New("Register snapshot not found")
+)
+
+// Register is the holder register (cap table) for an asset at a point in time.
+type Register struct {
+	ContractAddress bitcoin.RawAddress  `json:"ContractAddress,omitempty"`
+	AssetType       string              `json:"AssetType,omitempty"`
+	AssetCode       *protocol.AssetCode `json:"AssetCode,omitempty"`
+	TokenQty        uint64              `json:"TokenQty,omitempty"`
+	Timestamp       protocol.Timestamp  `json:"Timestamp,omitempty"`
+	Entries         []*RegisterEntry    `json:"Entries,omitempty"`
+}
+
+// RegisterEntry is one holder's line in a register.
+type RegisterEntry struct {
+	Address          bitcoin.RawAddress `json:"Address,omitempty"`
+	EntityName       string             `json:"EntityName,omitempty"`
+	FinalizedBalance uint64             `json:"FinalizedBalance,omitempty"`
+	PendingBalance   uint64             `json:"PendingBalance,omitempty"`
+	FrozenAmount     uint64             `json:"FrozenAmount,omitempty"`
+	Percentage       float64            `json:"Percentage,omitempty"`
+}
+
+// EntityNameLookup provides the name of the entity that owns an address, for example from an
+//   identity oracle. An empty name is returned for unknown addresses.
+type EntityNameLookup interface {
+	EntityName(ctx context.Context, address bitcoin.RawAddress) (string, error)
+}
+
+// BuildRegister builds the current holder register for an asset.
+// Holders with no finalized or pending balance are not included.
+func BuildRegister(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	as *state.Asset, now protocol.Timestamp) (*Register, error) {
+
+	hs, err := FetchAll(ctx, dbConn, contractAddress, as.Code)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch all holdings")
+	}
+
+	result := &Register{
+		ContractAddress: contractAddress,
+		AssetType:       as.AssetType,
+		AssetCode:       as.Code,
+		TokenQty:        as.TokenQty,
+		Timestamp:       now,
+		Entries:         make([]*RegisterEntry, 0, len(hs)),
+	}
+
+	for _, h := range hs {
+		if h.FinalizedBalance == 0 && h.PendingBalance == 0 {
+			continue
+		}
+
+		entry := &RegisterEntry{
+			Address:          h.Address,
+			FinalizedBalance: h.FinalizedBalance,
+			PendingBalance:   h.PendingBalance,
+			FrozenAmount:     FrozenBalance(h, now),
+		}
+
+		if as.TokenQty > 0 {
+			entry.Percentage = float64(h.FinalizedBalance) * 100.0 / float64(as.TokenQty)
+		}
+
+		result.Entries = append(result.Entries, entry)
+	}
+
+	result.Sort()
+	return result, nil
+}
+
+// FrozenBalance returns the quantity currently frozen by enforcement orders.
+func FrozenBalance(h *state.Holding, now protocol.Timestamp) uint64 {
+	result := uint64(0)
+	for _, status := range h.HoldingStatuses {
+		if status.Code != FreezeCode {
+			continue
+		}
+		if statusExpired(status, now) {
+			continue
+		}
+		result += status.Amount
+	}
+	return result
+}
+
+// Sort orders register entries by descending finalized balance, then by address so the output is
+//   deterministic.
+func (r *Register) Sort() {
+	sort.SliceStable(r.Entries, func(i, j int) bool {
+		if r.Entries[i].FinalizedBalance != r.Entries[j].FinalizedBalance {
+			return r.Entries[i].FinalizedBalance > r.Entries[j].FinalizedBalance
+		}
+		return bytes.Compare(r.Entries[i].Address.Bytes(), r.Entries[j].Address.Bytes()) < 0
+	})
+}
+
+// ApplyEntityNames fills in the entity names of register entries.
+func (r *Register) ApplyEntityNames(ctx context.Context, lookup EntityNameLookup) error {
+	for _, entry := range r.Entries {
+		name, err := lookup.EntityName(ctx, entry.Address)
+		if err != nil {
+			return errors.Wrap(err, "entity name")
+		}
+		entry.EntityName = name
+	}
+	return nil
+}
+
+// WriteCSV writes the register as CSV with a header row.
+func (r *Register) WriteCSV(w io.Writer, net bitcoin.Network) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"Address", "EntityName", "FinalizedBalance", "PendingBalance",
+		"FrozenAmount", "Percentage"}); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+
+	for _, entry := range r.Entries {
+		if err := cw.Write([]string{
+			bitcoin.NewAddressFromRawAddress(entry.Address, net).String(),
+			entry.EntityName,
+			strconv.FormatUint(entry.FinalizedBalance, 10),
+			strconv.FormatUint(entry.PendingBalance, 10),
+			strconv.FormatUint(entry.FrozenAmount, 10),
+			strconv.FormatFloat(entry.Percentage, 'f', 4, 64),
+		}); err != nil {
+			return errors.Wrap(err, "write entry")
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// SaveRegisterSnapshot saves a register so it can be retrieved later as of its timestamp.
+func SaveRegisterSnapshot(ctx context.Context, dbConn *db.DB, r *Register) error {
+	contractHash, err := r.ContractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "marshal register")
+	}
+
+	return dbConn.Put(ctx, buildRegisterStoragePath(contractHash, r.AssetCode, r.Timestamp), b)
+}
+
+// FetchRegisterSnapshot returns the latest register snapshot taken at or before the specified
+//   time.
+func FetchRegisterSnapshot(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	assetCode *protocol.AssetCode, at protocol.Timestamp) (*Register, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	path := fmt.Sprintf("%s/%s/%s/%s", storageKey, contractHash.String(), registerSubKey,
+		assetCode.String())
+	keys, err := dbConn.List(ctx, path)
+	if err != nil {
+		return nil, errors.Wrap(err, "list snapshots")
+	}
+
+	found := false
+	var latest uint64
+	for _, key := range keys {
+		ts, err := strconv.ParseUint(key[strings.LastIndex(key, "/")+1:], 10, 64)
+		if err != nil {
+			continue // not a snapshot
+		}
+		if ts > at.Nano() {
+			continue
+		}
+		if !found || ts > latest {
+			found = true
+			latest = ts
+		}
+	}
+
+	if !found {
+		return nil, ErrSnapshotNotFound
+	}
+
+	b, err := dbConn.Fetch(ctx, buildRegisterStoragePath(contractHash, assetCode,
+		protocol.NewTimestamp(latest)))
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch snapshot")
+	}
+
+	result := &Register{}
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal register")
+	}
+
+	return result, nil
+}
+
+// buildRegisterStoragePath returns the storage path for a register snapshot. The timestamp is
+//   zero padded so keys sort chronologically.
+func buildRegisterStoragePath(contractHash *bitcoin.Hash20, assetCode *protocol.AssetCode,
+	timestamp protocol.Timestamp) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%020d", storageKey, contractHash.String(), registerSubKey,
+		assetCode.String(), timestamp.Nano())
+}
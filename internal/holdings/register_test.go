@@ -0,0 +1,108 @@
+package holdings
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestRegisterSnapshots(t *testing.T) {
+	ctx := context.Background()
+	dbConn, err := db.New(&db.StorageConfig{
+		Bucket: "standalone",
+		Root:   "./tmp",
+	})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+	defer dbConn.Clear(ctx, "")
+
+	contractKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contractAddress, err := contractKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	assetCode := protocol.AssetCodeFromContract(contractAddress, 0)
+	as := &state.Asset{
+		Code:      assetCode,
+		AssetType: "SHC",
+		TokenQty:  1000,
+	}
+
+	now := protocol.CurrentTimestamp()
+	holders := []uint64{600, 400}
+	for _, balance := range holders {
+		key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+		if err != nil {
+			t.Fatalf("Failed to generate key : %s", err)
+		}
+		ra, err := key.RawAddress()
+		if err != nil {
+			t.Fatalf("Failed to create address : %s", err)
+		}
+
+		h, err := GetHolding(ctx, dbConn, contractAddress, assetCode, ra, now)
+		if err != nil {
+			t.Fatalf("Failed to get holding : %s", err)
+		}
+		h.FinalizedBalance = balance
+		h.PendingBalance = balance
+
+		if balance == 400 {
+			txid := protocol.TxIdFromBytes(make([]byte, 32))
+			if err := AddFreeze(h, txid, 100, protocol.NewTimestamp(0), now); err != nil {
+				t.Fatalf("Failed to add freeze : %s", err)
+			}
+		}
+
+		ci, err := Save(ctx, dbConn, contractAddress, assetCode, h)
+		if err != nil {
+			t.Fatalf("Failed to save holding : %s", err)
+		}
+		if err := ci.Write(ctx, dbConn); err != nil {
+			t.Fatalf("Failed to write holding : %s", err)
+		}
+	}
+
+	r, err := BuildRegister(ctx, dbConn, contractAddress, as, now)
+	if err != nil {
+		t.Fatalf("Failed to build register : %s", err)
+	}
+
+	if len(r.Entries) != 2 {
+		t.Fatalf("Wrong entry count : got %d, want %d", len(r.Entries), 2)
+	}
+	if r.Entries[0].FinalizedBalance != 600 || r.Entries[0].Percentage != 60.0 {
+		t.Errorf("Wrong first entry : %d %f", r.Entries[0].FinalizedBalance,
+			r.Entries[0].Percentage)
+	}
+	if r.Entries[1].FrozenAmount != 100 {
+		t.Errorf("Wrong frozen amount : got %d, want %d", r.Entries[1].FrozenAmount, 100)
+	}
+
+	if err := SaveRegisterSnapshot(ctx, dbConn, r); err != nil {
+		t.Fatalf("Failed to save snapshot : %s", err)
+	}
+
+	if _, err := FetchRegisterSnapshot(ctx, dbConn, contractAddress, assetCode,
+		protocol.NewTimestamp(now.Nano()-1)); err != ErrSnapshotNotFound {
+		t.Errorf("Snapshot found before it was taken : %v", err)
+	}
+
+	fetched, err := FetchRegisterSnapshot(ctx, dbConn, contractAddress, assetCode,
+		protocol.NewTimestamp(now.Nano()+1))
+	if err != nil {
+		t.Fatalf("Failed to fetch snapshot : %s", err)
+	}
+	if len(fetched.Entries) != 2 || fetched.Entries[1].FrozenAmount != 100 {
+		t.Errorf("Wrong snapshot entries")
+	}
+}
@@ -0,0 +1,207 @@
+package notice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/holdings"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const storageKey = "contracts"
+const storageSubKey = "notices"
+
+// MessageCode identifies a message payload that requests a notice to holders. Notices aren't in
+//   the protocol specification, so the code is outside of the ranges it uses.
+const MessageCode = uint32(9002)
+
+// DefaultBatchSize is the number of holders sent a notice in each tx when the node doesn't
+//   specify one.
+const DefaultBatchSize = 100
+
+var (
+	// ErrNotFound abstracts the standard not found error.
+	ErrNotFound = errors.New("Notice not found")
+)
+
+// Message is the payload of a message from the administration to their contract that requests a
+//   notice to holders. It is encoded as JSON.
+type Message struct {
+	// AssetCode limits the notice to holders of one asset. Empty is holders of all assets of the
+	//   contract.
+	AssetCode *protocol.AssetCode `json:"AssetCode,omitempty"`
+
+	// Payload is the serialized PublicMessage sent to each holder.
+	Payload []byte `json:"Payload,omitempty"`
+}
+
+// Bytes returns the encoded message.
+func (m *Message) Bytes() ([]byte, error) {
+	return json.Marshal(m)
+}
+
+// DeserializeMessage decodes a message payload.
+func DeserializeMessage(b []byte) (*Message, error) {
+	result := &Message{}
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal message")
+	}
+	return result, nil
+}
+
+// Hash returns the hash that identifies a notice. Notices with the same assets and payload have
+//   the same hash.
+func Hash(assetCode *protocol.AssetCode, payload []byte) bitcoin.Hash32 {
+	var buf bytes.Buffer
+	if assetCode != nil {
+		buf.WriteByte(1)
+		buf.Write(assetCode.Bytes())
+	} else {
+		buf.WriteByte(0) // All assets
+	}
+	buf.Write(payload)
+
+	var result bitcoin.Hash32
+	copy(result[:], bitcoin.DoubleSha256(buf.Bytes()))
+	return result
+}
+
+// Recipients returns the addresses with a balance of the assets, without duplicates, in the
+//   order of their bytes. assetCodes are the assets of the notice.
+func Recipients(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	assetCodes []*protocol.AssetCode) ([]bitcoin.RawAddress, error) {
+
+	found := make(map[bitcoin.Hash20]bitcoin.RawAddress)
+	for _, assetCode := range assetCodes {
+		hs, err := holdings.FetchAll(ctx, dbConn, contractAddress, assetCode)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch holdings")
+		}
+
+		for _, h := range hs {
+			if h.FinalizedBalance == 0 && h.PendingBalance == 0 {
+				continue
+			}
+
+			hash, err := h.Address.Hash()
+			if err != nil {
+				return nil, errors.Wrap(err, "address hash")
+			}
+			found[*hash] = h.Address
+		}
+	}
+
+	result := make([]bitcoin.RawAddress, 0, len(found))
+	for _, address := range found {
+		result = append(result, address)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Bytes(), result[j].Bytes()) < 0
+	})
+
+	return result, nil
+}
+
+// Batches splits recipients into batches of at most size.
+func Batches(recipients []bitcoin.RawAddress, size int) [][]bitcoin.RawAddress {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	var result [][]bitcoin.RawAddress
+	for start := 0; start < len(recipients); start += size {
+		end := start + size
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+		result = append(result, recipients[start:end])
+	}
+	return result
+}
+
+// Save writes the delivery record of a notice.
+func Save(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	n *state.Notice) error {
+
+	key, err := buildStoragePath(contractAddress, n.Hash)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(n)
+	if err != nil {
+		return errors.Wrap(err, "marshal notice")
+	}
+
+	return dbConn.Put(ctx, key, data)
+}
+
+// Fetch returns the delivery record of the notice with the hash.
+func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	hash bitcoin.Hash32) (*state.Notice, error) {
+
+	key, err := buildStoragePath(contractAddress, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := dbConn.Fetch(ctx, key)
+	if err != nil {
+		if errors.Cause(err) == db.ErrNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, errors.Wrap(err, "fetch notice")
+	}
+
+	result := &state.Notice{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal notice")
+	}
+
+	return result, nil
+}
+
+// List returns the delivery records of all notices of a contract.
+func List(ctx context.Context, dbConn *db.DB,
+	contractAddress bitcoin.RawAddress) ([]*state.Notice, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	data, err := dbConn.Search(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
+		storageSubKey))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*state.Notice, 0, len(data))
+	for _, b := range data {
+		n := &state.Notice{}
+		if err := json.Unmarshal(b, n); err != nil {
+			return nil, errors.Wrap(err, "unmarshal notice")
+		}
+		result = append(result, n)
+	}
+
+	return result, nil
+}
+
+func buildStoragePath(contractAddress bitcoin.RawAddress, hash bitcoin.Hash32) (string, error) {
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return "", errors.Wrap(err, "contract hash")
+	}
+
+	return fmt.Sprintf("%s/%s/%s/%s", storageKey, contractHash.String(), storageSubKey,
+		hash.String()), nil
+}
@@ -0,0 +1,54 @@
+package notice
+
+import (
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestBatches(t *testing.T) {
+	recipients := make([]bitcoin.RawAddress, 5)
+	for i := range recipients {
+		key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+		if err != nil {
+			t.Fatalf("Failed to generate key : %s", err)
+		}
+		recipients[i], err = key.RawAddress()
+		if err != nil {
+			t.Fatalf("Failed to create address : %s", err)
+		}
+	}
+
+	batches := Batches(recipients, 2)
+	if len(batches) != 3 {
+		t.Fatalf("Wrong batch count : got %d, want 3", len(batches))
+	}
+	if len(batches[2]) != 1 {
+		t.Errorf("Wrong last batch size : got %d, want 1", len(batches[2]))
+	}
+
+	if batches := Batches(recipients, 0); len(batches) != 1 {
+		t.Errorf("Default batch size should fit all recipients : got %d batches", len(batches))
+	}
+}
+
+func TestHash(t *testing.T) {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contractAddress, _ := key.RawAddress()
+	assetCode := protocol.AssetCodeFromContract(contractAddress, 0)
+	payload := []byte("notice")
+
+	if Hash(nil, payload) != Hash(nil, payload) {
+		t.Errorf("Same notice should have the same hash")
+	}
+	if Hash(nil, payload) == Hash(assetCode, payload) {
+		t.Errorf("Notice to one asset should have a different hash")
+	}
+	if Hash(nil, payload) == Hash(nil, []byte("other")) {
+		t.Errorf("Different payload should have a different hash")
+	}
+}
@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/tokenized/smart-contract/internal/platform/node"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// TypeLowBalance is sent when a contract address balance drops below its threshold.
+	TypeLowBalance = "low_balance"
+
+	// TypeBalanceRecovered is sent when a contract address balance is back above its threshold.
+	TypeBalanceRecovered = "balance_recovered"
+)
+
+// Alert is an operator notification. It is logged and posted as JSON to the webhook.
+type Alert struct {
+	Type      string `json:"type"`
+	Address   string `json:"address"`
+	Balance   uint64 `json:"balance"`
+	Threshold uint64 `json:"threshold"`
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"` // Unix seconds
+}
+
+// Notifier sends alerts to the log and an optional webhook.
+type Notifier struct {
+	WebhookURL string
+}
+
+// NewNotifier returns a notifier that posts to the webhook URL. Alerts are only logged when the
+//   URL is empty.
+func NewNotifier(webhookURL string) *Notifier {
+	return &Notifier{WebhookURL: webhookURL}
+}
+
+// Notify logs the alert and posts it to the webhook.
+func (n *Notifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.Timestamp == 0 {
+		alert.Timestamp = time.Now().Unix()
+	}
+
+	if alert.Type == TypeBalanceRecovered {
+		node.Log(ctx, "Alert %s : %s", alert.Type, alert.Message)
+	} else {
+		node.LogWarn(ctx, "Alert %s : %s", alert.Type, alert.Message)
+	}
+
+	if n == nil || len(n.WebhookURL) == 0 {
+		return nil
+	}
+
+	if err := post(n.WebhookURL, alert); err != nil {
+		return errors.Wrap(err, "post webhook")
+	}
+
+	return nil
+}
+
+func post(url string, request interface{}) error {
+	var transport = &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout: 5 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+
+	var client = &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: transport,
+	}
+
+	b, err := json.Marshal(request)
+	if err != nil {
+		return errors.Wrap(err, "marshal request")
+	}
+
+	httpResponse, err := client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+		return fmt.Errorf("%v %s", httpResponse.StatusCode, httpResponse.Status)
+	}
+
+	return nil
+}
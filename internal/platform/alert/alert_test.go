@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNotify(t *testing.T) {
+	ctx := context.Background()
+
+	received := make(chan Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- a
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(server.URL)
+	sent := Alert{
+		Type:      TypeLowBalance,
+		Address:   "1Contract",
+		Balance:   500,
+		Threshold: 1000,
+		Message:   "Low",
+	}
+	if err := notifier.Notify(ctx, sent); err != nil {
+		t.Fatalf("Failed to notify : %s", err)
+	}
+
+	a := <-received
+	if a.Type != sent.Type || a.Address != sent.Address || a.Balance != sent.Balance ||
+		a.Threshold != sent.Threshold || a.Timestamp == 0 {
+		t.Fatalf("Wrong alert received : %+v", a)
+	}
+
+	// Log only
+	if err := NewNotifier("").Notify(ctx, sent); err != nil {
+		t.Fatalf("Failed to notify without webhook : %s", err)
+	}
+}
@@ -1,23 +1,82 @@
 package config
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
 	"github.com/kelseyhightower/envconfig"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// envPrefix is the prefix of environment variables. Within a group the prefix also includes
+	//   the group name, like NODE_CONTRACT_FEE_RATE. Variables are also found without it.
+	envPrefix = "NODE"
+
+	// secretFileSuffix is appended to the key of a secret to specify a file containing it.
+	secretFileSuffix = "_FILE"
 )
 
 // Config is used to hold all runtime configuration.
+//
+// Values come from environment variables, then the optional config file named by CONFIG_FILE,
+//   then defaults. Fields tagged secret can also be read from the file named by the key with a
+//   _FILE suffix, like PRIV_KEY_FILE. Fields tagged reload can be changed while running.
 type Config struct {
+	File string `envconfig:"CONFIG_FILE"`
+
 	Contract struct {
-		PrivateKey   string  `envconfig:"PRIV_KEY"`
+		PrivateKey   string  `envconfig:"PRIV_KEY" secret:"true"`
 		OperatorName string  `envconfig:"OPERATOR_NAME"`
 		Version      string  `envconfig:"VERSION"`
 		FeeAddress   string  `envconfig:"FEE_ADDRESS"`
-		FeeRate      float32 `default:"1.0" envconfig:"FEE_RATE"`
-		DustFeeRate  float32 `default:"1.0" envconfig:"DUST_FEE_RATE"`
+		FeeRate      float32 `default:"1.0" envconfig:"FEE_RATE" reload:"true"`
+		DustFeeRate  float32 `default:"1.0" envconfig:"DUST_FEE_RATE" reload:"true"`
 
 		RequestTimeout    uint64  `default:"60000000000" envconfig:"REQUEST_TIMEOUT"` // Default 1 minute
 		PreprocessThreads int     `default:"4" envconfig:"PREPROCESS_THREADS"`
+		NoticeBatchSize   int     `default:"100" envconfig:"NOTICE_BATCH_SIZE"`
 		IsTest            bool    `default:"true" envconfig:"IS_TEST"`
-		MinFeeRate        float32 `default:"0.5" envconfig:"MIN_FEE_RATE"`
+		MinFeeRate        float32 `default:"0.5" envconfig:"MIN_FEE_RATE" reload:"true"`
+
+		// Fee rate source is static, rpc (node estimates), or schedule. The schedule is
+		//   "HH:MM=rate,..." in UTC. Estimates are bounded by MIN_FEE_RATE and MAX_FEE_RATE.
+		//   Overrides are "action code:rate,..." like "T2:1.5". Frequency is in seconds.
+		FeeRateSource        string             `default:"static" envconfig:"FEE_RATE_SOURCE"`
+		FeeRateSchedule      string             `envconfig:"FEE_RATE_SCHEDULE" reload:"true"`
+		FeeRateOverrides     map[string]float32 `envconfig:"FEE_RATE_OVERRIDES" reload:"true"`
+		MaxFeeRate           float32            `default:"0" envconfig:"MAX_FEE_RATE" reload:"true"`
+		FeeEstimateBlocks    int                `default:"1" envconfig:"FEE_ESTIMATE_BLOCKS"`
+		FeeEstimateFrequency int                `default:"300" envconfig:"FEE_ESTIMATE_FREQUENCY"`
+
+		// Selection of UTXOs to fund responses. Strategy is fifo, largest, bnb, or minchange.
+		UTXOStrategy         string `default:"fifo" envconfig:"UTXO_STRATEGY"`
+		UTXOMinConfirmations int    `default:"0" envconfig:"UTXO_MIN_CONFIRMATIONS"`
+
+		// Periodic consolidation of small contract UTXOs and low balance alerts. Frequency is in
+		//   seconds. A zero consolidation threshold disables consolidation. Low balance thresholds
+		//   are "address:satoshis,..." and override the default threshold for those contracts.
+		UTXOMaintenanceFrequency int               `default:"600" envconfig:"UTXO_MAINTENANCE_FREQUENCY"`
+		ConsolidationThreshold   uint64            `default:"1000" envconfig:"CONSOLIDATION_THRESHOLD" reload:"true"`
+		ConsolidationMinCount    int               `default:"10" envconfig:"CONSOLIDATION_MIN_COUNT" reload:"true"`
+		ConsolidationMaxInputs   int               `default:"100" envconfig:"CONSOLIDATION_MAX_INPUTS" reload:"true"`
+		ConsolidationMaxFeeRate  float32           `default:"1.0" envconfig:"CONSOLIDATION_MAX_FEE_RATE" reload:"true"`
+		LowBalanceThreshold      uint64            `default:"0" envconfig:"LOW_BALANCE_THRESHOLD" reload:"true"`
+		LowBalanceThresholds     map[string]uint64 `envconfig:"LOW_BALANCE_THRESHOLDS" reload:"true"`
+		AlertWebhookURL          string            `envconfig:"ALERT_WEBHOOK_URL" secret:"true"`
+
+		// Encryption of the stored wallet. Set one of these.
+		WalletPassphrase string `envconfig:"WALLET_PASSPHRASE" secret:"true"`
+		WalletKeyFile    string `envconfig:"WALLET_KEY_FILE"`
+
+		// Address of a remote signer holding the contract keys, like "unix:/run/signer.sock" or
+		//   "127.0.0.1:8500". When set PRIV_KEY isn't used.
+		SignerAddress string `envconfig:"SIGNER_ADDRESS"`
 	}
 	Bitcoin struct {
 		Network string `default:"mainnet" envconfig:"BITCOIN_CHAIN"`
@@ -35,14 +94,14 @@ type Config struct {
 	RpcNode struct {
 		Host       string `envconfig:"RPC_HOST"`
 		Username   string `envconfig:"RPC_USERNAME"`
-		Password   string `envconfig:"RPC_PASSWORD"`
+		Password   string `envconfig:"RPC_PASSWORD" secret:"true"`
 		MaxRetries int    `default:"10" envconfig:"RPC_MAX_RETRIES"`
 		RetryDelay int    `default:"2000" envconfig:"RPC_RETRY_DELAY"`
 	}
 	AWS struct {
 		Region          string `default:"ap-southeast-2" envconfig:"AWS_REGION" json:"AWS_REGION"`
-		AccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID" json:"AWS_ACCESS_KEY_ID"`
-		SecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" json:"AWS_SECRET_ACCESS_KEY"`
+		AccessKeyID     string `envconfig:"AWS_ACCESS_KEY_ID" json:"AWS_ACCESS_KEY_ID" secret:"true"`
+		SecretAccessKey string `envconfig:"AWS_SECRET_ACCESS_KEY" json:"AWS_SECRET_ACCESS_KEY" secret:"true"`
 		MaxRetries      int    `default:"10" envconfig:"AWS_MAX_RETRIES"`
 		RetryDelay      int    `default:"2000" envconfig:"AWS_RETRY_DELAY"`
 	}
@@ -54,24 +113,28 @@ type Config struct {
 		Bucket string `default:"standalone" envconfig:"CONTRACT_STORAGE_BUCKET"`
 		Root   string `default:"./tmp" envconfig:"CONTRACT_STORAGE_ROOT"`
 	}
+
+	// Contracts are per contract overrides from the config file, keyed by contract address.
+	Contracts map[string]ContractConfig `ignored:"true" reload:"true"`
 }
 
-// SafeConfig masks sensitive config values
+// ContractConfig overrides settings for a specific contract. Zero values aren't overridden.
+type ContractConfig struct {
+	FeeRate        float32 `envconfig:"FEE_RATE" json:",omitempty"`
+	MinFeeRate     float32 `envconfig:"MIN_FEE_RATE" json:",omitempty"`
+	RequestTimeout uint64  `envconfig:"REQUEST_TIMEOUT" json:",omitempty"` // Nanoseconds
+}
+
+// SafeConfig masks sensitive config values. Every field tagged secret is masked.
 func SafeConfig(cfg Config) *Config {
 	cfgSafe := cfg
 
-	if len(cfgSafe.Contract.PrivateKey) > 0 {
-		cfgSafe.Contract.PrivateKey = "*** Masked ***"
-	}
-	if len(cfgSafe.RpcNode.Password) > 0 {
-		cfgSafe.RpcNode.Password = "*** Masked ***"
-	}
-	if len(cfgSafe.AWS.AccessKeyID) > 0 {
-		cfgSafe.AWS.AccessKeyID = "*** Masked ***"
-	}
-	if len(cfgSafe.AWS.SecretAccessKey) > 0 {
-		cfgSafe.AWS.SecretAccessKey = "*** Masked ***"
-	}
+	walkFields(reflect.ValueOf(&cfgSafe).Elem(), envPrefix, func(info fieldInfo) error {
+		if info.tag.Get("secret") == "true" && info.field.String() != "" {
+			info.field.SetString("*** Masked ***")
+		}
+		return nil
+	})
 
 	return &cfgSafe
 }
@@ -80,9 +143,265 @@ func SafeConfig(cfg Config) *Config {
 func Environment() (*Config, error) {
 	var cfg Config
 
-	if err := envconfig.Process("NODE", &cfg); err != nil {
+	if err := envconfig.Process(envPrefix, &cfg); err != nil {
 		return nil, err
 	}
 
 	return &cfg, nil
 }
+
+// Load returns configuration sourced from environment variables and the config file, with
+//   secrets read from files.
+func Load() (*Config, error) {
+	cfg, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+
+	var file *File
+	if len(cfg.File) > 0 {
+		file, err = ReadFile(cfg.File)
+		if err != nil {
+			return nil, errors.Wrap(err, "read config file")
+		}
+
+		if err := cfg.applyFile(file); err != nil {
+			return nil, errors.Wrap(err, "apply config file")
+		}
+	}
+
+	if err := cfg.readSecrets(file); err != nil {
+		return nil, errors.Wrap(err, "read secrets")
+	}
+
+	return cfg, nil
+}
+
+// RestartRequired returns true when values that can't be changed while running differ.
+func RestartRequired(previous, current *Config) bool {
+	previousFixed := *previous
+	currentFixed := *current
+	clearReloadable(reflect.ValueOf(&previousFixed).Elem())
+	clearReloadable(reflect.ValueOf(&currentFixed).Elem())
+	return !reflect.DeepEqual(previousFixed, currentFixed)
+}
+
+// applyFile sets the values from the config file that weren't set by environment variables.
+func (cfg *Config) applyFile(file *File) error {
+	known := map[string]bool{}
+	err := walkFields(reflect.ValueOf(cfg).Elem(), envPrefix, func(info fieldInfo) error {
+		known[info.key] = true
+		if info.tag.Get("secret") == "true" {
+			known[info.key+secretFileSuffix] = true
+		}
+
+		value, exists := file.Values[info.key]
+		if !exists || info.key == "CONFIG_FILE" || info.isEnvSet() {
+			return nil
+		}
+		return errors.Wrap(setField(info.field, value), info.key)
+	})
+	if err != nil {
+		return err
+	}
+
+	for key := range file.Values {
+		if !known[key] {
+			return fmt.Errorf("Unknown key : %s", key)
+		}
+	}
+
+	cfg.Contracts = make(map[string]ContractConfig, len(file.Contracts))
+	for address, values := range file.Contracts {
+		var contract ContractConfig
+		applied := 0
+		err := walkFields(reflect.ValueOf(&contract).Elem(), "", func(info fieldInfo) error {
+			value, exists := values[info.key]
+			if !exists {
+				return nil
+			}
+			applied++
+			return errors.Wrap(setField(info.field, value), info.key)
+		})
+		if err != nil {
+			return errors.Wrap(err, address)
+		}
+		if applied != len(values) {
+			return fmt.Errorf("Unknown key in contract : %s", address)
+		}
+
+		cfg.Contracts[address] = contract
+	}
+
+	return nil
+}
+
+// readSecrets sets empty secrets from the files named by the environment variables or config file
+//   values with the _FILE suffix.
+func (cfg *Config) readSecrets(file *File) error {
+	return walkFields(reflect.ValueOf(cfg).Elem(), envPrefix, func(info fieldInfo) error {
+		if info.tag.Get("secret") != "true" || info.field.String() != "" {
+			return nil
+		}
+
+		fileKey := info.key + secretFileSuffix
+		path, exists := os.LookupEnv(info.envKey + secretFileSuffix)
+		if !exists {
+			path, exists = os.LookupEnv(fileKey)
+		}
+		if !exists && file != nil {
+			path, exists = file.Values[fileKey]
+		}
+		if !exists || len(path) == 0 {
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, fileKey)
+		}
+		info.field.SetString(strings.TrimSpace(string(b)))
+		return nil
+	})
+}
+
+// fieldInfo describes a config field.
+type fieldInfo struct {
+	key    string // Key without prefix, like FEE_RATE
+	envKey string // Key with prefix, like NODE_CONTRACT_FEE_RATE
+	field  reflect.Value
+	tag    reflect.StructTag
+}
+
+// isEnvSet returns true if an environment variable sets the field.
+func (info fieldInfo) isEnvSet() bool {
+	if _, exists := os.LookupEnv(info.envKey); exists {
+		return true
+	}
+	_, exists := os.LookupEnv(info.key)
+	return exists
+}
+
+// walkFields calls the function for each field with an envconfig key, including the fields of
+//   nested structs. The prefix is built the same way envconfig builds it.
+func walkFields(v reflect.Value, prefix string, fn func(info fieldInfo) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag
+		if tag.Get("ignored") == "true" {
+			continue
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			innerPrefix := strings.ToUpper(t.Field(i).Name)
+			if len(prefix) > 0 {
+				innerPrefix = prefix + "_" + innerPrefix
+			}
+			if err := walkFields(field, innerPrefix, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := strings.ToUpper(tag.Get("envconfig"))
+		if len(key) == 0 {
+			continue
+		}
+
+		envKey := key
+		if len(prefix) > 0 {
+			envKey = prefix + "_" + key
+		}
+
+		if err := fn(fieldInfo{key: key, envKey: envKey, field: field, tag: tag}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearReloadable zeroes the fields tagged reload, including ignored fields.
+func clearReloadable(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if t.Field(i).Tag.Get("reload") == "true" {
+			field.Set(reflect.Zero(field.Type()))
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			clearReloadable(field)
+		}
+	}
+}
+
+// setField sets a field from text in the format used by environment variables.
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(strings.Replace(value, "_", "", -1), 0,
+			field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(strings.Replace(value, "_", "", -1), 0,
+			field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.Replace(value, "_", "", -1),
+			field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("Unsupported map key type : %s", field.Type().Key())
+		}
+
+		m := reflect.MakeMap(field.Type())
+		for _, item := range strings.Split(value, ",") {
+			if len(strings.TrimSpace(item)) == 0 {
+				continue
+			}
+
+			colon := strings.LastIndex(item, ":")
+			if colon == -1 {
+				return fmt.Errorf("Invalid map item : %s", item)
+			}
+
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setField(element, strings.TrimSpace(item[colon+1:])); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(item[:colon])), element)
+		}
+		field.Set(m)
+
+	default:
+		return fmt.Errorf("Unsupported type : %s", field.Type())
+	}
+
+	return nil
+}
+
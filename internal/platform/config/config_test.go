@@ -0,0 +1,217 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testFile = `
+# Contract settings
+operator_name = "Test Operator # 1"
+fee_rate = 0.75
+MIN_FEE_RATE = 0.25
+request_timeout = 120_000_000_000
+fee_rate_overrides = { T2 = 1.5, "M2" = 1.0 }
+low_balance_thresholds = "1ContractAddress:10000"
+dust_fee_rate = 2.0
+is_test = false
+
+[spynode]
+node_address = '10.0.0.1:8333' # trailing comment
+
+[contracts."1ContractAddress"]
+fee_rate = 0.5
+request_timeout = 30000000000
+`
+
+func TestParseFile(t *testing.T) {
+	file, err := ParseFile(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatalf("Failed to parse file : %s", err)
+	}
+
+	values := map[string]string{
+		"OPERATOR_NAME":          "Test Operator # 1",
+		"FEE_RATE":               "0.75",
+		"MIN_FEE_RATE":           "0.25",
+		"REQUEST_TIMEOUT":        "120_000_000_000",
+		"FEE_RATE_OVERRIDES":     "T2:1.5,M2:1.0",
+		"LOW_BALANCE_THRESHOLDS": "1ContractAddress:10000",
+		"DUST_FEE_RATE":          "2.0",
+		"IS_TEST":                "false",
+		"NODE_ADDRESS":           "10.0.0.1:8333",
+	}
+	for key, want := range values {
+		if got := file.Values[key]; got != want {
+			t.Errorf("Wrong value for %s : got %q, want %q", key, got, want)
+		}
+	}
+	if len(file.Values) != len(values) {
+		t.Errorf("Wrong value count : got %d, want %d", len(file.Values), len(values))
+	}
+
+	contract, exists := file.Contracts["1ContractAddress"]
+	if !exists {
+		t.Fatalf("Missing contract table")
+	}
+	if contract["FEE_RATE"] != "0.5" || contract["REQUEST_TIMEOUT"] != "30000000000" {
+		t.Errorf("Wrong contract values : %v", contract)
+	}
+
+	invalid := []string{
+		"fee_rate",
+		"fee_rate = 1.0\nfee_rate = 2.0",
+		"[contracts.\"\"]",
+		"operator_name = \"unterminated",
+		"[spynode",
+	}
+	for _, text := range invalid {
+		if _, err := ParseFile(strings.NewReader(text)); err == nil {
+			t.Errorf("Invalid file accepted : %s", text)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyPath, []byte("secret key\n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file : %s", err)
+	}
+
+	configPath := filepath.Join(dir, "config.toml")
+	if err := ioutil.WriteFile(configPath,
+		[]byte(testFile+"\n[other]\npriv_key_file = \""+keyPath+"\"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file : %s", err)
+	}
+
+	setEnv(t, "CONFIG_FILE", configPath)
+	setEnv(t, "FEE_RATE", "2.0")
+	setEnv(t, "NODE_CONTRACT_DUST_FEE_RATE", "0.5")
+	defer os.Unsetenv("CONFIG_FILE")
+	defer os.Unsetenv("FEE_RATE")
+	defer os.Unsetenv("NODE_CONTRACT_DUST_FEE_RATE")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Failed to load : %s", err)
+	}
+
+	if cfg.Contract.FeeRate != 2.0 {
+		t.Errorf("Environment not preferred over file : %f", cfg.Contract.FeeRate)
+	}
+	if cfg.Contract.MinFeeRate != 0.25 || cfg.Contract.OperatorName != "Test Operator # 1" {
+		t.Errorf("File values not applied : %f %s", cfg.Contract.MinFeeRate,
+			cfg.Contract.OperatorName)
+	}
+	if cfg.Contract.RequestTimeout != 120000000000 || cfg.Contract.IsTest {
+		t.Errorf("File values not applied : %d %t", cfg.Contract.RequestTimeout,
+			cfg.Contract.IsTest)
+	}
+	if cfg.Contract.DustFeeRate != 0.5 {
+		t.Errorf("Prefixed environment variable not applied : %f", cfg.Contract.DustFeeRate)
+	}
+	if cfg.Contract.PreprocessThreads != 4 {
+		t.Errorf("Default not applied : %d", cfg.Contract.PreprocessThreads)
+	}
+	if cfg.Contract.FeeRateOverrides["T2"] != 1.5 ||
+		cfg.Contract.LowBalanceThresholds["1ContractAddress"] != 10000 {
+		t.Errorf("File maps not applied : %v %v", cfg.Contract.FeeRateOverrides,
+			cfg.Contract.LowBalanceThresholds)
+	}
+	if cfg.SpyNode.Address != "10.0.0.1:8333" {
+		t.Errorf("Grouped value not applied : %s", cfg.SpyNode.Address)
+	}
+	if cfg.Contract.PrivateKey != "secret key" {
+		t.Errorf("Secret not read from file : %q", cfg.Contract.PrivateKey)
+	}
+
+	contract := cfg.Contracts["1ContractAddress"]
+	if contract.FeeRate != 0.5 || contract.RequestTimeout != 30000000000 ||
+		contract.MinFeeRate != 0.0 {
+		t.Errorf("Wrong contract overrides : %+v", contract)
+	}
+
+	safe := SafeConfig(*cfg)
+	if safe.Contract.PrivateKey == cfg.Contract.PrivateKey {
+		t.Errorf("Secret read from file not masked")
+	}
+
+	if err := ioutil.WriteFile(configPath, []byte("fee_rat = 1.0\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file : %s", err)
+	}
+	if _, err := Load(); err == nil {
+		t.Errorf("Unknown key accepted")
+	}
+
+	if err := ioutil.WriteFile(configPath,
+		[]byte("[contracts.\"1ContractAddress\"]\ndust_fee_rate = 1.0\n"), 0600); err != nil {
+		t.Fatalf("Failed to write config file : %s", err)
+	}
+	if _, err := Load(); err == nil {
+		t.Errorf("Unsupported contract key accepted")
+	}
+}
+
+func TestRestartRequired(t *testing.T) {
+	previous, err := Environment()
+	if err != nil {
+		t.Fatalf("Failed to process environment : %s", err)
+	}
+
+	current := *previous
+	current.Contract.FeeRate = 2.0
+	current.Contract.LowBalanceThresholds = map[string]uint64{"1ContractAddress": 1000}
+	current.Contracts = map[string]ContractConfig{"1ContractAddress": ContractConfig{
+		FeeRate: 0.5}}
+	if RestartRequired(previous, &current) {
+		t.Errorf("Restart required for reloadable values")
+	}
+
+	current.SpyNode.Address = "10.0.0.1:8333"
+	if !RestartRequired(previous, &current) {
+		t.Errorf("Restart not required for spynode address")
+	}
+}
+
+func setEnv(t *testing.T, key, value string) {
+	if err := os.Setenv(key, value); err != nil {
+		t.Fatalf("Failed to set %s : %s", key, err)
+	}
+}
+
+func TestSafeConfig(t *testing.T) {
+	var cfg Config
+	secrets := 0
+	walkFields(reflect.ValueOf(&cfg).Elem(), envPrefix, func(info fieldInfo) error {
+		if info.tag.Get("secret") == "true" {
+			info.field.SetString("secret " + info.key)
+			secrets++
+		}
+		return nil
+	})
+	if secrets == 0 {
+		t.Fatalf("No secret fields")
+	}
+
+	safe := SafeConfig(cfg)
+	walkFields(reflect.ValueOf(safe).Elem(), envPrefix, func(info fieldInfo) error {
+		if info.tag.Get("secret") == "true" && info.field.String() != "*** Masked ***" {
+			t.Errorf("Secret not masked : %s", info.key)
+		}
+		return nil
+	})
+
+	if cfg.Contract.PrivateKey != "secret PRIV_KEY" {
+		t.Errorf("Original config modified : %s", cfg.Contract.PrivateKey)
+	}
+}
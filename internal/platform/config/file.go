@@ -0,0 +1,202 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// contractsTable is the table containing per contract overrides, like
+	//   [contracts."1ContractAddress..."].
+	contractsTable = "contracts"
+)
+
+// File is a parsed config file. The format is a subset of TOML:
+//
+//   # Comment
+//   fee_rate = 1.0
+//   operator_name = "Operator"
+//   fee_rate_overrides = { T2 = 1.5, M2 = 1.0 }
+//
+//   [spynode]
+//   node_address = "127.0.0.1:8333"
+//
+//   [contracts."1ContractAddress..."]
+//   fee_rate = 0.75
+//   request_timeout = 120000000000
+//
+// Keys are the environment variable names, in any case. Tables other than contracts only group
+//   keys and don't change their names. Values are strings, numbers, booleans, or inline tables
+//   for maps.
+type File struct {
+	// Values are keyed by upper case key.
+	Values map[string]string
+
+	// Contracts are the values of per contract tables, keyed by contract address.
+	Contracts map[string]map[string]string
+}
+
+// ReadFile reads and parses a config file.
+func ReadFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open")
+	}
+	defer f.Close()
+
+	return ParseFile(f)
+}
+
+// ParseFile parses a config file.
+func ParseFile(r io.Reader) (*File, error) {
+	result := &File{
+		Values:    make(map[string]string),
+		Contracts: make(map[string]map[string]string),
+	}
+
+	values := result.Values
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("Line %d : Invalid table header", lineNumber)
+			}
+			table := strings.TrimSpace(line[1 : len(line)-1])
+
+			if !strings.HasPrefix(strings.ToLower(table), contractsTable+".") {
+				values = result.Values
+				continue
+			}
+
+			address, err := unquote(strings.TrimSpace(table[len(contractsTable)+1:]))
+			if err != nil || len(address) == 0 {
+				return nil, fmt.Errorf("Line %d : Invalid contract table", lineNumber)
+			}
+			if _, exists := result.Contracts[address]; exists {
+				return nil, fmt.Errorf("Line %d : Duplicate contract table : %s", lineNumber,
+					address)
+			}
+			values = make(map[string]string)
+			result.Contracts[address] = values
+			continue
+		}
+
+		equal := strings.Index(line, "=")
+		if equal == -1 {
+			return nil, fmt.Errorf("Line %d : Missing =", lineNumber)
+		}
+
+		key := strings.ToUpper(strings.TrimSpace(line[:equal]))
+		if len(key) == 0 {
+			return nil, fmt.Errorf("Line %d : Missing key", lineNumber)
+		}
+		if _, exists := values[key]; exists {
+			return nil, fmt.Errorf("Line %d : Duplicate key : %s", lineNumber, key)
+		}
+
+		value, err := parseValue(strings.TrimSpace(line[equal+1:]))
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("line %d", lineNumber))
+		}
+		values[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read")
+	}
+
+	return result, nil
+}
+
+// parseValue converts a value to the text format used by environment variables. Inline tables
+//   become "key:value,key:value".
+func parseValue(s string) (string, error) {
+	if strings.HasPrefix(s, "{") {
+		if !strings.HasSuffix(s, "}") {
+			return "", errors.New("Invalid inline table")
+		}
+
+		var items []string
+		for _, item := range strings.Split(s[1:len(s)-1], ",") {
+			item = strings.TrimSpace(item)
+			if len(item) == 0 {
+				continue
+			}
+
+			equal := strings.Index(item, "=")
+			if equal == -1 {
+				return "", fmt.Errorf("Invalid inline table item : %s", item)
+			}
+			key, err := unquote(strings.TrimSpace(item[:equal]))
+			if err != nil {
+				return "", errors.Wrap(err, "inline table key")
+			}
+			value, err := unquote(strings.TrimSpace(item[equal+1:]))
+			if err != nil {
+				return "", errors.Wrap(err, "inline table value")
+			}
+			items = append(items, key+":"+value)
+		}
+
+		return strings.Join(items, ","), nil
+	}
+
+	return unquote(s)
+}
+
+// unquote removes the quotes from basic ("...") and literal ('...') strings. Other values are
+//   returned as they are.
+func unquote(s string) (string, error) {
+	if len(s) == 0 {
+		return "", errors.New("Missing value")
+	}
+
+	switch s[0] {
+	case '"':
+		return strconv.Unquote(s)
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return "", fmt.Errorf("Invalid string : %s", s)
+		}
+		return s[1 : len(s)-1], nil
+	}
+
+	return s, nil
+}
+
+// stripComment removes a # comment that isn't within a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, c := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+
+	return line
+}
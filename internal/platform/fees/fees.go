@@ -0,0 +1,295 @@
+package fees
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SourceStatic uses the configured fee rate.
+	SourceStatic = "static"
+
+	// SourceRPC follows the fee estimates of the RPC node.
+	SourceRPC = "rpc"
+
+	// SourceSchedule uses fee rates that change by time of day.
+	SourceSchedule = "schedule"
+)
+
+var (
+	// ErrNoEstimate occurs when the estimator can't provide an estimate.
+	ErrNoEstimate = errors.New("No fee estimate")
+)
+
+// Estimator provides fee rate estimates in satoshis per byte.
+type Estimator interface {
+	EstimateFeeRate(ctx context.Context) (float32, error)
+}
+
+// ScheduleEntry is a fee rate that applies from a time of day (UTC) until the next entry.
+type ScheduleEntry struct {
+	Start   time.Duration // Offset from midnight
+	FeeRate float32
+}
+
+// ContractRates are fee rates for a specific contract. Zero values aren't overridden.
+type ContractRates struct {
+	FeeRate    float32
+	MinFeeRate float32
+}
+
+// Policy determines the fee rates used for responses and the minimum fee rate accepted for
+//   requests. It is safe for concurrent use and can be updated while running.
+type Policy struct {
+	lock sync.RWMutex
+
+	feeRate     float32
+	dustFeeRate float32
+	minFeeRate  float32
+	maxFeeRate  float32 // Upper bound on estimates. Zero for none.
+
+	// overrides are fee rates for specific response actions, keyed by action code.
+	overrides map[string]float32
+
+	// contracts are fee rates for specific contracts, keyed by the raw address bytes.
+	contracts map[string]ContractRates
+
+	schedule []ScheduleEntry
+
+	estimator Estimator
+	estimate  float32 // Zero when there hasn't been a good estimate
+}
+
+// NewPolicy returns a policy that uses a static fee rate.
+func NewPolicy(feeRate, dustFeeRate, minFeeRate float32) *Policy {
+	return &Policy{
+		feeRate:     feeRate,
+		dustFeeRate: dustFeeRate,
+		minFeeRate:  minFeeRate,
+		overrides:   make(map[string]float32),
+		contracts:   make(map[string]ContractRates),
+	}
+}
+
+// FeeRate returns the fee rate for a response from the contract containing the action code. An
+//   override for the action takes precedence, so a higher settlement rate applies to every
+//   contract. Then a rate for the contract, then the latest estimate, then the schedule, then the
+//   static rate. The contract can be empty.
+func (p *Policy) FeeRate(contract bitcoin.RawAddress, actionCode string) float32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if rate, exists := p.overrides[actionCode]; exists {
+		return rate
+	}
+
+	if rates, exists := p.contracts[string(contract.Bytes())]; exists && rates.FeeRate > 0.0 {
+		return rates.FeeRate
+	}
+
+	return p.baseFeeRate(time.Now())
+}
+
+// DustFeeRate returns the fee rate used to calculate dust limits.
+func (p *Policy) DustFeeRate() float32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.dustFeeRate
+}
+
+// MinFeeRate returns the lowest fee rate accepted for requests to the contract. Zero means any.
+//   The contract can be empty.
+func (p *Policy) MinFeeRate(contract bitcoin.RawAddress) float32 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if rates, exists := p.contracts[string(contract.Bytes())]; exists && rates.MinFeeRate > 0.0 {
+		return rates.MinFeeRate
+	}
+
+	return p.minFeeRate
+}
+
+// SetFeeRate sets the static fee rate.
+func (p *Policy) SetFeeRate(feeRate float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.feeRate = feeRate
+}
+
+// SetDustFeeRate sets the fee rate used to calculate dust limits.
+func (p *Policy) SetDustFeeRate(dustFeeRate float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.dustFeeRate = dustFeeRate
+}
+
+// SetMinFeeRate sets the lowest fee rate accepted for requests.
+func (p *Policy) SetMinFeeRate(minFeeRate float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.minFeeRate = minFeeRate
+}
+
+// SetMaxFeeRate sets the upper bound on estimated fee rates. Zero removes the bound.
+func (p *Policy) SetMaxFeeRate(maxFeeRate float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.maxFeeRate = maxFeeRate
+}
+
+// SetOverrides replaces the per action fee rates, keyed by action code.
+func (p *Policy) SetOverrides(overrides map[string]float32) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.overrides = make(map[string]float32, len(overrides))
+	for code, rate := range overrides {
+		p.overrides[code] = rate
+	}
+}
+
+// SetContractRates replaces the per contract fee rates, keyed by the raw address bytes.
+func (p *Policy) SetContractRates(contracts map[string]ContractRates) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.contracts = make(map[string]ContractRates, len(contracts))
+	for address, rates := range contracts {
+		p.contracts[address] = rates
+	}
+}
+
+// SetSchedule replaces the time of day fee rates.
+func (p *Policy) SetSchedule(schedule []ScheduleEntry) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.schedule = make([]ScheduleEntry, len(schedule))
+	copy(p.schedule, schedule)
+	sort.Slice(p.schedule, func(i, j int) bool {
+		return p.schedule[i].Start < p.schedule[j].Start
+	})
+}
+
+// SetEstimator sets the source of fee estimates. Nil stops following estimates.
+func (p *Policy) SetEstimator(estimator Estimator) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.estimator = estimator
+	p.estimate = 0
+}
+
+// Update retrieves a new estimate from the estimator. The previous estimate is kept when it
+//   fails.
+func (p *Policy) Update(ctx context.Context) error {
+	p.lock.RLock()
+	estimator := p.estimator
+	p.lock.RUnlock()
+
+	if estimator == nil {
+		return nil
+	}
+
+	estimate, err := estimator.EstimateFeeRate(ctx)
+	if err != nil {
+		return errors.Wrap(err, "estimate")
+	}
+	if estimate <= 0.0 {
+		return ErrNoEstimate
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if estimate < p.minFeeRate {
+		estimate = p.minFeeRate
+	}
+	if p.maxFeeRate > 0.0 && estimate > p.maxFeeRate {
+		estimate = p.maxFeeRate
+	}
+	p.estimate = estimate
+	return nil
+}
+
+// Run updates the estimate. It is used as a periodic scheduler task.
+func (p *Policy) Run(ctx context.Context) {
+	if err := p.Update(ctx); err != nil {
+		logger.Warn(ctx, "Failed to update fee estimate : %s", err)
+		return
+	}
+
+	logger.Verbose(ctx, "Fee rate : %f", p.FeeRate(bitcoin.RawAddress{}, ""))
+}
+
+func (p *Policy) baseFeeRate(now time.Time) float32 {
+	if p.estimator != nil && p.estimate > 0.0 {
+		return p.estimate
+	}
+
+	if len(p.schedule) > 0 {
+		now = now.UTC()
+		offset := now.Sub(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+
+		// Before the first entry the last entry from the previous day applies.
+		result := p.schedule[len(p.schedule)-1].FeeRate
+		for _, entry := range p.schedule {
+			if entry.Start > offset {
+				break
+			}
+			result = entry.FeeRate
+		}
+		return result
+	}
+
+	return p.feeRate
+}
+
+// ParseSchedule parses a schedule like "00:00=0.5,08:00=1.0" with UTC times of day.
+func ParseSchedule(s string) ([]ScheduleEntry, error) {
+	var result []ScheduleEntry
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+
+		parts := strings.Split(item, "=")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("Invalid schedule entry : %s", item)
+		}
+
+		start, err := time.Parse("15:04", strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, errors.Wrap(err, "parse time")
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 32)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse fee rate")
+		}
+
+		result = append(result, ScheduleEntry{
+			Start:   time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+			FeeRate: float32(rate),
+		})
+	}
+
+	return result, nil
+}
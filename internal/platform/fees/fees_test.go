@@ -0,0 +1,169 @@
+package fees
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+)
+
+func TestFeeRate(t *testing.T) {
+	ctx := context.Background()
+
+	policy := NewPolicy(1.0, 1.0, 0.5)
+	policy.SetOverrides(map[string]float32{"T2": 1.5})
+	policy.SetMaxFeeRate(2.0)
+
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 1.0 {
+		t.Errorf("Wrong static fee rate : %f", rate)
+	}
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "T2"); rate != 1.5 {
+		t.Errorf("Wrong override fee rate : %f", rate)
+	}
+
+	estimator := &testEstimator{rate: 0.75}
+	policy.SetEstimator(estimator)
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 1.0 {
+		t.Errorf("Wrong fee rate before estimate : %f", rate)
+	}
+
+	if err := policy.Update(ctx); err != nil {
+		t.Fatalf("Failed to update : %s", err)
+	}
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 0.75 {
+		t.Errorf("Wrong estimated fee rate : %f", rate)
+	}
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "T2"); rate != 1.5 {
+		t.Errorf("Wrong override fee rate with estimate : %f", rate)
+	}
+
+	estimator.rate = 0.1
+	policy.Update(ctx)
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 0.5 {
+		t.Errorf("Estimate not bounded by min fee rate : %f", rate)
+	}
+
+	estimator.rate = 5.0
+	policy.Update(ctx)
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 2.0 {
+		t.Errorf("Estimate not bounded by max fee rate : %f", rate)
+	}
+
+	estimator.rate = -1.0
+	if err := policy.Update(ctx); err != ErrNoEstimate {
+		t.Errorf("Wrong error for no estimate : %v", err)
+	}
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "A2"); rate != 2.0 {
+		t.Errorf("Previous estimate not kept : %f", rate)
+	}
+}
+
+func TestContractRates(t *testing.T) {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contract, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	policy := NewPolicy(1.0, 1.0, 0.5)
+	policy.SetOverrides(map[string]float32{"T2": 1.5})
+	policy.SetContractRates(map[string]ContractRates{
+		string(contract.Bytes()): ContractRates{FeeRate: 0.25},
+	})
+
+	if rate := policy.FeeRate(contract, "A2"); rate != 0.25 {
+		t.Errorf("Wrong contract fee rate : %f", rate)
+	}
+	if rate := policy.FeeRate(contract, "T2"); rate != 1.5 {
+		t.Errorf("Action override not applied over contract fee rate : %f", rate)
+	}
+	if rate := policy.FeeRate(bitcoin.RawAddress{}, "T2"); rate != 1.5 {
+		t.Errorf("Wrong fee rate without contract : %f", rate)
+	}
+	if rate := policy.MinFeeRate(contract); rate != 0.5 {
+		t.Errorf("Contract without min fee rate not using default : %f", rate)
+	}
+
+	policy.SetContractRates(map[string]ContractRates{
+		string(contract.Bytes()): ContractRates{MinFeeRate: 0.1},
+	})
+	if rate := policy.FeeRate(contract, "A2"); rate != 1.0 {
+		t.Errorf("Contract without fee rate not using default : %f", rate)
+	}
+	if rate := policy.MinFeeRate(contract); rate != 0.1 {
+		t.Errorf("Wrong contract min fee rate : %f", rate)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	schedule, err := ParseSchedule("08:00=1.0, 20:30=0.75,02:00=0.5")
+	if err != nil {
+		t.Fatalf("Failed to parse schedule : %s", err)
+	}
+
+	policy := NewPolicy(1.0, 1.0, 0.5)
+	policy.SetSchedule(schedule)
+
+	tests := []struct {
+		hour, minute int
+		want         float32
+	}{
+		{0, 0, 0.75},
+		{2, 0, 0.5},
+		{7, 59, 0.5},
+		{8, 0, 1.0},
+		{20, 29, 1.0},
+		{20, 30, 0.75},
+		{23, 59, 0.75},
+	}
+
+	for _, tt := range tests {
+		now := time.Date(2020, 6, 1, tt.hour, tt.minute, 0, 0, time.UTC)
+		if rate := policy.baseFeeRate(now); rate != tt.want {
+			t.Errorf("Wrong fee rate at %02d:%02d : got %f, want %f", tt.hour, tt.minute, rate,
+				tt.want)
+		}
+	}
+
+	if _, err := ParseSchedule("8am=1.0"); err == nil {
+		t.Errorf("Invalid schedule time accepted")
+	}
+}
+
+func TestRPCEstimator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		var request rpcRequest
+		json.NewDecoder(r.Body).Decode(&request)
+		if username != "user" || password != "pass" || request.Method != "estimatefee" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"result":0.00001,"error":null,"id":1}`))
+	}))
+	defer server.Close()
+
+	estimator := NewRPCEstimator(server.URL, "user", "pass", 2)
+	rate, err := estimator.EstimateFeeRate(context.Background())
+	if err != nil {
+		t.Fatalf("Failed to estimate : %s", err)
+	}
+	if rate != 1.0 {
+		t.Errorf("Wrong fee rate : %f", rate)
+	}
+}
+
+type testEstimator struct {
+	rate float32
+}
+
+func (e *testEstimator) EstimateFeeRate(ctx context.Context) (float32, error) {
+	return e.rate, nil
+}
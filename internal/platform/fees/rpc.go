@@ -0,0 +1,115 @@
+package fees
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RPCEstimator retrieves fee estimates from a bitcoin node's RPC interface.
+type RPCEstimator struct {
+	host     string
+	username string
+	password string
+	blocks   int // Target number of blocks for confirmation
+}
+
+// NewRPCEstimator returns an estimator for the node at host ("host:port").
+func NewRPCEstimator(host, username, password string, blocks int) *RPCEstimator {
+	if blocks < 1 {
+		blocks = 1
+	}
+
+	return &RPCEstimator{
+		host:     host,
+		username: username,
+		password: password,
+		blocks:   blocks,
+	}
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcResponse struct {
+	Result *float64 `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// EstimateFeeRate returns the node's estimate in satoshis per byte.
+func (e *RPCEstimator) EstimateFeeRate(ctx context.Context) (float32, error) {
+	var transport = &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout: 5 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+
+	var client = &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: transport,
+	}
+
+	b, err := json.Marshal(rpcRequest{
+		JSONRPC: "1.0",
+		ID:      1,
+		Method:  "estimatefee",
+		Params:  []interface{}{e.blocks},
+	})
+	if err != nil {
+		return 0.0, errors.Wrap(err, "marshal request")
+	}
+
+	url := e.host
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		url = "http://" + url
+	}
+
+	httpRequest, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return 0.0, errors.Wrap(err, "create request")
+	}
+	httpRequest = httpRequest.WithContext(ctx)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.SetBasicAuth(e.username, e.password)
+
+	httpResponse, err := client.Do(httpRequest)
+	if err != nil {
+		return 0.0, err
+	}
+	defer httpResponse.Body.Close()
+
+	var response rpcResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		if httpResponse.StatusCode < 200 || httpResponse.StatusCode > 299 {
+			return 0.0, fmt.Errorf("%v %s", httpResponse.StatusCode, httpResponse.Status)
+		}
+		return 0.0, errors.Wrap(err, "decode response")
+	}
+
+	if response.Error != nil {
+		return 0.0, fmt.Errorf("RPC error %d : %s", response.Error.Code, response.Error.Message)
+	}
+
+	// The node returns -1 when it doesn't have enough data.
+	if response.Result == nil || *response.Result <= 0.0 {
+		return 0.0, ErrNoEstimate
+	}
+
+	// Convert BSV per kilobyte to satoshis per byte.
+	return float32(*response.Result * 100000000.0 / 1000.0), nil
+}
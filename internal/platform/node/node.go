@@ -5,7 +5,9 @@ import (
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
 	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/internal/utxos"
 	"github.com/tokenized/smart-contract/pkg/inspector"
 	"github.com/tokenized/smart-contract/pkg/wallet"
 	"github.com/tokenized/specification/dist/golang/protocol"
@@ -17,6 +19,9 @@ type ctxKey int
 // KeyValues is how event values or stored/retrieved.
 const KeyValues ctxKey = 1
 
+// keyTimestamp is how a fixed processing time is stored/retrieved.
+const keyTimestamp ctxKey = 2
+
 // Values represent state for each event.
 type Values struct {
 	Now        protocol.Timestamp
@@ -44,6 +49,7 @@ type App struct {
 	mw       []Middleware
 	masterDB *db.DB
 	wallet   wallet.WalletInterface
+	signer   wallet.Signer
 }
 
 // Node configuration
@@ -52,25 +58,51 @@ type Config struct {
 	Version            string
 	FeeAddress         bitcoin.RawAddress
 	Net                bitcoin.Network
-	FeeRate            float32
-	DustFeeRate        float32
-	MinFeeRate         float32
-	RequestTimeout     uint64 // Nanoseconds until a request to another contract times out and the original request is rejected.
+	Fees               *fees.Policy // Fee rates for responses and the minimum for requests
+	RequestTimeout     uint64       // Nanoseconds until a request to another contract times out and the original request is rejected.
 	PreprocessThreads  int
+	NoticeBatchSize    int // Holders sent a notice in each tx
 	IsTest             bool
+
+	// Overrides of RequestTimeout for specific contracts. Nil for none.
+	RequestTimeouts *RequestTimeouts
+
+	// Selection of UTXOs to fund responses.
+	UTXOStrategy         utxos.Strategy
+	UTXOMinConfirmations int
 }
 
-// New creates an App value that handle a set of routes for the application.
-func New(config *Config, masterDB *db.DB, wallet wallet.WalletInterface, mw ...Middleware) *App {
+// ContractRequestTimeout returns the request timeout for requests from the contract to other
+//   contracts.
+func (c *Config) ContractRequestTimeout(contractAddress bitcoin.RawAddress) uint64 {
+	if c.RequestTimeouts != nil {
+		if timeout, exists := c.RequestTimeouts.Get(contractAddress); exists {
+			return timeout
+		}
+	}
+	return c.RequestTimeout
+}
+
+// New creates an App value that handle a set of routes for the application. The signer signs
+//   responses for the keys in the wallet.
+func New(config *Config, masterDB *db.DB, wallet wallet.WalletInterface, signer wallet.Signer,
+	mw ...Middleware) *App {
 	return &App{
 		ProtoMux: protomux.New(),
 		config:   config,
 		mw:       mw,
 		masterDB: masterDB,
 		wallet:   wallet,
+		signer:   signer,
 	}
 }
 
+// ContextWithTimestamp returns a context that makes handlers use the specified time as the current
+//   time. This is used when replaying txs so the results match the original processing.
+func ContextWithTimestamp(ctx context.Context, ts protocol.Timestamp) context.Context {
+	return context.WithValue(ctx, keyTimestamp, ts)
+}
+
 // Handle is our mechanism for mounting Handlers for a given event
 // this makes for really easy, convenient event handling.
 func (a *App) Handle(verb, event string, handler Handler, mw ...Middleware) {
@@ -85,6 +117,7 @@ func (a *App) Handle(verb, event string, handler Handler, mw ...Middleware) {
 			Mux:      a.ProtoMux,
 			Config:   a.config,
 			MasterDB: a.masterDB,
+			Signer:   a.signer,
 		}
 
 		// For each address controlled by this wallet
@@ -99,8 +132,14 @@ func (a *App) Handle(verb, event string, handler Handler, mw ...Middleware) {
 			v := Values{
 				Now: protocol.CurrentTimestamp(),
 			}
+			if ts, ok := ctx.Value(keyTimestamp).(protocol.Timestamp); ok {
+				v.Now = ts
+			}
 			ctx = context.WithValue(ctx, KeyValues, &v)
 
+			w.ContractAddress = walletKey.Address
+			w.ContractFee = 0
+
 			// Call the wrapped handler functions.
 			handled = true
 			if err := handler(ctx, w, itx, walletKey); err != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/logger"
 	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/smart-contract/internal/revenue"
 	"github.com/tokenized/smart-contract/internal/transactions"
 	"github.com/tokenized/smart-contract/pkg/inspector"
 	"github.com/tokenized/smart-contract/pkg/wallet"
@@ -97,7 +98,8 @@ func RespondRejectText(ctx context.Context, w *ResponseWriter, itx *inspector.Tr
 	}
 
 	// Create reject tx. Change goes back to requestor.
-	rejectTx := txbuilder.NewTxBuilder(w.Config.FeeRate, w.Config.DustFeeRate)
+	rejectTx := txbuilder.NewTxBuilder(w.Config.Fees.FeeRate(wk.Address, rejection.Code()),
+		w.Config.Fees.DustFeeRate())
 	if len(w.RejectOutputs) > 0 {
 		var changeAddress bitcoin.RawAddress
 		for _, output := range w.RejectOutputs {
@@ -122,9 +124,11 @@ func RespondRejectText(ctx context.Context, w *ResponseWriter, itx *inspector.Tr
 	if len(w.RejectOutputs) > 0 {
 		rejectAddressFound := false
 		for i, output := range w.RejectOutputs {
-			dustLimit, err := txbuilder.DustLimitForAddress(output.Address, w.Config.DustFeeRate)
+			dustLimit, err := txbuilder.DustLimitForAddress(output.Address,
+				w.Config.Fees.DustFeeRate())
 			if err != nil {
-				dustLimit = txbuilder.DustLimit(txbuilder.P2PKHOutputSize, w.Config.DustFeeRate)
+				dustLimit = txbuilder.DustLimit(txbuilder.P2PKHOutputSize,
+					w.Config.Fees.DustFeeRate())
 			}
 			if output.Value < dustLimit {
 				output.Value = dustLimit
@@ -157,7 +161,7 @@ func RespondRejectText(ctx context.Context, w *ResponseWriter, itx *inspector.Tr
 	rejectTx.AddOutput(payload, 0, false, false)
 
 	// Sign the tx
-	err = rejectTx.Sign([]bitcoin.Key{wk.Key})
+	err = w.Signer.Sign(ctx, rejectTx, wk.Address)
 	if err != nil {
 		Error(ctx, w, err)
 		return ErrNoResponse
@@ -184,7 +188,8 @@ func RespondSuccess(ctx context.Context, w *ResponseWriter, itx *inspector.Trans
 
 	// Create respond tx. Use contract address as backup change
 	// address if an output wasn't specified
-	respondTx := txbuilder.NewTxBuilder(w.Config.FeeRate, w.Config.DustFeeRate)
+	respondTx := txbuilder.NewTxBuilder(w.Config.Fees.FeeRate(wk.Address, msg.Code()),
+		w.Config.Fees.DustFeeRate())
 	respondTx.SetChangeAddress(w.Config.FeeAddress, "")
 
 	// Get the specified UTXOs, otherwise look up the spendable
@@ -224,7 +229,7 @@ func RespondSuccess(ctx context.Context, w *ResponseWriter, itx *inspector.Trans
 	respondTx.AddOutput(payload, 0, false, false)
 
 	// Sign the tx
-	err = respondTx.Sign([]bitcoin.Key{wk.Key})
+	err = w.Signer.Sign(ctx, respondTx, wk.Address)
 	if err != nil {
 		if errors.Cause(err) == txbuilder.ErrInsufficientValue {
 			LogWarn(ctx, "Sending reject. Failed to sign tx : %s\n%s", err,
@@ -253,5 +258,42 @@ func Respond(ctx context.Context, w *ResponseWriter, itx *inspector.Transaction)
 		return errors.Wrap(err, "Failed to save tx")
 	}
 
-	return w.Respond(ctx, itx.MsgTx)
+	if err := w.Respond(ctx, itx.MsgTx); err != nil {
+		return err
+	}
+
+	if err := recordFees(ctx, w, itx); err != nil {
+		LogError(ctx, "Failed to record fees : %s", err)
+	}
+
+	return nil
+}
+
+// recordFees adds the response to the fee ledger. Rejections don't pay contract fees.
+func recordFees(ctx context.Context, w *ResponseWriter, itx *inspector.Transaction) error {
+	if w.ContractAddress.IsEmpty() || itx.MsgProto == nil {
+		return nil
+	}
+
+	entry := &revenue.Entry{
+		ContractAddress: w.ContractAddress,
+		ActionCode:      itx.MsgProto.Code(),
+		TxId:            itx.Hash,
+		Timestamp:       protocol.CurrentTimestamp(),
+	}
+	if v, ok := ctx.Value(KeyValues).(*Values); ok {
+		entry.Timestamp = v.Now
+	}
+
+	if entry.ActionCode != actions.CodeRejection {
+		entry.ContractFee = w.ContractFee
+	}
+
+	fee, err := itx.Fee()
+	if err != nil {
+		return errors.Wrap(err, "mining fee")
+	}
+	entry.MiningFee = fee
+
+	return revenue.Save(ctx, w.MasterDB, entry)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/tokenized/pkg/wire"
 	"github.com/tokenized/smart-contract/internal/platform/db"
 	"github.com/tokenized/smart-contract/internal/platform/protomux"
+	"github.com/tokenized/smart-contract/pkg/wallet"
 )
 
 type ResponseWriter struct {
@@ -18,7 +19,13 @@ type ResponseWriter struct {
 	RejectAddress bitcoin.RawAddress
 	Config        *Config
 	MasterDB      *db.DB
+	Signer        wallet.Signer
 	Mux           protomux.Handler
+
+	// ContractAddress is the address of the contract responding. ContractFee is the fee the
+	//   response pays to the fee address. They are recorded in the fee ledger.
+	ContractAddress bitcoin.RawAddress
+	ContractFee     uint64
 }
 
 // AddChangeOutput is a helper to add a change output
@@ -49,10 +56,17 @@ func (w *ResponseWriter) AddContractFee(ctx context.Context, value uint64) error
 			fee.Change = true
 		}
 		w.Outputs = append(w.Outputs, *fee)
+		w.ContractFee += value
 	}
 	return nil
 }
 
+// RecordContractFee records a contract fee paid by an output the handler added to the response tx
+//   itself, like in a settlement.
+func (w *ResponseWriter) RecordContractFee(value uint64) {
+	w.ContractFee += value
+}
+
 // SetUTXOs is an optional function that allows explicit UTXOs to be spent in the response
 // be sure to remember any remaining UTXOs so they can be spent later.
 func (w *ResponseWriter) SetUTXOs(ctx context.Context, utxos []bitcoin.UTXO) error {
@@ -111,9 +125,9 @@ type Output struct {
 
 // outputValue returns a payment output ensuring the value is always above the dust limit
 func outputValue(ctx context.Context, config *Config, addr bitcoin.RawAddress, value uint64, change bool) *Output {
-	dustLimit, err := txbuilder.DustLimitForAddress(addr, config.DustFeeRate)
+	dustLimit, err := txbuilder.DustLimitForAddress(addr, config.Fees.DustFeeRate())
 	if err != nil {
-		dustLimit = txbuilder.DustLimit(txbuilder.P2PKHOutputSize, config.DustFeeRate)
+		dustLimit = txbuilder.DustLimit(txbuilder.P2PKHOutputSize, config.Fees.DustFeeRate())
 	}
 	if value < dustLimit {
 		value = dustLimit
@@ -0,0 +1,41 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+)
+
+// RequestTimeouts are the request timeouts of specific contracts. They are safe for concurrent
+//   use and can be updated while running.
+type RequestTimeouts struct {
+	lock     sync.RWMutex
+	timeouts map[string]uint64 // Nanoseconds, keyed by the raw address bytes
+}
+
+// NewRequestTimeouts returns request timeouts keyed by the raw contract address bytes.
+func NewRequestTimeouts(timeouts map[string]uint64) *RequestTimeouts {
+	result := &RequestTimeouts{}
+	result.Set(timeouts)
+	return result
+}
+
+// Get returns the request timeout of the contract and true if it has one.
+func (rt *RequestTimeouts) Get(contractAddress bitcoin.RawAddress) (uint64, bool) {
+	rt.lock.RLock()
+	defer rt.lock.RUnlock()
+
+	timeout, exists := rt.timeouts[string(contractAddress.Bytes())]
+	return timeout, exists
+}
+
+// Set replaces the request timeouts.
+func (rt *RequestTimeouts) Set(timeouts map[string]uint64) {
+	rt.lock.Lock()
+	defer rt.lock.Unlock()
+
+	rt.timeouts = make(map[string]uint64, len(timeouts))
+	for address, timeout := range timeouts {
+		rt.timeouts[address] = timeout
+	}
+}
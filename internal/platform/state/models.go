@@ -38,6 +38,10 @@ type Contract struct {
 	AssetCodes []*protocol.AssetCode `json:"AssetCodes,omitempty"`
 
 	FullOracles []Oracle `json:"_,omitempty"`
+
+	// FullAuthorities are the authority oracles of the contract with the public key and URL from
+	//   their contract formations. Orders must be signed by one of them.
+	FullAuthorities []Oracle `json:"-"`
 }
 
 type Oracle struct {
@@ -106,25 +110,93 @@ type Vote struct {
 	VoteTxId     *protocol.TxId     `json:"VoteTxId,omitempty"`
 	ProposalTxId *protocol.TxId     `json:"ProposalTxId,omitempty"`
 	TokenQty     uint64             `json:"TokenQty,omitempty"`
+	Quorum       uint32             `json:"Quorum,omitempty"`     // Percentage of TokenQty
+	EarlyClose   bool               `json:"EarlyClose,omitempty"` // Complete when decided
 	Expires      protocol.Timestamp `json:"Expires,omitempty"`
 	Timestamp    protocol.Timestamp `json:"Timestamp,omitempty"`
 	CreatedAt    protocol.Timestamp `json:"CreatedAt,omitempty"`
 	UpdatedAt    protocol.Timestamp `json:"UpdatedAt,omitempty"`
 
 	OptionTally []uint64           `json:"OptionTally,omitempty"`
+	Rounds      []VoteRound        `json:"Rounds,omitempty"`   // Instant runoff only
+	Turnout     uint64             `json:"Turnout,omitempty"`  // Quantity voted
+	NoQuorum    bool               `json:"NoQuorum,omitempty"` // Turnout was below the quorum
 	Result      string             `json:"Result,omitempty"`
 	AppliedTxId *protocol.TxId     `json:"AppliedTxId,omitempty"`
 	CompletedAt protocol.Timestamp `json:"CompletedAt,omitempty"`
 
+	ClosedEarlyAt protocol.Timestamp `json:"ClosedEarlyAt,omitempty"` // When found to be decided
+	CloseReason   string             `json:"CloseReason,omitempty"`
+	BallotRoot    *bitcoin.Hash32    `json:"BallotRoot,omitempty"` // Merkle root of final ballots
+
 	Ballots    map[bitcoin.Hash20]Ballot `json:"-"` // json can only encode string maps
 	BallotList []Ballot                  `json:"Ballots,omitempty"`
 }
 
+// VoteRound is a round of counting an instant runoff vote.
+type VoteRound struct {
+	OptionTally []uint64 `json:"OptionTally,omitempty"`
+	Exhausted   uint64   `json:"Exhausted,omitempty"`  // Quantity of ballots with no options left
+	Eliminated  string   `json:"Eliminated,omitempty"` // Options eliminated after this round
+}
+
 type Ballot struct {
 	Address   bitcoin.RawAddress `json:"Address,omitempty"`
 	Vote      string             `json:"Vote,omitempty"`
 	Quantity  uint64             `json:"Quantity,omitempty"`
 	Timestamp protocol.Timestamp `json:"Timestamp,omitempty"`
+	TxId      *protocol.TxId     `json:"TxId,omitempty"` // Ballot cast tx
+
+	// Proxy can cast the ballot for the holder, until the holder casts it.
+	Proxy       bitcoin.RawAddress `json:"Proxy,omitempty"`
+	CastByProxy bool               `json:"CastByProxy,omitempty"`
+}
+
+// Delegation assigns the votes of a holder to a proxy.
+type Delegation struct {
+	Delegator bitcoin.RawAddress  `json:"Delegator,omitempty"`
+	Proxy     bitcoin.RawAddress  `json:"Proxy,omitempty"`
+	AssetCode *protocol.AssetCode `json:"AssetCode,omitempty"` // Nil for all assets
+	Expires   protocol.Timestamp  `json:"Expires,omitempty"`   // Zero for never
+	TxId      *protocol.TxId      `json:"TxId,omitempty"`      // Message that set it
+	Timestamp protocol.Timestamp  `json:"Timestamp,omitempty"`
+}
+
+// Notice is the delivery record of a notice from the administration to holders.
+type Notice struct {
+	Hash        bitcoin.Hash32       `json:"Hash"`
+	AssetCode   *protocol.AssetCode  `json:"AssetCode,omitempty"` // Nil for all assets
+	RequestTxId *protocol.TxId       `json:"RequestTxId,omitempty"`
+	Recipients  []bitcoin.RawAddress `json:"Recipients,omitempty"`
+	TxIds       []*protocol.TxId     `json:"TxIds,omitempty"` // Message txs, one for each batch
+	Timestamp   protocol.Timestamp   `json:"Timestamp,omitempty"`
+}
+
+// Order is the record of an enforcement order accepted by the contract and the responses that
+//   applied it.
+type Order struct {
+	TxId                     *protocol.TxId      `json:"TxId,omitempty"`
+	ComplianceAction         string              `json:"ComplianceAction,omitempty"`
+	AssetCode                *protocol.AssetCode `json:"AssetCode,omitempty"` // Nil for the contract
+	Targets                  []OrderTarget       `json:"Targets,omitempty"`
+	FreezeTxId               *protocol.TxId      `json:"FreezeTxId,omitempty"` // Thaw orders
+	FreezePeriod             protocol.Timestamp  `json:"FreezePeriod,omitempty"`
+	DepositAddress           bitcoin.RawAddress  `json:"DepositAddress,omitempty"`
+	AuthorityName            string              `json:"AuthorityName,omitempty"`
+	AuthorityPublicKey       []byte              `json:"AuthorityPublicKey,omitempty"`
+	SupportingEvidenceFormat uint32              `json:"SupportingEvidenceFormat,omitempty"`
+	SupportingEvidenceHash   *bitcoin.Hash32     `json:"SupportingEvidenceHash,omitempty"`
+	Message                  string              `json:"Message,omitempty"`
+	Status                   string              `json:"Status,omitempty"`
+	ResponseTxIds            []*protocol.TxId    `json:"ResponseTxIds,omitempty"`
+	CreatedAt                protocol.Timestamp  `json:"CreatedAt,omitempty"`
+	UpdatedAt                protocol.Timestamp  `json:"UpdatedAt,omitempty"`
+}
+
+// OrderTarget is an address affected by an enforcement order.
+type OrderTarget struct {
+	Address  bitcoin.RawAddress `json:"Address,omitempty"`
+	Quantity uint64             `json:"Quantity,omitempty"`
 }
 
 // PendingTransfer defines the information required to monitor pending multi-contract transfers.
@@ -12,6 +12,7 @@ import (
 	"github.com/tokenized/pkg/scheduler"
 	"github.com/tokenized/smart-contract/internal/holdings"
 	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/fees"
 	"github.com/tokenized/smart-contract/internal/platform/node"
 	"github.com/tokenized/smart-contract/internal/utxos"
 	"github.com/tokenized/smart-contract/pkg/wallet"
@@ -63,9 +64,7 @@ func New(logFileName string) *Test {
 		ContractProviderID: "TokenizedTest",
 		Version:            "TestVersion",
 		Net:                bitcoin.MainNet,
-		FeeRate:            1.0,
-		DustFeeRate:        1.0,
-		MinFeeRate:         0.5,
+		Fees:               fees.NewPolicy(1.0, 1.0, 0.5),
 		RequestTimeout:     1000000000000,
 		IsTest:             true,
 	}
@@ -0,0 +1,213 @@
+package revenue
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+	PeriodYear  = "year"
+	PeriodAll   = "all"
+)
+
+// Report is the fee revenue of a contract aggregated by period.
+type Report struct {
+	ContractAddress bitcoin.RawAddress `json:"ContractAddress,omitempty"`
+	Period          string             `json:"Period,omitempty"`
+	Start           protocol.Timestamp `json:"Start,omitempty"`
+	End             protocol.Timestamp `json:"End,omitempty"`
+	Periods         []*PeriodTotals    `json:"Periods,omitempty"`
+	Total           Totals             `json:"Total"`
+}
+
+// PeriodTotals are the totals for one period, like one month.
+type PeriodTotals struct {
+	Name     string             `json:"Name,omitempty"` // For example 2020-06
+	Start    protocol.Timestamp `json:"Start,omitempty"`
+	Totals   Totals             `json:"Totals"`
+	ByAction map[string]*Totals `json:"ByAction,omitempty"`
+}
+
+// Totals are summed ledger entries.
+type Totals struct {
+	Responses   int    `json:"Responses"`
+	ContractFee uint64 `json:"ContractFee"`
+	MiningFee   uint64 `json:"MiningFee"`
+}
+
+func (t *Totals) add(entry *Entry) {
+	t.Responses++
+	t.ContractFee += entry.ContractFee
+	t.MiningFee += entry.MiningFee
+}
+
+// BuildReport aggregates the ledger entries of a contract with timestamps in [start, end). A zero
+//   end means no end.
+func BuildReport(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	period string, start, end protocol.Timestamp) (*Report, error) {
+
+	entries, err := Fetch(ctx, dbConn, contractAddress, start, end)
+	if err != nil {
+		return nil, errors.Wrap(err, "fetch entries")
+	}
+
+	return NewReport(contractAddress, period, start, end, entries)
+}
+
+// NewReport aggregates ledger entries by period. Entries must be sorted oldest first.
+func NewReport(contractAddress bitcoin.RawAddress, period string, start, end protocol.Timestamp,
+	entries []*Entry) (*Report, error) {
+
+	period = strings.ToLower(period)
+	switch period {
+	case PeriodDay, PeriodMonth, PeriodYear, PeriodAll:
+	default:
+		return nil, fmt.Errorf("Unsupported period : %s", period)
+	}
+
+	result := &Report{
+		ContractAddress: contractAddress,
+		Period:          period,
+		Start:           start,
+		End:             end,
+	}
+
+	var current *PeriodTotals
+	for _, entry := range entries {
+		name, periodStart := periodOf(period, entry.Timestamp)
+		if current == nil || current.Name != name {
+			current = &PeriodTotals{
+				Name:     name,
+				Start:    periodStart,
+				ByAction: make(map[string]*Totals),
+			}
+			result.Periods = append(result.Periods, current)
+		}
+
+		current.Totals.add(entry)
+		action, exists := current.ByAction[entry.ActionCode]
+		if !exists {
+			action = &Totals{}
+			current.ByAction[entry.ActionCode] = action
+		}
+		action.add(entry)
+
+		result.Total.add(entry)
+	}
+
+	return result, nil
+}
+
+// WriteCSV writes a row per period and action with a header row.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"Period", "ActionCode", "Responses", "ContractFee",
+		"MiningFee"}); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+
+	for _, p := range r.Periods {
+		codes := make([]string, 0, len(p.ByAction))
+		for code := range p.ByAction {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+
+		for _, code := range codes {
+			if err := writeTotals(cw, p.Name, code, p.ByAction[code]); err != nil {
+				return errors.Wrap(err, "write action")
+			}
+		}
+
+		if err := writeTotals(cw, p.Name, "", &p.Totals); err != nil {
+			return errors.Wrap(err, "write period")
+		}
+	}
+
+	if err := writeTotals(cw, "total", "", &r.Total); err != nil {
+		return errors.Wrap(err, "write total")
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteEntriesCSV writes ledger entries as CSV with a header row.
+func WriteEntriesCSV(w io.Writer, entries []*Entry, net bitcoin.Network) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"Timestamp", "ContractAddress", "ActionCode", "TxId",
+		"ContractFee", "MiningFee"}); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+
+	for _, entry := range entries {
+		if err := cw.Write([]string{
+			time.Unix(0, int64(entry.Timestamp.Nano())).UTC().Format(time.RFC3339),
+			bitcoin.NewAddressFromRawAddress(entry.ContractAddress, net).String(),
+			entry.ActionCode,
+			entry.TxId.String(),
+			strconv.FormatUint(entry.ContractFee, 10),
+			strconv.FormatUint(entry.MiningFee, 10),
+		}); err != nil {
+			return errors.Wrap(err, "write entry")
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeTotals(cw *csv.Writer, period, code string, t *Totals) error {
+	return cw.Write([]string{
+		period,
+		code,
+		strconv.Itoa(t.Responses),
+		strconv.FormatUint(t.ContractFee, 10),
+		strconv.FormatUint(t.MiningFee, 10),
+	})
+}
+
+// periodOf returns the name and start of the period (UTC) containing the timestamp.
+func periodOf(period string, ts protocol.Timestamp) (string, protocol.Timestamp) {
+	t := time.Unix(0, int64(ts.Nano())).UTC()
+
+	var start time.Time
+	var name string
+	switch period {
+	case PeriodDay:
+		start = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		name = start.Format("2006-01-02")
+	case PeriodMonth:
+		start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		name = start.Format("2006-01")
+	case PeriodYear:
+		start = time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+		name = start.Format("2006")
+	default:
+		return PeriodAll, protocol.NewTimestamp(0)
+	}
+
+	return name, protocol.NewTimestamp(uint64(start.UnixNano()))
+}
+
+func sortEntries(entries []*Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Nano() < entries[j].Timestamp.Nano()
+	})
+}
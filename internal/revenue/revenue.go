@@ -0,0 +1,111 @@
+package revenue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	storageKey    = "contracts"
+	storageSubKey = "fees"
+)
+
+// Entry is the fee ledger record of one response sent by a contract.
+type Entry struct {
+	ContractAddress bitcoin.RawAddress `json:"ContractAddress,omitempty"`
+	ActionCode      string             `json:"ActionCode,omitempty"`
+	TxId            *bitcoin.Hash32    `json:"TxId,omitempty"`
+
+	// ContractFee is the fee paid to the operator's fee address.
+	ContractFee uint64 `json:"ContractFee,omitempty"`
+
+	// MiningFee is the tx fee paid from the contract's UTXOs.
+	MiningFee uint64 `json:"MiningFee,omitempty"`
+
+	Timestamp protocol.Timestamp `json:"Timestamp,omitempty"`
+}
+
+// Save writes a ledger entry.
+func Save(ctx context.Context, dbConn *db.DB, entry *Entry) error {
+	if entry.TxId == nil {
+		return errors.New("Missing txid")
+	}
+
+	contractHash, err := entry.ContractAddress.Hash()
+	if err != nil {
+		return errors.Wrap(err, "contract hash")
+	}
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "marshal entry")
+	}
+
+	return dbConn.Put(ctx, buildStoragePath(contractHash, entry.Timestamp, entry.TxId), b)
+}
+
+// Fetch returns the ledger entries of a contract with timestamps in [start, end), oldest first.
+//   A zero end means no end.
+func Fetch(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress,
+	start, end protocol.Timestamp) ([]*Entry, error) {
+
+	contractHash, err := contractAddress.Hash()
+	if err != nil {
+		return nil, errors.Wrap(err, "contract hash")
+	}
+
+	keys, err := dbConn.List(ctx, fmt.Sprintf("%s/%s/%s", storageKey, contractHash.String(),
+		storageSubKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "list entries")
+	}
+
+	var result []*Entry
+	for _, key := range keys {
+		name := key[strings.LastIndex(key, "/")+1:]
+		underscore := strings.Index(name, "_")
+		if underscore == -1 {
+			continue // not an entry
+		}
+
+		ts, err := strconv.ParseUint(name[:underscore], 10, 64)
+		if err != nil {
+			continue // not an entry
+		}
+		if ts < start.Nano() || (end.Nano() != 0 && ts >= end.Nano()) {
+			continue
+		}
+
+		b, err := dbConn.Fetch(ctx, key)
+		if err != nil {
+			return nil, errors.Wrap(err, "fetch entry")
+		}
+
+		entry := &Entry{}
+		if err := json.Unmarshal(b, entry); err != nil {
+			return nil, errors.Wrap(err, "unmarshal entry")
+		}
+		result = append(result, entry)
+	}
+
+	// Keys are zero padded timestamps, but the storage doesn't guarantee list order.
+	sortEntries(result)
+	return result, nil
+}
+
+// buildStoragePath returns the storage path for a ledger entry. The timestamp is zero padded so
+//   keys sort chronologically.
+func buildStoragePath(contractHash *bitcoin.Hash20, timestamp protocol.Timestamp,
+	txid *bitcoin.Hash32) string {
+	return fmt.Sprintf("%s/%s/%s/%020d_%s", storageKey, contractHash.String(), storageSubKey,
+		timestamp.Nano(), txid.String())
+}
@@ -0,0 +1,110 @@
+package revenue
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestReport(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "revenue")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbConn, err := db.New(&db.StorageConfig{Bucket: "standalone", Root: dir})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contractAddress, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	entries := []struct {
+		at          time.Time
+		code        string
+		contractFee uint64
+		miningFee   uint64
+	}{
+		{time.Date(2020, 5, 31, 23, 0, 0, 0, time.UTC), "C2", 1000, 200},
+		{time.Date(2020, 6, 1, 1, 0, 0, 0, time.UTC), "T2", 1000, 300},
+		{time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC), "T2", 1000, 250},
+		{time.Date(2020, 6, 20, 12, 0, 0, 0, time.UTC), "M2", 0, 150},
+		{time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC), "A2", 500, 100},
+	}
+
+	for i, e := range entries {
+		txid := &bitcoin.Hash32{}
+		txid[0] = byte(i + 1)
+		if err := Save(ctx, dbConn, &Entry{
+			ContractAddress: contractAddress,
+			ActionCode:      e.code,
+			TxId:            txid,
+			ContractFee:     e.contractFee,
+			MiningFee:       e.miningFee,
+			Timestamp:       protocol.NewTimestamp(uint64(e.at.UnixNano())),
+		}); err != nil {
+			t.Fatalf("Failed to save entry %d : %s", i, err)
+		}
+	}
+
+	start := protocol.NewTimestamp(uint64(time.Date(2020, 6, 1, 0, 0, 0, 0,
+		time.UTC).UnixNano()))
+	end := protocol.NewTimestamp(uint64(time.Date(2020, 7, 1, 0, 0, 0, 0, time.UTC).UnixNano()))
+
+	report, err := BuildReport(ctx, dbConn, contractAddress, PeriodMonth, start, end)
+	if err != nil {
+		t.Fatalf("Failed to build report : %s", err)
+	}
+
+	if len(report.Periods) != 1 || report.Periods[0].Name != "2020-06" {
+		t.Fatalf("Wrong periods : %+v", report.Periods)
+	}
+	if report.Total.Responses != 3 || report.Total.ContractFee != 2000 ||
+		report.Total.MiningFee != 700 {
+		t.Fatalf("Wrong total : %+v", report.Total)
+	}
+	transfers := report.Periods[0].ByAction["T2"]
+	if transfers == nil || transfers.Responses != 2 || transfers.ContractFee != 2000 {
+		t.Fatalf("Wrong settlement totals : %+v", transfers)
+	}
+
+	report, err = BuildReport(ctx, dbConn, contractAddress, PeriodDay, protocol.NewTimestamp(0),
+		protocol.NewTimestamp(0))
+	if err != nil {
+		t.Fatalf("Failed to build report : %s", err)
+	}
+	if len(report.Periods) != 5 || report.Total.ContractFee != 3500 {
+		t.Fatalf("Wrong daily report : %d periods, %d contract fee", len(report.Periods),
+			report.Total.ContractFee)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("Failed to write CSV : %s", err)
+	}
+	if !strings.HasSuffix(buf.String(), "total,,5,3500,1000\n") {
+		t.Fatalf("Wrong CSV total : %s", buf.String())
+	}
+
+	if _, err := NewReport(contractAddress, "week", start, end, nil); err == nil {
+		t.Fatalf("Unsupported period accepted")
+	}
+}
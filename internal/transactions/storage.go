@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/logger"
@@ -50,6 +51,30 @@ func GetTx(ctx context.Context, masterDb *db.DB, txid *bitcoin.Hash32, isTest bo
 	return &result, nil
 }
 
+// List returns all txs in storage.
+func List(ctx context.Context, masterDb *db.DB, isTest bool) ([]*inspector.Transaction, error) {
+	keys, err := masterDb.List(ctx, storageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*inspector.Transaction, 0, len(keys))
+	for _, key := range keys {
+		txid, err := bitcoin.NewHash32FromStr(key[strings.LastIndex(key, "/")+1:])
+		if err != nil {
+			continue // Not a tx
+		}
+
+		itx, err := GetTx(ctx, masterDb, txid, isTest)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, itx)
+	}
+
+	return result, nil
+}
+
 // Returns the storage path prefix for a given identifier.
 func buildStoragePath(txid *bitcoin.Hash32) string {
 	return fmt.Sprintf("%s/%s", storageKey, txid.String())
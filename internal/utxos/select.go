@@ -0,0 +1,294 @@
+package utxos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+)
+
+// Strategy is a method of choosing which UTXOs to spend.
+type Strategy uint8
+
+const (
+	// StrategyFIFO spends the oldest UTXOs first.
+	StrategyFIFO = Strategy(0)
+
+	// StrategyLargestFirst spends the largest UTXOs first, using the fewest inputs.
+	StrategyLargestFirst = Strategy(1)
+
+	// StrategyBranchAndBound searches for a set of UTXOs that needs no change output. It falls back
+	//   to largest first when there isn't one.
+	StrategyBranchAndBound = Strategy(2)
+
+	// StrategyMinimizeChange spends the UTXOs that leave the least change.
+	StrategyMinimizeChange = Strategy(3)
+
+	// branchAndBoundTries limits the branch and bound search.
+	branchAndBoundTries = 100000
+)
+
+// SelectOptions specifies how UTXOs are selected.
+type SelectOptions struct {
+	Strategy Strategy
+
+	// MinConfirmations is the number of confirmations a UTXO needs to be selected. Height must be
+	//   set to the current block height when this is non-zero.
+	MinConfirmations int
+	Height           int
+
+	// CostOfChange is the value that would be lost to fees by adding a change output. Branch and
+	//   bound accepts selections up to this much over the amount.
+	CostOfChange uint64
+
+	// Reserve marks the selected UTXOs so they aren't selected again until they are spent,
+	//   released, or the reservation times out.
+	Reserve bool
+}
+
+// StrategyFromString returns the strategy with the specified name.
+func StrategyFromString(name string) (Strategy, error) {
+	switch strings.ToLower(name) {
+	case "", "fifo":
+		return StrategyFIFO, nil
+	case "largest":
+		return StrategyLargestFirst, nil
+	case "bnb":
+		return StrategyBranchAndBound, nil
+	case "minchange":
+		return StrategyMinimizeChange, nil
+	default:
+		return StrategyFIFO, fmt.Errorf("Unknown UTXO strategy : %s", name)
+	}
+}
+
+// Select returns available UTXOs for the address totaling at least the specified amount. UTXOs
+//   are available when they are unspent, unreserved, and have enough confirmations.
+func (us *UTXOs) Select(amount uint64, address bitcoin.RawAddress,
+	options SelectOptions) ([]*UTXO, error) {
+
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	now := time.Now()
+	available := us.available(address, options, now)
+
+	var result []*UTXO
+	switch options.Strategy {
+	case StrategyLargestFirst:
+		result = selectLargestFirst(available, amount)
+	case StrategyBranchAndBound:
+		result = selectBranchAndBound(available, amount, options.CostOfChange)
+		if result == nil {
+			result = selectLargestFirst(available, amount)
+		}
+	case StrategyMinimizeChange:
+		result = selectMinimizeChange(available, amount)
+	default:
+		result = selectFIFO(available, amount)
+	}
+
+	if result == nil {
+		return nil, ErrInsufficientFunds
+	}
+
+	if options.Reserve {
+		for _, utxo := range result {
+			utxo.reservedAt = now
+		}
+	}
+
+	return result, nil
+}
+
+// available returns the UTXOs for the address that can be selected, in FIFO order.
+func (us *UTXOs) available(address bitcoin.RawAddress, options SelectOptions,
+	now time.Time) []*UTXO {
+
+	timeout := us.ReservationTimeout
+	if timeout == 0 {
+		timeout = DefaultReservationTimeout
+	}
+
+	var result []*UTXO
+	for _, utxo := range us.byAddress[string(address.Bytes())] {
+		if utxo.isSpent() {
+			continue
+		}
+		if !utxo.reservedAt.IsZero() && now.Sub(utxo.reservedAt) < timeout {
+			continue
+		}
+		if options.MinConfirmations > 0 &&
+			utxo.Confirmations(options.Height) < options.MinConfirmations {
+			continue
+		}
+		result = append(result, utxo)
+	}
+
+	sortBySequence(result)
+	return result
+}
+
+func selectFIFO(available []*UTXO, amount uint64) []*UTXO {
+	total := uint64(0)
+	var result []*UTXO
+	for _, utxo := range available {
+		result = append(result, utxo)
+		total += uint64(utxo.Output.Value)
+		if total >= amount {
+			return result
+		}
+	}
+
+	return nil
+}
+
+func selectLargestFirst(available []*UTXO, amount uint64) []*UTXO {
+	sorted := make([]*UTXO, len(available))
+	copy(sorted, available)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value > sorted[j].Output.Value
+	})
+
+	return selectFIFO(sorted, amount)
+}
+
+// selectMinimizeChange uses the smallest single UTXO that covers the amount. Otherwise it takes
+//   the largest UTXOs needed and drops any that aren't needed, smallest first.
+func selectMinimizeChange(available []*UTXO, amount uint64) []*UTXO {
+	var best *UTXO
+	for _, utxo := range available {
+		if uint64(utxo.Output.Value) >= amount &&
+			(best == nil || utxo.Output.Value < best.Output.Value) {
+			best = utxo
+		}
+	}
+	if best != nil {
+		return []*UTXO{best}
+	}
+
+	result := selectLargestFirst(available, amount)
+	if result == nil {
+		return nil
+	}
+
+	total := uint64(0)
+	for _, utxo := range result {
+		total += uint64(utxo.Output.Value)
+	}
+
+	// Largest first is sorted descending so go backward to drop the smallest first.
+	for i := len(result) - 1; i >= 0; i-- {
+		value := uint64(result[i].Output.Value)
+		if total-value >= amount {
+			total -= value
+			result = append(result[:i], result[i+1:]...)
+		}
+	}
+
+	return result
+}
+
+// selectBranchAndBound does a depth first search for a set of UTXOs totaling between the amount
+//   and the amount plus the cost of change. It returns nil if there isn't one.
+func selectBranchAndBound(available []*UTXO, amount, costOfChange uint64) []*UTXO {
+	sorted := make([]*UTXO, len(available))
+	copy(sorted, available)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Output.Value > sorted[j].Output.Value
+	})
+
+	// remaining[i] is the total value of sorted[i:].
+	remaining := make([]uint64, len(sorted)+1)
+	for i := len(sorted) - 1; i >= 0; i-- {
+		remaining[i] = remaining[i+1] + uint64(sorted[i].Output.Value)
+	}
+	if remaining[0] < amount {
+		return nil
+	}
+
+	var best []bool
+	bestWaste := uint64(0)
+	included := make([]bool, len(sorted))
+	tries := 0
+
+	var search func(index int, total uint64)
+	search = func(index int, total uint64) {
+		tries++
+		if tries > branchAndBoundTries {
+			return
+		}
+
+		if total > amount+costOfChange {
+			return // Over the upper bound
+		}
+
+		if total >= amount {
+			waste := total - amount
+			if best == nil || waste < bestWaste {
+				best = make([]bool, len(included))
+				copy(best, included)
+				bestWaste = waste
+			}
+			return
+		}
+
+		if index == len(sorted) || total+remaining[index] < amount {
+			return // Can't reach the amount
+		}
+
+		included[index] = true
+		search(index+1, total+uint64(sorted[index].Output.Value))
+		included[index] = false
+
+		if best != nil && bestWaste == 0 {
+			return // Exact match
+		}
+		search(index+1, total)
+	}
+
+	search(0, 0)
+
+	if best == nil {
+		return nil
+	}
+
+	var result []*UTXO
+	for i, include := range best {
+		if include {
+			result = append(result, sorted[i])
+		}
+	}
+	return result
+}
+
+// SelectBelow returns up to limit available UTXOs for the address with values below the
+//   threshold, oldest first. It is used to consolidate small UTXOs. Strategy is ignored.
+func (us *UTXOs) SelectBelow(threshold uint64, limit int, address bitcoin.RawAddress,
+	options SelectOptions) []*UTXO {
+
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	now := time.Now()
+	var result []*UTXO
+	for _, utxo := range us.available(address, options, now) {
+		if uint64(utxo.Output.Value) >= threshold {
+			continue
+		}
+		result = append(result, utxo)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+
+	if options.Reserve {
+		for _, utxo := range result {
+			utxo.reservedAt = now
+		}
+	}
+
+	return result
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/smart-contract/internal/platform/db"
@@ -11,17 +12,36 @@ import (
 
 const (
 	storageKey = "utxos"
+
+	// versionMarker is written before the version. Version 0 data starts with the UTXO count,
+	//   which won't be this large.
+	versionMarker  = uint32(0xffffffff)
+	storageVersion = uint8(1)
 )
 
 func (us *UTXOs) Save(ctx context.Context, masterDb *db.DB) error {
+	us.lock.Lock()
+	list := us.list()
+	us.lock.Unlock()
+
 	var buf bytes.Buffer
 
-	count := uint32(len(us.list))
+	marker := versionMarker
+	if err := binary.Write(&buf, binary.LittleEndian, &marker); err != nil {
+		return err
+	}
+
+	version := storageVersion
+	if err := binary.Write(&buf, binary.LittleEndian, &version); err != nil {
+		return err
+	}
+
+	count := uint32(len(list))
 	if err := binary.Write(&buf, binary.LittleEndian, &count); err != nil {
 		return err
 	}
 
-	for _, utxo := range us.list {
+	for _, utxo := range list {
 		if err := utxo.Write(&buf); err != nil {
 			return err
 		}
@@ -31,11 +51,11 @@ func (us *UTXOs) Save(ctx context.Context, masterDb *db.DB) error {
 }
 
 func Load(ctx context.Context, masterDb *db.DB) (*UTXOs, error) {
-	result := UTXOs{}
+	result := New()
 	data, err := masterDb.Fetch(ctx, storageKey)
 	if err != nil {
 		if err == db.ErrNotFound {
-			return &result, nil // No UTXOs yet
+			return result, nil // No UTXOs yet
 		}
 		return nil, err
 	}
@@ -47,16 +67,28 @@ func Load(ctx context.Context, masterDb *db.DB) (*UTXOs, error) {
 		return nil, err
 	}
 
-	result.list = make([]*UTXO, count)
-	for i, _ := range result.list {
+	version := uint8(0)
+	if count == versionMarker {
+		if err := binary.Read(buf, binary.LittleEndian, &version); err != nil {
+			return nil, err
+		}
+		if version != storageVersion {
+			return nil, fmt.Errorf("Unknown UTXO storage version : %d", version)
+		}
+		if err := binary.Read(buf, binary.LittleEndian, &count); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := uint32(0); i < count; i++ {
 		utxo := UTXO{}
-		result.list[i] = &utxo
-		if err := utxo.Read(buf); err != nil {
+		if err := utxo.Read(buf, version); err != nil {
 			return nil, err
 		}
+		result.add(&utxo)
 	}
 
-	return &result, nil
+	return result, nil
 }
 
 func (utxo *UTXO) Write(buf *bytes.Buffer) error {
@@ -85,10 +117,15 @@ func (utxo *UTXO) Write(buf *bytes.Buffer) error {
 		return err
 	}
 
+	height := int32(utxo.Height)
+	if err := binary.Write(buf, binary.LittleEndian, &height); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (utxo *UTXO) Read(buf *bytes.Reader) error {
+func (utxo *UTXO) Read(buf *bytes.Reader, version uint8) error {
 	hash, err := bitcoin.DeserializeHash32(buf)
 	if err != nil {
 		return err
@@ -118,5 +155,13 @@ func (utxo *UTXO) Read(buf *bytes.Reader) error {
 		return err
 	}
 
+	if version > 0 {
+		var height int32
+		if err := binary.Read(buf, binary.LittleEndian, &height); err != nil {
+			return err
+		}
+		utxo.Height = int(height)
+	}
+
 	return nil
 }
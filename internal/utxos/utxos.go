@@ -1,113 +1,274 @@
 package utxos
 
 import (
-	"bytes"
 	"errors"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/wire"
 )
 
-var zeroTxId bitcoin.Hash32
+const (
+	// DefaultReservationTimeout is how long UTXOs stay reserved if they are never spent or
+	//   released, for example when a response fails to broadcast.
+	DefaultReservationTimeout = 10 * time.Minute
+)
+
+var (
+	// ErrInsufficientFunds occurs when there aren't enough available UTXOs for an amount.
+	ErrInsufficientFunds = errors.New("Not enough funds")
 
+	zeroTxId bitcoin.Hash32
+)
+
+// UTXOs is the set of outputs paying to contract addresses. It is indexed by outpoint and by
+//   address, and is safe for concurrent use.
 type UTXOs struct {
-	list []*UTXO
+	lock sync.Mutex
+
+	byOutPoint map[wire.OutPoint]*UTXO
+	byAddress  map[string]map[wire.OutPoint]*UTXO
+	byTx       map[bitcoin.Hash32][]*UTXO
+	sequence   uint64
+
+	// ReservationTimeout is how long a reservation lasts. Zero means DefaultReservationTimeout.
+	ReservationTimeout time.Duration
 }
 
 type UTXO struct {
 	OutPoint wire.OutPoint
 	Output   wire.TxOut
 	SpentBy  *bitcoin.Hash32 // Tx Id of transaction that spent utxo
+
+	// Height of the block containing the tx. Zero when unconfirmed.
+	Height int
+
+	address    bitcoin.RawAddress
+	sequence   uint64    // Order added, for FIFO selection
+	reservedAt time.Time // Zero when not reserved
+}
+
+// Confirmations returns the number of confirmations of the UTXO at the specified current block
+//   height.
+func (utxo *UTXO) Confirmations(height int) int {
+	if utxo.Height == 0 || height < utxo.Height {
+		return 0
+	}
+	return height - utxo.Height + 1
+}
+
+func (utxo *UTXO) isSpent() bool {
+	return utxo.SpentBy != nil && !utxo.SpentBy.Equal(&zeroTxId)
+}
+
+// New returns an empty UTXO set.
+func New() *UTXOs {
+	return &UTXOs{
+		byOutPoint: make(map[wire.OutPoint]*UTXO),
+		byAddress:  make(map[string]map[wire.OutPoint]*UTXO),
+		byTx:       make(map[bitcoin.Hash32][]*UTXO),
+	}
 }
 
 // Add adds/spends UTXOs based on the tx.
 func (us *UTXOs) Add(tx *wire.MsgTx, addresses []bitcoin.RawAddress) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
 	txHash := tx.TxHash()
 
-	// Check for payments to pkh
+	// Check for payments to contract addresses
 	for index, output := range tx.TxOut {
 		outputAddress, err := bitcoin.RawAddressFromLockingScript(output.PkScript)
-		if err != nil {
+		if err != nil || !containsAddress(addresses, outputAddress) {
 			continue
 		}
 
-		for _, address := range addresses {
-			if address.Equal(outputAddress) {
-				found := false
-
-				// Ensure not to duplicate
-				for _, existing := range us.list {
-					if bytes.Equal(existing.OutPoint.Hash[:], txHash[:]) &&
-						existing.OutPoint.Index == uint32(index) {
-						found = true
-						break
-					}
-				}
-
-				if !found {
-					// Add
-					newUTXO := UTXO{
-						OutPoint: wire.OutPoint{Hash: *txHash, Index: uint32(index)},
-						Output:   *output,
-						SpentBy:  &bitcoin.Hash32{},
-					}
-					us.list = append(us.list, &newUTXO)
-				}
-			}
+		outpoint := wire.OutPoint{Hash: *txHash, Index: uint32(index)}
+		if _, exists := us.byOutPoint[outpoint]; exists {
+			continue // Ensure not to duplicate
 		}
+
+		us.add(&UTXO{
+			OutPoint: outpoint,
+			Output:   *output,
+			SpentBy:  &bitcoin.Hash32{},
+		})
 	}
 
 	// Check for spends from UTXOs
 	for _, input := range tx.TxIn {
-		for _, existing := range us.list {
-			if bytes.Equal(input.PreviousOutPoint.Hash[:], existing.OutPoint.Hash[:]) &&
-				input.PreviousOutPoint.Index == existing.OutPoint.Index {
-				existing.SpentBy = txHash
-			}
+		if existing, exists := us.byOutPoint[input.PreviousOutPoint]; exists {
+			existing.SpentBy = txHash
+			existing.reservedAt = time.Time{}
 		}
 	}
 }
 
 // Remove removes UTXOs in the tx from the set.
 func (us *UTXOs) Remove(tx *wire.MsgTx, addresses []bitcoin.RawAddress) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	txHash := tx.TxHash()
 	for index, output := range tx.TxOut {
 		outputAddress, err := bitcoin.RawAddressFromLockingScript(output.PkScript)
-		if err != nil {
+		if err != nil || !containsAddress(addresses, outputAddress) {
 			continue
 		}
 
-		for _, address := range addresses {
-			if address.Equal(outputAddress) {
-				txHash := tx.TxHash()
-				// Ensure not to duplicate
-				for i, existing := range us.list {
-					if bytes.Equal(existing.OutPoint.Hash[:], txHash[:]) &&
-						existing.OutPoint.Index == uint32(index) {
-						us.list = append(us.list[:i], us.list[i+1:]...)
-					}
-				}
-			}
-		}
+		us.remove(wire.OutPoint{Hash: *txHash, Index: uint32(index)})
 	}
 }
 
-// Get returns UTXOs (FIFO) totaling at least the specified amount.
+// Confirm sets the block height of the UTXOs created by a tx.
+func (us *UTXOs) Confirm(txid bitcoin.Hash32, height int) {
+	us.setHeight(txid, height)
+}
+
+// Unconfirm clears the block height of the UTXOs created by a tx, when its block is reorged out.
+func (us *UTXOs) Unconfirm(txid bitcoin.Hash32) {
+	us.setHeight(txid, 0)
+}
+
+// Get returns available UTXOs (FIFO) totaling at least the specified amount.
 func (us *UTXOs) Get(amount uint64, address bitcoin.RawAddress) ([]*UTXO, error) {
-	resultAmount := uint64(0)
-	result := make([]*UTXO, 0, 5)
-	for _, existing := range us.list {
-		if bytes.Equal(existing.SpentBy[:], zeroTxId[:]) {
-			outputAddress, err := bitcoin.RawAddressFromLockingScript(existing.Output.PkScript)
-			if err != nil || !address.Equal(outputAddress) {
-				continue
-			}
-			result = append(result, existing)
-			resultAmount += uint64(existing.Output.Value)
-			if resultAmount > amount {
-				return result, nil
-			}
+	return us.Select(amount, address, SelectOptions{})
+}
+
+// Release removes the reservation from UTXOs so they can be selected again. It is used when the
+//   tx they were reserved for won't be sent.
+func (us *UTXOs) Release(utxos []*UTXO) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	for _, utxo := range utxos {
+		if existing, exists := us.byOutPoint[utxo.OutPoint]; exists {
+			existing.reservedAt = time.Time{}
+		}
+	}
+}
+
+// Balance returns the total value of unspent UTXOs for the address.
+func (us *UTXOs) Balance(address bitcoin.RawAddress) uint64 {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	result := uint64(0)
+	for _, utxo := range us.byAddress[string(address.Bytes())] {
+		if !utxo.isSpent() {
+			result += uint64(utxo.Output.Value)
+		}
+	}
+	return result
+}
+
+// Unspent returns the unspent UTXOs for the address in the order they were added.
+func (us *UTXOs) Unspent(address bitcoin.RawAddress) []*UTXO {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	var result []*UTXO
+	for _, utxo := range us.byAddress[string(address.Bytes())] {
+		if !utxo.isSpent() {
+			result = append(result, utxo)
+		}
+	}
+
+	sortBySequence(result)
+	return result
+}
+
+func (us *UTXOs) add(utxo *UTXO) {
+	if us.byOutPoint == nil {
+		us.byOutPoint = make(map[wire.OutPoint]*UTXO)
+		us.byAddress = make(map[string]map[wire.OutPoint]*UTXO)
+		us.byTx = make(map[bitcoin.Hash32][]*UTXO)
+	}
+
+	address, err := bitcoin.RawAddressFromLockingScript(utxo.Output.PkScript)
+	if err == nil {
+		utxo.address = address
+	}
+
+	us.sequence++
+	utxo.sequence = us.sequence
+	us.byOutPoint[utxo.OutPoint] = utxo
+
+	key := string(utxo.address.Bytes())
+	addressUTXOs, exists := us.byAddress[key]
+	if !exists {
+		addressUTXOs = make(map[wire.OutPoint]*UTXO)
+		us.byAddress[key] = addressUTXOs
+	}
+	addressUTXOs[utxo.OutPoint] = utxo
+
+	us.byTx[utxo.OutPoint.Hash] = append(us.byTx[utxo.OutPoint.Hash], utxo)
+}
+
+func (us *UTXOs) remove(outpoint wire.OutPoint) {
+	utxo, exists := us.byOutPoint[outpoint]
+	if !exists {
+		return
+	}
+
+	delete(us.byOutPoint, outpoint)
+
+	key := string(utxo.address.Bytes())
+	if addressUTXOs, exists := us.byAddress[key]; exists {
+		delete(addressUTXOs, outpoint)
+		if len(addressUTXOs) == 0 {
+			delete(us.byAddress, key)
 		}
 	}
 
-	return result, errors.New("Not enough funds")
+	txUTXOs := us.byTx[outpoint.Hash]
+	for i, txUTXO := range txUTXOs {
+		if txUTXO == utxo {
+			txUTXOs = append(txUTXOs[:i], txUTXOs[i+1:]...)
+			break
+		}
+	}
+	if len(txUTXOs) == 0 {
+		delete(us.byTx, outpoint.Hash)
+	} else {
+		us.byTx[outpoint.Hash] = txUTXOs
+	}
+}
+
+func (us *UTXOs) setHeight(txid bitcoin.Hash32, height int) {
+	us.lock.Lock()
+	defer us.lock.Unlock()
+
+	for _, utxo := range us.byTx[txid] {
+		utxo.Height = height
+	}
+}
+
+// list returns all UTXOs in the order they were added.
+func (us *UTXOs) list() []*UTXO {
+	result := make([]*UTXO, 0, len(us.byOutPoint))
+	for _, utxo := range us.byOutPoint {
+		result = append(result, utxo)
+	}
+
+	sortBySequence(result)
+	return result
+}
+
+func sortBySequence(utxos []*UTXO) {
+	sort.Slice(utxos, func(i, j int) bool {
+		return utxos[i].sequence < utxos[j].sequence
+	})
+}
+
+func containsAddress(addresses []bitcoin.RawAddress, address bitcoin.RawAddress) bool {
+	for _, a := range addresses {
+		if a.Equal(address) {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,224 @@
+package utxos
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/wire"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+)
+
+func TestSelect(t *testing.T) {
+	address, lockingScript := testAddress(t)
+
+	tests := []struct {
+		name     string
+		values   []uint64
+		amount   uint64
+		strategy Strategy
+		want     []uint64
+	}{
+		{"fifo exact", []uint64{1000, 2000, 3000}, 3000, StrategyFIFO, []uint64{1000, 2000}},
+		{"fifo", []uint64{1000, 2000, 3000}, 3500, StrategyFIFO, []uint64{1000, 2000, 3000}},
+		{"largest", []uint64{1000, 2000, 3000}, 3500, StrategyLargestFirst, []uint64{3000, 2000}},
+		{"minchange single", []uint64{5000, 1200, 3000}, 1100, StrategyMinimizeChange,
+			[]uint64{1200}},
+		{"minchange multiple", []uint64{1000, 2000, 3000, 500}, 4600, StrategyMinimizeChange,
+			[]uint64{3000, 2000}},
+		{"bnb exact", []uint64{5000, 1500, 2500, 700}, 4000, StrategyBranchAndBound,
+			[]uint64{2500, 1500}},
+		{"bnb fallback", []uint64{5000, 3000}, 4000, StrategyBranchAndBound, []uint64{5000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			us := New()
+			us.Add(testTx(lockingScript, tt.values...), []bitcoin.RawAddress{address})
+
+			selected, err := us.Select(tt.amount, address, SelectOptions{
+				Strategy:     tt.strategy,
+				CostOfChange: 50,
+			})
+			if err != nil {
+				t.Fatalf("Failed to select : %s", err)
+			}
+
+			if len(selected) != len(tt.want) {
+				t.Fatalf("Wrong selection count : got %d, want %d", len(selected), len(tt.want))
+			}
+			for i, utxo := range selected {
+				if utxo.Output.Value != tt.want[i] {
+					t.Errorf("Wrong value %d : got %d, want %d", i, utxo.Output.Value, tt.want[i])
+				}
+			}
+		})
+	}
+
+	us := New()
+	us.Add(testTx(lockingScript, 1000), []bitcoin.RawAddress{address})
+	if _, err := us.Get(1001, address); err != ErrInsufficientFunds {
+		t.Fatalf("Wrong error for insufficient funds : %v", err)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	address, lockingScript := testAddress(t)
+
+	us := New()
+	tx := testTx(lockingScript, 1000, 2000)
+	us.Add(tx, []bitcoin.RawAddress{address})
+
+	first, err := us.Select(1000, address, SelectOptions{Reserve: true})
+	if err != nil {
+		t.Fatalf("Failed to select : %s", err)
+	}
+
+	second, err := us.Select(1000, address, SelectOptions{Reserve: true})
+	if err != nil {
+		t.Fatalf("Failed to select : %s", err)
+	}
+	if second[0].OutPoint == first[0].OutPoint {
+		t.Fatalf("Reserved UTXO selected again")
+	}
+
+	if _, err := us.Select(1000, address, SelectOptions{}); err != ErrInsufficientFunds {
+		t.Fatalf("Wrong error with all reserved : %v", err)
+	}
+
+	us.Release(first)
+	if _, err := us.Select(1000, address, SelectOptions{}); err != nil {
+		t.Fatalf("Failed to select released : %s", err)
+	}
+
+	// Reservations expire.
+	us.ReservationTimeout = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, err := us.Select(3000, address, SelectOptions{}); err != nil {
+		t.Fatalf("Failed to select after reservation timeout : %s", err)
+	}
+
+	// Spending clears the UTXO from selection.
+	spend := wire.NewMsgTx(1)
+	spend.AddTxIn(wire.NewTxIn(&second[0].OutPoint, nil))
+	us.Add(spend, []bitcoin.RawAddress{address})
+	if us.Balance(address) != 1000 {
+		t.Fatalf("Wrong balance after spend : %d", us.Balance(address))
+	}
+}
+
+func TestConfirmations(t *testing.T) {
+	address, lockingScript := testAddress(t)
+
+	us := New()
+	tx := testTx(lockingScript, 1000)
+	us.Add(tx, []bitcoin.RawAddress{address})
+
+	options := SelectOptions{MinConfirmations: 2, Height: 100}
+	if _, err := us.Select(1000, address, options); err != ErrInsufficientFunds {
+		t.Fatalf("Selected unconfirmed UTXO : %v", err)
+	}
+
+	us.Confirm(*tx.TxHash(), 100)
+	if _, err := us.Select(1000, address, options); err != ErrInsufficientFunds {
+		t.Fatalf("Selected UTXO with 1 confirmation : %v", err)
+	}
+
+	options.Height = 101
+	if _, err := us.Select(1000, address, options); err != nil {
+		t.Fatalf("Failed to select UTXO with 2 confirmations : %s", err)
+	}
+
+	us.Unconfirm(*tx.TxHash())
+	if _, err := us.Select(1000, address, options); err != ErrInsufficientFunds {
+		t.Fatalf("Selected reorged UTXO : %v", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	address, lockingScript := testAddress(t)
+
+	dir, err := ioutil.TempDir("", "utxos")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbConn, err := db.New(&db.StorageConfig{Bucket: "standalone", Root: dir})
+	if err != nil {
+		t.Fatalf("Failed to create DB : %s", err)
+	}
+
+	us := New()
+	tx := testTx(lockingScript, 1000, 2000)
+	us.Add(tx, []bitcoin.RawAddress{address})
+	us.Confirm(*tx.TxHash(), 50)
+
+	if err := us.Save(ctx, dbConn); err != nil {
+		t.Fatalf("Failed to save : %s", err)
+	}
+
+	loaded, err := Load(ctx, dbConn)
+	if err != nil {
+		t.Fatalf("Failed to load : %s", err)
+	}
+
+	unspent := loaded.Unspent(address)
+	if len(unspent) != 2 {
+		t.Fatalf("Wrong loaded count : %d", len(unspent))
+	}
+	for i, utxo := range unspent {
+		if utxo.Output.Value != tx.TxOut[i].Value || utxo.Height != 50 {
+			t.Errorf("Wrong loaded UTXO %d : value %d, height %d", i, utxo.Output.Value,
+				utxo.Height)
+		}
+	}
+}
+
+func testAddress(t *testing.T) (bitcoin.RawAddress, []byte) {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	address, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	lockingScript, err := address.LockingScript()
+	if err != nil {
+		t.Fatalf("Failed to create locking script : %s", err)
+	}
+
+	return address, lockingScript
+}
+
+func testTx(lockingScript []byte, values ...uint64) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	for _, value := range values {
+		tx.AddTxOut(wire.NewTxOut(value, lockingScript))
+	}
+	return tx
+}
+
+func TestSelectBelow(t *testing.T) {
+	address, lockingScript := testAddress(t)
+
+	us := New()
+	us.Add(testTx(lockingScript, 100, 5000, 200, 300, 400), []bitcoin.RawAddress{address})
+
+	selected := us.SelectBelow(1000, 2, address, SelectOptions{Reserve: true})
+	if len(selected) != 2 || selected[0].Output.Value != 100 || selected[1].Output.Value != 200 {
+		t.Fatalf("Wrong selection : %v", selected)
+	}
+
+	selected = us.SelectBelow(1000, 0, address, SelectOptions{})
+	if len(selected) != 2 || selected[0].Output.Value != 300 || selected[1].Output.Value != 400 {
+		t.Fatalf("Wrong selection with reserved : %v", selected)
+	}
+}
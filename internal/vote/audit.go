@@ -0,0 +1,415 @@
+package vote
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/db"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/smart-contract/internal/transactions"
+
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// AuditEntry is an eligible voter in a vote audit report.
+type AuditEntry struct {
+	Address     bitcoin.RawAddress `json:"Address"`
+	Quantity    uint64             `json:"Quantity"`
+	TxId        *protocol.TxId     `json:"TxId,omitempty"` // Ballot cast tx, nil if not cast
+	Vote        string             `json:"Vote,omitempty"`
+	CastByProxy bool               `json:"CastByProxy,omitempty"`
+	Scores      []uint64           `json:"Scores,omitempty"` // Counted for each vote option
+	Leaf        bitcoin.Hash32     `json:"Leaf"`
+}
+
+// AuditReport lists every ballot of a vote with what it counted for, and the merkle root over
+//   them so each holder can prove their ballot is included.
+type AuditReport struct {
+	VoteTxId    *protocol.TxId `json:"VoteTxId"`
+	VoteOptions string         `json:"VoteOptions"`
+	TokenQty    uint64         `json:"TokenQty"`
+	Turnout     uint64         `json:"Turnout"`
+	OptionTally []uint64       `json:"OptionTally,omitempty"`
+	Result      string         `json:"Result,omitempty"`
+	BallotRoot  bitcoin.Hash32 `json:"BallotRoot"`
+	Entries     []*AuditEntry  `json:"Entries"`
+}
+
+// NewAuditReport builds the audit report of a vote from its stored ballots. Scores are left
+//   empty for instant runoff votes, since a ballot's score depends on the round. The rounds are
+//   kept with the vote.
+func NewAuditReport(vt *state.Vote, proposal *actions.Proposal,
+	votingSystem *actions.VotingSystemField) (*AuditReport, error) {
+
+	result := &AuditReport{
+		VoteTxId:    vt.VoteTxId,
+		VoteOptions: proposal.VoteOptions,
+		TokenQty:    vt.TokenQty,
+		Turnout:     Turnout(vt),
+		OptionTally: vt.OptionTally,
+		Result:      vt.Result,
+	}
+
+	for _, ballot := range SortedBallots(vt) {
+		entry := &AuditEntry{
+			Address:     ballot.Address,
+			Quantity:    ballot.Quantity,
+			TxId:        ballot.TxId,
+			Vote:        ballot.Vote,
+			CastByProxy: ballot.CastByProxy,
+		}
+
+		leaf, err := BallotHash(ballot)
+		if err != nil {
+			return nil, errors.Wrap(err, "ballot hash")
+		}
+		entry.Leaf = *leaf
+
+		if len(ballot.Vote) > 0 && votingSystem.TallyLogic != TallyLogicInstantRunoff {
+			scores := make([]float32, len(proposal.VoteOptions))
+			addScores(scores, ballot, proposal, votingSystem)
+			for _, score := range scores {
+				entry.Scores = append(entry.Scores, uint64(score))
+			}
+		}
+
+		result.Entries = append(result.Entries, entry)
+	}
+
+	leaves := make([]bitcoin.Hash32, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		leaves = append(leaves, entry.Leaf)
+	}
+	result.BallotRoot = MerkleRoot(leaves)
+
+	return result, nil
+}
+
+// Proof returns the index of an address's entry and the merkle proof that it is included in the
+//   ballot root.
+func (r *AuditReport) Proof(address bitcoin.RawAddress) (int, []bitcoin.Hash32, error) {
+	leaves := make([]bitcoin.Hash32, 0, len(r.Entries))
+	index := -1
+	for i, entry := range r.Entries {
+		if entry.Address.Equal(address) {
+			index = i
+		}
+		leaves = append(leaves, entry.Leaf)
+	}
+	if index == -1 {
+		return 0, nil, ErrNotFound
+	}
+
+	proof, err := MerkleProof(leaves, index)
+	if err != nil {
+		return 0, nil, errors.Wrap(err, "merkle proof")
+	}
+
+	return index, proof, nil
+}
+
+// WriteCSV writes the entries as CSV with a header row, a score column for each vote option, and
+//   a final row with the ballot root.
+func (r *AuditReport) WriteCSV(w io.Writer, net bitcoin.Network) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"Address", "Quantity", "TxId", "Vote", "CastByProxy"}
+	for _, option := range r.VoteOptions {
+		header = append(header, fmt.Sprintf("Score %c", option))
+	}
+	header = append(header, "Leaf")
+	if err := cw.Write(header); err != nil {
+		return errors.Wrap(err, "write header")
+	}
+
+	for _, entry := range r.Entries {
+		txid := ""
+		if entry.TxId != nil {
+			txid = entry.TxId.String()
+		}
+
+		row := []string{
+			bitcoin.NewAddressFromRawAddress(entry.Address, net).String(),
+			strconv.FormatUint(entry.Quantity, 10),
+			txid,
+			entry.Vote,
+			strconv.FormatBool(entry.CastByProxy),
+		}
+		for i := range r.VoteOptions {
+			if i < len(entry.Scores) {
+				row = append(row, strconv.FormatUint(entry.Scores[i], 10))
+			} else {
+				row = append(row, "")
+			}
+		}
+		row = append(row, entry.Leaf.String())
+
+		if err := cw.Write(row); err != nil {
+			return errors.Wrap(err, "write entry")
+		}
+	}
+
+	row := make([]string, len(header))
+	row[0] = "BallotRoot"
+	row[len(row)-1] = r.BallotRoot.String()
+	if err := cw.Write(row); err != nil {
+		return errors.Wrap(err, "write root")
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// SortedBallots returns the ballots of a vote in the order of their address bytes, which is the
+//   order of the leaves of the ballot merkle tree.
+func SortedBallots(vt *state.Vote) []state.Ballot {
+	result := make([]state.Ballot, 0, len(vt.Ballots))
+	for _, ballot := range vt.Ballots {
+		result = append(result, ballot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Address.Bytes(), result[j].Address.Bytes()) < 0
+	})
+	return result
+}
+
+// BallotRoot returns the merkle root over the ballots of a vote.
+func BallotRoot(vt *state.Vote) (*bitcoin.Hash32, error) {
+	var leaves []bitcoin.Hash32
+	for _, ballot := range SortedBallots(vt) {
+		leaf, err := BallotHash(ballot)
+		if err != nil {
+			return nil, errors.Wrap(err, "ballot hash")
+		}
+		leaves = append(leaves, *leaf)
+	}
+
+	root := MerkleRoot(leaves)
+	return &root, nil
+}
+
+// BallotHash returns the merkle leaf of a ballot. It is the double SHA256 of the address,
+//   quantity, ballot cast txid, and choices. Ballots that weren't cast have a zero txid and no
+//   choices.
+func BallotHash(ballot state.Ballot) (*bitcoin.Hash32, error) {
+	var buf bytes.Buffer
+
+	address := ballot.Address.Bytes()
+	if err := binary.Write(&buf, binary.LittleEndian, uint32(len(address))); err != nil {
+		return nil, errors.Wrap(err, "address size")
+	}
+	buf.Write(address)
+
+	if err := binary.Write(&buf, binary.LittleEndian, ballot.Quantity); err != nil {
+		return nil, errors.Wrap(err, "quantity")
+	}
+
+	if ballot.TxId != nil {
+		buf.Write(ballot.TxId.Bytes())
+	} else {
+		buf.Write(make([]byte, bitcoin.Hash32Size))
+	}
+
+	if err := binary.Write(&buf, binary.LittleEndian, uint32(len(ballot.Vote))); err != nil {
+		return nil, errors.Wrap(err, "vote size")
+	}
+	buf.WriteString(ballot.Vote)
+
+	return bitcoin.NewHash32(bitcoin.DoubleSha256(buf.Bytes()))
+}
+
+// MerkleRoot returns the root of a merkle tree over the leaves. Like a block's merkle tree, the
+//   last hash of a level with an odd count is paired with itself. The root of no leaves is zero.
+func MerkleRoot(leaves []bitcoin.Hash32) bitcoin.Hash32 {
+	if len(leaves) == 0 {
+		return bitcoin.Hash32{}
+	}
+
+	level := leaves
+	for len(level) > 1 {
+		level = merkleLevel(level)
+	}
+	return level[0]
+}
+
+// MerkleProof returns the hashes that combine with the leaf at index to produce the root.
+func MerkleProof(leaves []bitcoin.Hash32, index int) ([]bitcoin.Hash32, error) {
+	if index < 0 || index >= len(leaves) {
+		return nil, fmt.Errorf("Leaf index out of range : %d", index)
+	}
+
+	var result []bitcoin.Hash32
+	level := leaves
+	for len(level) > 1 {
+		sibling := index ^ 1
+		if sibling >= len(level) {
+			sibling = index
+		}
+		result = append(result, level[sibling])
+
+		level = merkleLevel(level)
+		index /= 2
+	}
+
+	return result, nil
+}
+
+// VerifyMerkleProof returns true if the proof combines the leaf at index into the root.
+func VerifyMerkleProof(leaf bitcoin.Hash32, index int, proof []bitcoin.Hash32,
+	root bitcoin.Hash32) bool {
+
+	hash := leaf
+	for _, sibling := range proof {
+		if index%2 == 0 {
+			hash = merkleParent(hash, sibling)
+		} else {
+			hash = merkleParent(sibling, hash)
+		}
+		index /= 2
+	}
+
+	return hash.Equal(&root)
+}
+
+func merkleLevel(level []bitcoin.Hash32) []bitcoin.Hash32 {
+	result := make([]bitcoin.Hash32, 0, (len(level)+1)/2)
+	for i := 0; i < len(level); i += 2 {
+		if i+1 < len(level) {
+			result = append(result, merkleParent(level[i], level[i+1]))
+		} else {
+			result = append(result, merkleParent(level[i], level[i]))
+		}
+	}
+	return result
+}
+
+func merkleParent(left, right bitcoin.Hash32) bitcoin.Hash32 {
+	var result bitcoin.Hash32
+	copy(result[:], bitcoin.DoubleSha256(append(left.Bytes(), right.Bytes()...)))
+	return result
+}
+
+// VerifyAudit recomputes the tally of a completed vote from its stored ballot cast txs. It returns
+//   a description of each ballot that doesn't match its tx, and of any difference between the
+//   recomputed and recorded results.
+func VerifyAudit(ctx context.Context, dbConn *db.DB, vt *state.Vote, proposal *actions.Proposal,
+	votingSystem *actions.VotingSystemField, isTest bool) ([]string, error) {
+
+	var result []string
+
+	recount := &state.Vote{
+		TokenQty: vt.TokenQty,
+		Quorum:   vt.Quorum,
+		Ballots:  make(map[bitcoin.Hash20]state.Ballot),
+	}
+
+	for hash, ballot := range vt.Ballots {
+		counted := state.Ballot{
+			Address:  ballot.Address,
+			Quantity: ballot.Quantity,
+		}
+
+		if len(ballot.Vote) > 0 {
+			vote, err := verifyBallot(ctx, dbConn, vt, ballot, isTest)
+			if err != nil {
+				return nil, errors.Wrap(err, "verify ballot")
+			}
+			if len(vote) == 0 {
+				result = append(result, fmt.Sprintf("Ballot %x not verified by its tx",
+					ballot.Address.Bytes()))
+			}
+			counted.Vote = vote
+		}
+
+		recount.Ballots[hash] = counted
+	}
+
+	tally, outcome, err := CalculateResults(ctx, recount, proposal, votingSystem)
+	if err != nil {
+		return nil, errors.Wrap(err, "calculate results")
+	}
+
+	if vt.CompletedAt.Nano() != 0 {
+		if !equalTallys(tally, vt.OptionTally) {
+			result = append(result, fmt.Sprintf("Recomputed tally %v doesn't match %v", tally,
+				vt.OptionTally))
+		}
+		if outcome != vt.Result {
+			result = append(result, fmt.Sprintf("Recomputed result \"%s\" doesn't match \"%s\"",
+				outcome, vt.Result))
+		}
+	}
+
+	if vt.BallotRoot != nil {
+		root, err := BallotRoot(vt)
+		if err != nil {
+			return nil, errors.Wrap(err, "ballot root")
+		}
+		if !root.Equal(vt.BallotRoot) {
+			result = append(result, fmt.Sprintf("Ballot root %s doesn't match %s", root.String(),
+				vt.BallotRoot.String()))
+		}
+	}
+
+	return result, nil
+}
+
+func equalTallys(l, r []uint64) bool {
+	if len(l) != len(r) {
+		return false
+	}
+	for i := range l {
+		if l[i] != r[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyBallot returns the choices of a ballot's cast tx, or an empty string if the tx is missing
+//   or doesn't match the ballot.
+func verifyBallot(ctx context.Context, dbConn *db.DB, vt *state.Vote, ballot state.Ballot,
+	isTest bool) (string, error) {
+
+	if ballot.TxId == nil {
+		return "", nil
+	}
+
+	hash, err := bitcoin.NewHash32(ballot.TxId.Bytes())
+	if err != nil {
+		return "", errors.Wrap(err, "tx hash")
+	}
+
+	castTx, err := transactions.GetTx(ctx, dbConn, hash, isTest)
+	if err == transactions.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "get tx")
+	}
+
+	cast, ok := castTx.MsgProto.(*actions.BallotCast)
+	if !ok || !bytes.Equal(cast.VoteTxId, vt.VoteTxId.Bytes()) || cast.Vote != ballot.Vote {
+		return "", nil
+	}
+
+	caster := ballot.Address
+	if ballot.CastByProxy {
+		caster = ballot.Proxy
+	}
+	if !castTx.Inputs[0].Address.Equal(caster) {
+		return "", nil
+	}
+
+	return cast.Vote, nil
+}
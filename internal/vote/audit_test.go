@@ -0,0 +1,126 @@
+package vote
+
+import (
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestMerkleProof(t *testing.T) {
+	for count := 1; count <= 9; count++ {
+		leaves := make([]bitcoin.Hash32, count)
+		for i := range leaves {
+			leaves[i][0] = byte(i + 1)
+		}
+		root := MerkleRoot(leaves)
+
+		for i := range leaves {
+			proof, err := MerkleProof(leaves, i)
+			if err != nil {
+				t.Fatalf("Failed to create proof : %s", err)
+			}
+			if !VerifyMerkleProof(leaves[i], i, proof, root) {
+				t.Fatalf("Proof %d of %d didn't verify", i, count)
+			}
+
+			wrong := leaves[i]
+			wrong[1] = 0xff
+			if VerifyMerkleProof(wrong, i, proof, root) {
+				t.Fatalf("Wrong leaf %d of %d verified", i, count)
+			}
+		}
+	}
+}
+
+func TestAuditReport(t *testing.T) {
+	proposal := &actions.Proposal{
+		VoteOptions: "ABC",
+		VoteMax:     2,
+	}
+
+	votingSystem := &actions.VotingSystemField{
+		VoteType:            "R",
+		TallyLogic:          TallyLogicWeighted,
+		ThresholdPercentage: 50,
+	}
+
+	vt := &state.Vote{
+		TokenQty: 1000,
+		Ballots:  make(map[bitcoin.Hash20]state.Ballot),
+	}
+	ballots := []state.Ballot{
+		{Address: generateAddress(t), Quantity: 600, Vote: "AB",
+			TxId: protocol.TxIdFromBytes(make([]byte, 32))},
+		{Address: generateAddress(t), Quantity: 300, Vote: "C",
+			TxId: protocol.TxIdFromBytes(make([]byte, 32))},
+		{Address: generateAddress(t), Quantity: 100},
+	}
+	for _, ballot := range ballots {
+		hash, err := ballot.Address.Hash()
+		if err != nil {
+			t.Fatalf("Failed to hash address : %s", err)
+		}
+		vt.Ballots[*hash] = ballot
+	}
+
+	report, err := NewAuditReport(vt, proposal, votingSystem)
+	if err != nil {
+		t.Fatalf("Failed to create report : %s", err)
+	}
+
+	if len(report.Entries) != len(ballots) || report.Turnout != 900 {
+		t.Fatalf("Wrong report : %d entries, turnout %d", len(report.Entries), report.Turnout)
+	}
+
+	root, err := BallotRoot(vt)
+	if err != nil {
+		t.Fatalf("Failed to calculate root : %s", err)
+	}
+	if !root.Equal(&report.BallotRoot) {
+		t.Fatalf("Wrong root : got %s, want %s", report.BallotRoot.String(), root.String())
+	}
+
+	for _, ballot := range ballots {
+		index, proof, err := report.Proof(ballot.Address)
+		if err != nil {
+			t.Fatalf("Failed to create proof : %s", err)
+		}
+
+		entry := report.Entries[index]
+		if !VerifyMerkleProof(entry.Leaf, index, proof, *root) {
+			t.Fatalf("Proof didn't verify")
+		}
+
+		switch entry.Vote {
+		case "AB": // Weighted second choice counts half
+			if entry.Scores[0] != 600 || entry.Scores[1] != 300 || entry.Scores[2] != 0 {
+				t.Fatalf("Wrong scores : %v", entry.Scores)
+			}
+		case "C":
+			if entry.Scores[2] != 300 {
+				t.Fatalf("Wrong scores : %v", entry.Scores)
+			}
+		case "":
+			if len(entry.Scores) != 0 {
+				t.Fatalf("Uncast ballot has scores : %v", entry.Scores)
+			}
+		}
+	}
+
+	// Changing a counted ballot changes the root.
+	changed := ballots[0]
+	changed.Vote = "BA"
+	hash, _ := changed.Address.Hash()
+	vt.Ballots[*hash] = changed
+	changedRoot, err := BallotRoot(vt)
+	if err != nil {
+		t.Fatalf("Failed to calculate root : %s", err)
+	}
+	if changedRoot.Equal(root) {
+		t.Fatalf("Root didn't change with ballot")
+	}
+}
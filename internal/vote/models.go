@@ -20,6 +20,8 @@ type NewVote struct {
 	VoteTxId     protocol.TxId      `json:"VoteTxId,omitempty"`
 	ProposalTxId protocol.TxId      `json:"ProposalTxId,omitempty"`
 	TokenQty     uint64             `json:"TokenQty,omitempty"`
+	Quorum       uint32             `json:"Quorum,omitempty"`
+	EarlyClose   bool               `json:"EarlyClose,omitempty"`
 	Expires      protocol.Timestamp `json:"Expires,omitempty"`
 	Timestamp    protocol.Timestamp `json:"Timestamp,omitempty"`
 
@@ -31,6 +33,13 @@ type UpdateVote struct {
 	CompletedAt *protocol.Timestamp `json:"CompletedAt,omitempty"`
 	AppliedTxId *protocol.TxId      `json:"AppliedTxId,omitempty"`
 	OptionTally *[]uint64           `json:"OptionTally,omitempty"`
+	Rounds      *[]state.VoteRound  `json:"Rounds,omitempty"`
+	Turnout     *uint64             `json:"Turnout,omitempty"`
+	NoQuorum    *bool               `json:"NoQuorum,omitempty"`
 	Result      *string             `json:"Result,omitempty"`
 	NewBallot   *state.Ballot       `json:"NewBallot,omitempty"`
+
+	ClosedEarlyAt *protocol.Timestamp `json:"ClosedEarlyAt,omitempty"`
+	CloseReason   *string             `json:"CloseReason,omitempty"`
+	BallotRoot    *bitcoin.Hash32     `json:"BallotRoot,omitempty"`
 }
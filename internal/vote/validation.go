@@ -2,6 +2,7 @@ package vote
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/tokenized/specification/dist/golang/actions"
@@ -14,6 +15,10 @@ func ValidateProposal(msg *actions.Proposal, now protocol.Timestamp) error {
 		return errors.New("No vote options")
 	}
 
+	if strings.Contains(msg.VoteOptions, ResultNoQuorum) {
+		return fmt.Errorf("Vote options contain no quorum result : %s", ResultNoQuorum)
+	}
+
 	if msg.VoteMax == 0 {
 		return errors.New("Zero vote max")
 	}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
+	"sort"
 
 	"github.com/tokenized/pkg/bitcoin"
 	"github.com/tokenized/pkg/logger"
@@ -23,6 +25,17 @@ var (
 	ErrNotFound = errors.New("Vote not found")
 )
 
+// Tally logic of voting systems.
+const (
+	TallyLogicStandard      = 0
+	TallyLogicWeighted      = 1
+	TallyLogicInstantRunoff = 2 // Ballots rank options. The lowest options are eliminated in rounds.
+)
+
+// ResultNoQuorum is the result of a vote whose turnout was below its quorum. It isn't a valid vote
+//   option, so it can be told apart from the empty result of a vote in which no option passed.
+const ResultNoQuorum = "-"
+
 // Retrieve gets the specified vote from the database.
 func Retrieve(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddress, voteID *protocol.TxId) (*state.Vote, error) {
 	ctx, span := trace.StartSpan(ctx, "internal.vote.Retrieve")
@@ -85,9 +98,27 @@ func Update(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAddre
 	if uv.OptionTally != nil {
 		v.OptionTally = *uv.OptionTally
 	}
+	if uv.Rounds != nil {
+		v.Rounds = *uv.Rounds
+	}
+	if uv.Turnout != nil {
+		v.Turnout = *uv.Turnout
+	}
+	if uv.NoQuorum != nil {
+		v.NoQuorum = *uv.NoQuorum
+	}
 	if uv.AppliedTxId != nil {
 		v.AppliedTxId = uv.AppliedTxId
 	}
+	if uv.ClosedEarlyAt != nil {
+		v.ClosedEarlyAt = *uv.ClosedEarlyAt
+	}
+	if uv.CloseReason != nil {
+		v.CloseReason = *uv.CloseReason
+	}
+	if uv.BallotRoot != nil {
+		v.BallotRoot = uv.BallotRoot
+	}
 	if uv.NewBallot != nil {
 		hash, err := uv.NewBallot.Address.Hash()
 		if err != nil {
@@ -136,36 +167,80 @@ func AddBallot(ctx context.Context, dbConn *db.DB, contractAddress bitcoin.RawAd
 	return nil
 }
 
-// CalculateResults calculates the result of a completed vote.
+// CastableBallots returns the ballots an address can cast. These are its own ballot, unless it
+//   already cast it, and the ballots delegated to it that haven't been cast. A holder's own ballot
+//   overrides one cast by their proxy.
+func CastableBallots(vt *state.Vote, address bitcoin.RawAddress) []state.Ballot {
+	var result []state.Ballot
+	for _, ballot := range vt.Ballots {
+		if ballot.Address.Equal(address) {
+			if ballot.Timestamp.Nano() == 0 || ballot.CastByProxy {
+				result = append(result, ballot)
+			}
+			continue
+		}
+
+		if ballot.Proxy.Equal(address) && ballot.Timestamp.Nano() == 0 {
+			result = append(result, ballot)
+		}
+	}
+
+	return result
+}
+
+// IsVoter returns true if the address holds a ballot of the vote or is the proxy of one.
+func IsVoter(vt *state.Vote, address bitcoin.RawAddress) bool {
+	for _, ballot := range vt.Ballots {
+		if ballot.Address.Equal(address) || ballot.Proxy.Equal(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CalculateResults calculates the result of a completed vote. The result is the options that
+//   passed, or ResultNoQuorum when the turnout was below the vote's quorum.
 func CalculateResults(ctx context.Context, vt *state.Vote, proposal *actions.Proposal,
 	votingSystem *actions.VotingSystemField) ([]uint64, string, error) {
 
 	floatTallys := make([]float32, len(proposal.VoteOptions))
 	votedQuantity := uint64(0)
-	var score float32
-	for _, ballot := range vt.Ballots {
-		if len(ballot.Vote) == 0 {
-			continue // Skip ballots that weren't completed
-		}
-		for i, choice := range ballot.Vote {
-			switch votingSystem.TallyLogic {
-			case 0: // Standard
-				score = float32(ballot.Quantity)
-			case 1: // Weighted
-				score = float32(ballot.Quantity) * (float32(int(proposal.VoteMax)-i) / float32(proposal.VoteMax))
-			default:
-				return nil, "", fmt.Errorf("Unsupported tally logic : %d", votingSystem.TallyLogic)
-			}
+	switch votingSystem.TallyLogic {
+	case TallyLogicInstantRunoff:
+		// The thresholds apply to the final round, in which exhausted ballots aren't counted.
+		rounds := InstantRunoffRounds(vt, proposal)
+		for i, round := range rounds {
+			logger.Verbose(ctx, "Vote round %d : %v eliminated \"%s\" exhausted %d", i+1,
+				round.OptionTally, round.Eliminated, round.Exhausted)
+		}
 
-			for j, option := range proposal.VoteOptions {
-				if option == choice {
-					floatTallys[j] += score
-					break
-				}
+		for i, tally := range rounds[len(rounds)-1].OptionTally {
+			floatTallys[i] = float32(tally)
+			votedQuantity += tally
+		}
+
+	case TallyLogicStandard, TallyLogicWeighted:
+		for _, ballot := range vt.Ballots {
+			if len(ballot.Vote) == 0 {
+				continue // Skip ballots that weren't completed
 			}
+			addScores(floatTallys, ballot, proposal, votingSystem)
+			votedQuantity += ballot.Quantity
 		}
 
-		votedQuantity += ballot.Quantity
+	default:
+		return nil, "", fmt.Errorf("Unsupported tally logic : %d", votingSystem.TallyLogic)
+	}
+
+	if !HasQuorum(vt) {
+		logger.Verbose(ctx, "Vote has no quorum : turnout %d of %d, quorum %d%%", Turnout(vt),
+			vt.TokenQty, vt.Quorum)
+		tallys := make([]uint64, len(proposal.VoteOptions))
+		for i, floatTally := range floatTallys {
+			tallys[i] = uint64(floatTally)
+		}
+		return tallys, ResultNoQuorum, nil
 	}
 
 	var winners bytes.Buffer
@@ -220,6 +295,107 @@ func CalculateResults(ctx context.Context, vt *state.Vote, proposal *actions.Pro
 	return tallys, winners.String(), nil
 }
 
+// Turnout returns the quantity of the ballots that were completed.
+func Turnout(vt *state.Vote) uint64 {
+	result := uint64(0)
+	for _, ballot := range vt.Ballots {
+		if len(ballot.Vote) > 0 {
+			result += ballot.Quantity
+		}
+	}
+	return result
+}
+
+// HasQuorum returns true if the turnout of the vote is at least its quorum percentage of the
+//   vote's token quantity.
+func HasQuorum(vt *state.Vote) bool {
+	return Turnout(vt)*100 >= uint64(vt.Quorum)*vt.TokenQty
+}
+
+// Decided returns true when an absolute vote's result can't change before the vote's cut off,
+//   with a reason describing it. Ballots that haven't been cast, and ballots cast by a proxy that
+//   the holder can still override, could be cast for any option. Votes that aren't absolute, or
+//   use instant runoff tally logic, are never decided early.
+func Decided(vt *state.Vote, proposal *actions.Proposal,
+	votingSystem *actions.VotingSystemField) (bool, string) {
+
+	if votingSystem.VoteType != "A" || (votingSystem.TallyLogic != TallyLogicStandard &&
+		votingSystem.TallyLogic != TallyLogicWeighted) || vt.TokenQty == 0 {
+		return false, ""
+	}
+
+	minTallys := make([]float32, len(proposal.VoteOptions))
+	turnout := uint64(0)
+	open := uint64(0)
+	for _, ballot := range vt.Ballots {
+		if len(ballot.Vote) == 0 || ballot.CastByProxy {
+			open += ballot.Quantity
+			continue
+		}
+		addScores(minTallys, ballot, proposal, votingSystem)
+		turnout += ballot.Quantity
+	}
+
+	if vt.Quorum != 0 {
+		if (turnout+open)*100 < uint64(vt.Quorum)*vt.TokenQty {
+			return true, fmt.Sprintf("Quorum can't be reached with %d of %d tokens open", open,
+				vt.TokenQty)
+		}
+		if turnout*100 < uint64(vt.Quorum)*vt.TokenQty {
+			return false, ""
+		}
+	}
+
+	// Each option must certainly pass or certainly fail the threshold.
+	threshold := float32(votingSystem.ThresholdPercentage) / 100.0
+	var passing []int
+	for i, minTally := range minTallys {
+		if minTally/float32(vt.TokenQty) >= threshold {
+			passing = append(passing, i)
+			continue
+		}
+		if (minTally+float32(open))/float32(vt.TokenQty) >= threshold {
+			return false, ""
+		}
+	}
+
+	// The order of passing options is the result, so it can't change either.
+	sort.SliceStable(passing, func(i, j int) bool {
+		return minTallys[passing[i]] > minTallys[passing[j]]
+	})
+	var result bytes.Buffer
+	for i, index := range passing {
+		if i > 0 && minTallys[passing[i-1]] <= minTallys[index]+float32(open) {
+			return false, ""
+		}
+		result.WriteByte(proposal.VoteOptions[index])
+	}
+
+	return true, fmt.Sprintf("Result \"%s\" decided with %d of %d tokens open", result.String(),
+		open, vt.TokenQty)
+}
+
+// addScores adds the scores of a ballot's choices to the option tallys.
+func addScores(tallys []float32, ballot state.Ballot, proposal *actions.Proposal,
+	votingSystem *actions.VotingSystemField) {
+
+	var score float32
+	for i, choice := range ballot.Vote {
+		if votingSystem.TallyLogic == TallyLogicWeighted {
+			score = float32(ballot.Quantity) * (float32(int(proposal.VoteMax)-i) / float32(proposal.VoteMax))
+		} else {
+			score = float32(ballot.Quantity)
+		}
+
+		for j, option := range proposal.VoteOptions {
+			if option == choice {
+				tallys[j] += score
+				break
+			}
+		}
+	}
+}
+
 func ValidateVotingSystem(system *actions.VotingSystemField) error {
 	if system.VoteType != "R" && system.VoteType != "A" && system.VoteType != "P" {
 		return fmt.Errorf("Unsupported vote type : %s", system.VoteType)
@@ -227,8 +403,86 @@ func ValidateVotingSystem(system *actions.VotingSystemField) error {
 	if system.ThresholdPercentage == 0 || system.ThresholdPercentage >= 100 {
 		return fmt.Errorf("Threshold Percentage out of range : %d", system.ThresholdPercentage)
 	}
-	if system.TallyLogic != 0 && system.TallyLogic != 1 {
+	if system.TallyLogic != TallyLogicStandard && system.TallyLogic != TallyLogicWeighted &&
+		system.TallyLogic != TallyLogicInstantRunoff {
 		return fmt.Errorf("Tally Logic invalid : %d", system.TallyLogic)
 	}
 	return nil
 }
+
+// InstantRunoffRounds counts the ballots of a completed vote in rounds. Each round counts every
+//   ballot for its highest ranked option that hasn't been eliminated. The rounds end when an option
+//   has a majority of the counted ballots, or when the remaining options are tied. Otherwise the
+//   options with the lowest tally are eliminated for the next round. The last round is the result.
+func InstantRunoffRounds(vt *state.Vote, proposal *actions.Proposal) []state.VoteRound {
+	var result []state.VoteRound
+	eliminated := make([]bool, len(proposal.VoteOptions))
+
+	for {
+		round := state.VoteRound{
+			OptionTally: make([]uint64, len(proposal.VoteOptions)),
+		}
+
+		counted := uint64(0)
+		for _, ballot := range vt.Ballots {
+			if len(ballot.Vote) == 0 {
+				continue // Skip ballots that weren't completed
+			}
+
+			index := highestRanked(ballot.Vote, proposal.VoteOptions, eliminated)
+			if index == -1 {
+				round.Exhausted += ballot.Quantity
+				continue
+			}
+
+			round.OptionTally[index] += ballot.Quantity
+			counted += ballot.Quantity
+		}
+
+		remaining := 0
+		lowest := uint64(math.MaxUint64)
+		highest := uint64(0)
+		for i, tally := range round.OptionTally {
+			if eliminated[i] {
+				continue
+			}
+
+			remaining++
+			if tally < lowest {
+				lowest = tally
+			}
+			if tally > highest {
+				highest = tally
+			}
+		}
+
+		if remaining <= 1 || highest*2 > counted || lowest == highest {
+			return append(result, round)
+		}
+
+		var eliminations bytes.Buffer
+		for i, tally := range round.OptionTally {
+			if !eliminated[i] && tally == lowest {
+				eliminated[i] = true
+				eliminations.WriteByte(proposal.VoteOptions[i])
+			}
+		}
+		round.Eliminated = eliminations.String()
+
+		result = append(result, round)
+	}
+}
+
+// highestRanked returns the index of the first choice that is an option that hasn't been
+//   eliminated, or -1 if there isn't one.
+func highestRanked(choices, options string, eliminated []bool) int {
+	for _, choice := range choices {
+		for i, option := range options {
+			if option == choice && !eliminated[i] {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,363 @@
+package vote
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/smart-contract/internal/platform/state"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+)
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		ballots  map[string]uint64 // Quantity of each ranking
+		voteType string
+		rounds   []state.VoteRound
+		result   string
+	}{
+		{
+			name:     "transferred majority",
+			ballots:  map[string]uint64{"ACB": 40, "BCA": 35, "CBA": 25},
+			voteType: "R",
+			rounds: []state.VoteRound{
+				{OptionTally: []uint64{40, 35, 25}, Eliminated: "C"},
+				{OptionTally: []uint64{40, 60, 0}},
+			},
+			result: "B",
+		},
+		{
+			name:     "exhausted ballots",
+			ballots:  map[string]uint64{"A": 40, "B": 35, "C": 25},
+			voteType: "R",
+			rounds: []state.VoteRound{
+				{OptionTally: []uint64{40, 35, 25}, Eliminated: "C"},
+				{OptionTally: []uint64{40, 35, 0}, Exhausted: 25},
+			},
+			result: "A",
+		},
+		{
+			name:     "absolute threshold",
+			ballots:  map[string]uint64{"A": 40, "B": 35, "C": 25},
+			voteType: "A",
+			rounds: []state.VoteRound{
+				{OptionTally: []uint64{40, 35, 25}, Eliminated: "C"},
+				{OptionTally: []uint64{40, 35, 0}, Exhausted: 25},
+			},
+			result: "",
+		},
+		{
+			name:     "tied",
+			ballots:  map[string]uint64{"AC": 40, "BC": 40, "C": 20},
+			voteType: "P",
+			rounds: []state.VoteRound{
+				{OptionTally: []uint64{40, 40, 20}, Eliminated: "C"},
+				{OptionTally: []uint64{40, 40, 0}, Exhausted: 20},
+			},
+			result: "AB",
+		},
+	}
+
+	proposal := &actions.Proposal{
+		VoteOptions: "ABC",
+		VoteMax:     3,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vt := &state.Vote{
+				TokenQty: 100,
+				Ballots:  make(map[bitcoin.Hash20]state.Ballot),
+			}
+			for choices, quantity := range tt.ballots {
+				key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+				if err != nil {
+					t.Fatalf("Failed to generate key : %s", err)
+				}
+				ra, err := key.RawAddress()
+				if err != nil {
+					t.Fatalf("Failed to create address : %s", err)
+				}
+				hash, err := ra.Hash()
+				if err != nil {
+					t.Fatalf("Failed to hash address : %s", err)
+				}
+				vt.Ballots[*hash] = state.Ballot{Address: ra, Vote: choices, Quantity: quantity}
+			}
+
+			rounds := InstantRunoffRounds(vt, proposal)
+			if len(rounds) != len(tt.rounds) {
+				t.Fatalf("Wrong round count : got %d, want %d", len(rounds), len(tt.rounds))
+			}
+			for i, round := range rounds {
+				want := tt.rounds[i]
+				for j := range want.OptionTally {
+					if round.OptionTally[j] != want.OptionTally[j] {
+						t.Fatalf("Round %d wrong tally : got %v, want %v", i+1, round.OptionTally,
+							want.OptionTally)
+					}
+				}
+				if round.Exhausted != want.Exhausted {
+					t.Fatalf("Round %d wrong exhausted : got %d, want %d", i+1, round.Exhausted,
+						want.Exhausted)
+				}
+				if round.Eliminated != want.Eliminated {
+					t.Fatalf("Round %d wrong eliminated : got \"%s\", want \"%s\"", i+1,
+						round.Eliminated, want.Eliminated)
+				}
+			}
+
+			votingSystem := &actions.VotingSystemField{
+				VoteType:            tt.voteType,
+				TallyLogic:          TallyLogicInstantRunoff,
+				ThresholdPercentage: 50,
+			}
+			if err := ValidateVotingSystem(votingSystem); err != nil {
+				t.Fatalf("Voting system invalid : %s", err)
+			}
+
+			tally, result, err := CalculateResults(context.Background(), vt, proposal, votingSystem)
+			if err != nil {
+				t.Fatalf("Failed to calculate results : %s", err)
+			}
+
+			final := tt.rounds[len(tt.rounds)-1]
+			for i := range final.OptionTally {
+				if tally[i] != final.OptionTally[i] {
+					t.Fatalf("Wrong tally : got %v, want %v", tally, final.OptionTally)
+				}
+			}
+			if result != tt.result {
+				t.Fatalf("Wrong result : got \"%s\", want \"%s\"", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	tests := []struct {
+		name     string
+		ballots  map[string]uint64 // Quantity of each choice, empty for not voted
+		quorum   uint32
+		noQuorum bool
+		result   string
+	}{
+		{"no quorum set", map[string]uint64{"A": 100, "": 900}, 0, false, "A"},
+		{"below quorum", map[string]uint64{"A": 200, "B": 90, "": 710}, 30, true, ResultNoQuorum},
+		{"at quorum", map[string]uint64{"A": 200, "B": 100, "": 700}, 30, false, "A"},
+		{"none passed", map[string]uint64{"A": 250, "B": 200, "": 550}, 30, false, ""},
+	}
+
+	proposal := &actions.Proposal{
+		VoteOptions: "AB",
+		VoteMax:     1,
+	}
+
+	votingSystem := &actions.VotingSystemField{
+		VoteType:            "R",
+		TallyLogic:          TallyLogicStandard,
+		ThresholdPercentage: 60,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vt := &state.Vote{
+				TokenQty: 1000,
+				Quorum:   tt.quorum,
+				Ballots:  make(map[bitcoin.Hash20]state.Ballot),
+			}
+			turnout := uint64(0)
+			for choice, quantity := range tt.ballots {
+				key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+				if err != nil {
+					t.Fatalf("Failed to generate key : %s", err)
+				}
+				ra, err := key.RawAddress()
+				if err != nil {
+					t.Fatalf("Failed to create address : %s", err)
+				}
+				hash, err := ra.Hash()
+				if err != nil {
+					t.Fatalf("Failed to hash address : %s", err)
+				}
+				vt.Ballots[*hash] = state.Ballot{Address: ra, Vote: choice, Quantity: quantity}
+				if len(choice) > 0 {
+					turnout += quantity
+				}
+			}
+
+			if Turnout(vt) != turnout {
+				t.Fatalf("Wrong turnout : got %d, want %d", Turnout(vt), turnout)
+			}
+
+			if HasQuorum(vt) == tt.noQuorum {
+				t.Fatalf("Wrong quorum : got %t, want %t", HasQuorum(vt), !tt.noQuorum)
+			}
+
+			tally, result, err := CalculateResults(context.Background(), vt, proposal, votingSystem)
+			if err != nil {
+				t.Fatalf("Failed to calculate results : %s", err)
+			}
+
+			if tally[0] != tt.ballots["A"] || tally[1] != tt.ballots["B"] {
+				t.Fatalf("Wrong tally : got %v", tally)
+			}
+
+			if result != tt.result {
+				t.Fatalf("Wrong result : got \"%s\", want \"%s\"", result, tt.result)
+			}
+		})
+	}
+}
+
+func TestNoQuorumOption(t *testing.T) {
+	now := protocol.CurrentTimestamp()
+	proposal := &actions.Proposal{
+		VoteOptions:         "AB" + ResultNoQuorum,
+		VoteMax:             1,
+		VoteCutOffTimestamp: now.Nano() + 1000000000,
+	}
+
+	if err := ValidateProposal(proposal, now); err == nil {
+		t.Fatalf("Proposal with no quorum result option not rejected")
+	}
+
+	proposal.VoteOptions = "AB"
+	if err := ValidateProposal(proposal, now); err != nil {
+		t.Fatalf("Failed to validate proposal : %s", err)
+	}
+}
+
+func TestDecided(t *testing.T) {
+	type ballot struct {
+		vote     string
+		quantity uint64
+		byProxy  bool
+	}
+
+	tests := []struct {
+		name     string
+		voteType string
+		quorum   uint32
+		ballots  []ballot
+		decided  bool
+	}{
+		{"passed", "A", 0, []ballot{{"A", 800, false}, {"B", 100, false}, {"", 100, false}}, true},
+		{"failed", "A", 0, []ballot{{"A", 300, false}, {"B", 300, false}, {"", 400, false}}, true},
+		{"undecided", "A", 0, []ballot{{"A", 700, false}, {"", 300, false}}, false},
+		{"proxy can be overridden", "A", 0,
+			[]ballot{{"A", 700, false}, {"A", 200, true}, {"", 100, false}}, false},
+		{"quorum unreachable", "A", 90, []ballot{{"A", 500, false}, {"", 300, false}}, true},
+		{"quorum not reached", "A", 90, []ballot{{"A", 800, false}, {"", 200, false}}, false},
+		{"relative", "R", 0, []ballot{{"A", 900, false}, {"", 100, false}}, false},
+	}
+
+	proposal := &actions.Proposal{
+		VoteOptions: "AB",
+		VoteMax:     1,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			votingSystem := &actions.VotingSystemField{
+				VoteType:            tt.voteType,
+				TallyLogic:          TallyLogicStandard,
+				ThresholdPercentage: 75,
+			}
+
+			vt := &state.Vote{
+				TokenQty: 1000,
+				Quorum:   tt.quorum,
+				Ballots:  make(map[bitcoin.Hash20]state.Ballot),
+			}
+			for _, b := range tt.ballots {
+				ra := generateAddress(t)
+				hash, err := ra.Hash()
+				if err != nil {
+					t.Fatalf("Failed to hash address : %s", err)
+				}
+				vt.Ballots[*hash] = state.Ballot{Address: ra, Vote: b.vote, Quantity: b.quantity,
+					CastByProxy: b.byProxy}
+			}
+
+			decided, reason := Decided(vt, proposal, votingSystem)
+			if decided != tt.decided {
+				t.Fatalf("Wrong decided : got %t, want %t", decided, tt.decided)
+			}
+			if decided && len(reason) == 0 {
+				t.Fatalf("Missing reason")
+			}
+		})
+	}
+}
+
+func TestCastableBallots(t *testing.T) {
+	proxy := generateAddress(t)
+	other := generateAddress(t)
+
+	vt := &state.Vote{
+		Ballots: make(map[bitcoin.Hash20]state.Ballot),
+	}
+	addBallot := func(ballot state.Ballot) {
+		hash, err := ballot.Address.Hash()
+		if err != nil {
+			t.Fatalf("Failed to hash address : %s", err)
+		}
+		vt.Ballots[*hash] = ballot
+	}
+
+	addBallot(state.Ballot{Address: proxy, Quantity: 10})
+	delegator := generateAddress(t)
+	addBallot(state.Ballot{Address: delegator, Quantity: 20, Proxy: proxy})
+	addBallot(state.Ballot{Address: generateAddress(t), Quantity: 40, Proxy: proxy, Vote: "A",
+		Timestamp: protocol.NewTimestamp(1)})
+
+	if !IsVoter(vt, proxy) || !IsVoter(vt, delegator) || IsVoter(vt, other) {
+		t.Fatalf("Wrong voters")
+	}
+
+	if quantity := castableQuantity(CastableBallots(vt, proxy)); quantity != 30 {
+		t.Fatalf("Wrong proxy quantity : got %d, want 30", quantity)
+	}
+
+	// The proxy casts its own ballot and the delegator's.
+	for _, ballot := range CastableBallots(vt, proxy) {
+		ballot.Vote = "B"
+		ballot.Timestamp = protocol.NewTimestamp(2)
+		ballot.CastByProxy = !ballot.Address.Equal(proxy)
+		addBallot(ballot)
+	}
+
+	if len(CastableBallots(vt, proxy)) != 0 {
+		t.Fatalf("Proxy shouldn't have ballots left")
+	}
+
+	// The delegator can still override the proxy's ballot.
+	if quantity := castableQuantity(CastableBallots(vt, delegator)); quantity != 20 {
+		t.Fatalf("Wrong delegator quantity : got %d, want 20", quantity)
+	}
+}
+
+func castableQuantity(ballots []state.Ballot) uint64 {
+	result := uint64(0)
+	for _, ballot := range ballots {
+		result += ballot.Quantity
+	}
+	return result
+}
+
+func generateAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	ra, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return ra
+}
@@ -440,6 +440,47 @@ func (o *HTTPClient) ApproveReceive(ctx context.Context, contract, asset string,
 	return result, response.Data.BlockHash, nil
 }
 
+// GetEntity requests the entity information that the identity oracle has associated with an
+//   address. The /identity/entity endpoint is served by the reference oracle in cmd/identityoracle,
+//   but isn't part of every oracle's API, so ErrNotServed is returned when it isn't found. An
+//   empty entity is returned when the oracle doesn't know the address.
+func (o *HTTPClient) GetEntity(ctx context.Context,
+	address bitcoin.RawAddress) (*actions.EntityField, error) {
+
+	request := struct {
+		Address bitcoin.RawAddress `json:"address" validate:"required"`
+	}{
+		Address: address,
+	}
+
+	var response struct {
+		Data struct {
+			Entity actions.EntityField `json:"entity"`
+		}
+	}
+
+	if err := post(ctx, o.URL+"/identity/entity", request, &response); err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.Wrap(ErrNotServed, "/identity/entity")
+		}
+		return nil, errors.Wrap(err, "http post")
+	}
+
+	return &response.Data.Entity, nil
+}
+
+// EntityName returns the name of the entity associated with an address, or an empty string when
+//   the identity oracle doesn't know the address. ErrNotServed is returned when the oracle doesn't
+//   serve entities.
+func (o *HTTPClient) EntityName(ctx context.Context, address bitcoin.RawAddress) (string, error) {
+	entity, err := o.GetEntity(ctx, address)
+	if err != nil {
+		return "", err
+	}
+
+	return entity.Name, nil
+}
+
 // post sends an HTTP POST request.
 func post(ctx context.Context, url string, request, response interface{}) error {
 	var transport = &http.Transport{
@@ -19,6 +19,9 @@ var (
 
 	// ErrInvalidSignature means a provided signature is invalid.
 	ErrInvalidSignature = errors.New("Invalid Signature")
+
+	// ErrNotServed means the identity oracle doesn't serve the requested endpoint.
+	ErrNotServed = errors.New("Not Served")
 )
 
 // Factory is the interface for creating new identity clients.
@@ -2,6 +2,8 @@ package identity
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/tokenized/pkg/bitcoin"
@@ -9,6 +11,8 @@ import (
 	"github.com/tokenized/specification/dist/golang/actions"
 	"github.com/tokenized/specification/dist/golang/assets"
 	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
 )
 
 var (
@@ -109,3 +113,156 @@ func TestApproveReceive(t *testing.T) {
 		}
 	}
 }
+
+func TestEntityNameNotServed(t *testing.T) {
+	ctx := context.Background()
+
+	// An oracle without the entity endpoint.
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	address, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	client, err := NewHTTPClient(address, server.URL, key.PublicKey())
+	if err != nil {
+		t.Fatalf("Failed to create client : %s", err)
+	}
+
+	if _, err := client.EntityName(ctx, address); errors.Cause(err) != ErrNotServed {
+		t.Fatalf("Missing entity endpoint not reported : %v", err)
+	}
+}
+
+func TestAdminCertificateEntity(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	address, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	issuer := actions.EntityField{Name: "Test"}
+	var blockHash bitcoin.Hash32
+
+	// Without an entity contract the certificate is for the issuer.
+	entity := adminCertificateEntity(&issuer, bitcoin.RawAddress{})
+	if _, ok := entity.(*actions.EntityField); !ok {
+		t.Fatalf("Wrong issuer entity type : %T", entity)
+	}
+	if _, err := protocol.ContractAdminIdentityOracleSigHash(ctx, address, entity, blockHash, 0,
+		1); err != nil {
+		t.Fatalf("Failed to generate issuer sig hash : %s", err)
+	}
+
+	entity = adminCertificateEntity(&issuer, address)
+	if contract, ok := entity.(bitcoin.RawAddress); !ok || !contract.Equal(address) {
+		t.Fatalf("Wrong contract entity : %v", entity)
+	}
+	if _, err := protocol.ContractAdminIdentityOracleSigHash(ctx, address, entity, blockHash, 0,
+		1); err != nil {
+		t.Fatalf("Failed to generate contract sig hash : %s", err)
+	}
+}
+
+func TestValidateAdminIdentityCertificate(t *testing.T) {
+	ctx := context.Background()
+
+	oracleKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate oracle key : %s", err)
+	}
+	oracleAddress, err := oracleKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create oracle address : %s", err)
+	}
+
+	adminKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate admin key : %s", err)
+	}
+	adminAddress, err := adminKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create admin address : %s", err)
+	}
+
+	contractKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate contract key : %s", err)
+	}
+	contractAddress, err := contractKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create contract address : %s", err)
+	}
+
+	blocks := NewRandBlockHashes()
+	issuer := actions.EntityField{Name: "Test"}
+
+	// certificate signs the admin for the issuer, or for the contract when it isn't empty.
+	certificate := func(contract bitcoin.RawAddress,
+		approved uint8) actions.AdminIdentityCertificateField {
+		blockHash, err := blocks.Hash(ctx, 100)
+		if err != nil {
+			t.Fatalf("Failed to get block hash : %s", err)
+		}
+
+		var entity interface{}
+		if contract.IsEmpty() {
+			entity = &issuer
+		} else {
+			entity = contract
+		}
+
+		sigHash, err := protocol.ContractAdminIdentityOracleSigHash(ctx, adminAddress, entity,
+			*blockHash, 0, approved)
+		if err != nil {
+			t.Fatalf("Failed to generate sig hash : %s", err)
+		}
+
+		sig, err := oracleKey.Sign(sigHash)
+		if err != nil {
+			t.Fatalf("Failed to sign : %s", err)
+		}
+
+		return actions.AdminIdentityCertificateField{
+			EntityContract: oracleAddress.Bytes(),
+			Signature:      sig.Bytes(),
+			BlockHeight:    100,
+		}
+	}
+
+	// Issuer entity
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, oracleKey.PublicKey(), blocks,
+		adminAddress, issuer, bitcoin.RawAddress{},
+		certificate(bitcoin.RawAddress{}, 1)); err != nil {
+		t.Fatalf("Failed to validate issuer certificate : %s", err)
+	}
+
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, oracleKey.PublicKey(), blocks,
+		adminAddress, issuer, bitcoin.RawAddress{},
+		certificate(bitcoin.RawAddress{}, 0)); err != ErrNotApproved {
+		t.Fatalf("Not approved issuer certificate : %v", err)
+	}
+
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, oracleKey.PublicKey(), blocks,
+		adminAddress, actions.EntityField{Name: "Other"}, bitcoin.RawAddress{},
+		certificate(bitcoin.RawAddress{}, 1)); errors.Cause(err) != ErrInvalidSignature {
+		t.Fatalf("Wrong issuer certificate not invalid : %v", err)
+	}
+
+	// Entity contract
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, oracleKey.PublicKey(), blocks,
+		adminAddress, issuer, contractAddress, certificate(contractAddress, 1)); err != nil {
+		t.Fatalf("Failed to validate contract certificate : %s", err)
+	}
+}
@@ -0,0 +1,740 @@
+package identity
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/json"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const serverStorageKey = "identity"
+
+var (
+	// ErrBadRequest means a request to the server is malformed.
+	ErrBadRequest = errors.New("Bad Request")
+)
+
+// BlockTip provides the latest block, which the server's signatures commit to. rpcnode.RPCNode
+//   implements it.
+type BlockTip interface {
+	GetLatestBlock(ctx context.Context) (*bitcoin.Hash32, int32, error)
+}
+
+// Storage keeps the server's users. Fetch must return an error with a cause of ErrNotFound when
+//   the key isn't stored.
+type Storage interface {
+	Fetch(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// User is an entity registered with the server.
+type User struct {
+	ID        uuid.UUID           `json:"id"`
+	Entity    actions.EntityField `json:"entity"`
+	PublicKey bitcoin.PublicKey   `json:"public_key"`
+	XPubs     []UserXPubs         `json:"xpubs"`
+}
+
+// UserXPubs is a set of xpubs registered to a user and the signers required to spend from them.
+type UserXPubs struct {
+	XPubs           bitcoin.ExtendedKeys `json:"xpubs"`
+	RequiredSigners int                  `json:"required_signers"`
+}
+
+// Server is a reference identity oracle. It serves the endpoints called by HTTPClient and signs
+//   approvals with the oracle key. Every receive to a registered xpub is approved, and entity and
+//   admin approvals only require the entity to match the one registered, so it is intended for
+//   testing.
+type Server struct {
+	ContractAddress bitcoin.RawAddress
+	Key             bitcoin.Key
+
+	ctx     context.Context
+	storage Storage
+	blocks  BlockTip
+	mux     *http.ServeMux
+	lock    sync.Mutex
+}
+
+// NewServer returns an identity oracle server that keeps users in storage.
+func NewServer(ctx context.Context, contractAddress bitcoin.RawAddress, key bitcoin.Key,
+	storage Storage, blocks BlockTip) *Server {
+
+	result := &Server{
+		ContractAddress: contractAddress,
+		Key:             key,
+		ctx:             ctx,
+		storage:         storage,
+		blocks:          blocks,
+		mux:             http.NewServeMux(),
+	}
+
+	result.mux.HandleFunc("/oracle/id", result.handleID)
+	result.mux.HandleFunc("/oracle/user", result.handleUser)
+	result.mux.HandleFunc("/oracle/register", result.handleRegister)
+	result.mux.HandleFunc("/oracle/addXPub", result.handleAddXPub)
+	result.mux.HandleFunc("/oracle/updateIdentity", result.handleUpdateIdentity)
+	result.mux.HandleFunc("/transfer/approve", result.handleApproveReceive)
+	result.mux.HandleFunc("/identity/verifyPubKey", result.handleVerifyPubKey)
+	result.mux.HandleFunc("/identity/verifyAdmin", result.handleVerifyAdmin)
+	result.mux.HandleFunc("/identity/entity", result.handleEntity)
+
+	return result
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleID returns the oracle's contract address and public key.
+func (s *Server) handleID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.respondError(w, http.StatusMethodNotAllowed, errors.New(r.Method))
+		return
+	}
+
+	s.respond(w, struct {
+		ContractAddress bitcoin.RawAddress `json:"contract_address"`
+		PublicKey       bitcoin.PublicKey  `json:"public_key"`
+	}{
+		ContractAddress: s.ContractAddress,
+		PublicKey:       s.Key.PublicKey(),
+	})
+}
+
+// handleUser returns the id of the user that registered the xpubs.
+func (s *Server) handleUser(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		XPubs bitcoin.ExtendedKeys `json:"xpubs"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, _, err := s.fetchUserByXPubs(request.XPubs)
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	s.respond(w, struct {
+		UserID uuid.UUID `json:"user_id"`
+	}{
+		UserID: user.ID,
+	})
+}
+
+// handleRegister creates a user for an entity. The request is signed by the key that authorizes
+//   the user's later requests. Registering the same key again returns the existing user.
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Entity    actions.EntityField `json:"entity"`
+		PublicKey bitcoin.PublicKey   `json:"public_key"`
+		Signature bitcoin.Signature   `json:"signature"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	hasher := sha256.New()
+	if err := request.Entity.WriteDeterministic(hasher); err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(err, "write entity"))
+		return
+	}
+	hash := sha256.Sum256(hasher.Sum(nil))
+
+	if !request.Signature.Verify(hash[:], request.PublicKey) {
+		s.respondError(w, http.StatusUnauthorized, ErrInvalidSignature)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	keyPath := fmt.Sprintf("%s/keys/%x", serverStorageKey, request.PublicKey.Bytes())
+	status := "existing"
+	user, err := s.fetchUserByIndex(keyPath)
+	if err != nil {
+		if errors.Cause(err) != ErrNotFound {
+			s.respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		status = "created"
+		user = &User{
+			ID:        uuid.New(),
+			Entity:    request.Entity,
+			PublicKey: request.PublicKey,
+		}
+		if err := s.saveUser(user); err != nil {
+			s.respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err := s.storage.Put(s.ctx, keyPath, []byte(user.ID.String())); err != nil {
+			s.respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+		logger.Info(s.ctx, "Registered identity user %s : %s", user.ID, user.Entity.Name)
+	}
+
+	s.respond(w, struct {
+		Status string    `json:"status"`
+		UserID uuid.UUID `json:"user_id"`
+	}{
+		Status: status,
+		UserID: user.ID,
+	})
+}
+
+// handleAddXPub adds xpubs to a user.
+func (s *Server) handleAddXPub(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UserID          uuid.UUID            `json:"user_id"`
+		XPubs           bitcoin.ExtendedKeys `json:"xpubs"`
+		RequiredSigners int                  `json:"required_signers"`
+		Signature       bitcoin.Signature    `json:"signature"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if len(request.XPubs) == 0 || request.RequiredSigners < 1 ||
+		request.RequiredSigners > len(request.XPubs) {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(ErrBadRequest, "required signers"))
+		return
+	}
+	for _, xpub := range request.XPubs {
+		if xpub.IsPrivate() {
+			s.respondError(w, http.StatusBadRequest,
+				errors.Wrap(ErrBadRequest, "private keys not allowed"))
+			return
+		}
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	user, err := s.fetchUser(request.UserID)
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	hasher := sha256.New()
+	hasher.Write(request.UserID[:])
+	hasher.Write(request.XPubs.Bytes())
+	binary.Write(hasher, binary.LittleEndian, uint32(request.RequiredSigners))
+	hash := sha256.Sum256(hasher.Sum(nil))
+
+	if !request.Signature.Verify(hash[:], user.PublicKey) {
+		s.respondError(w, http.StatusUnauthorized, ErrInvalidSignature)
+		return
+	}
+
+	existing, _, err := s.fetchUserByXPubs(request.XPubs)
+	if err == nil {
+		if existing.ID == user.ID {
+			s.respond(w, struct{}{}) // Already registered
+			return
+		}
+		s.respondError(w, http.StatusConflict,
+			errors.Wrap(ErrBadRequest, "xpubs registered to another user"))
+		return
+	}
+	if errors.Cause(err) != ErrNotFound {
+		s.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	user.XPubs = append(user.XPubs, UserXPubs{
+		XPubs:           request.XPubs,
+		RequiredSigners: request.RequiredSigners,
+	})
+	if err := s.saveUser(user); err != nil {
+		s.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := s.storage.Put(s.ctx, xpubsPath(request.XPubs), []byte(user.ID.String())); err != nil {
+		s.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.respond(w, struct{}{})
+}
+
+// handleUpdateIdentity replaces a user's entity.
+func (s *Server) handleUpdateIdentity(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UserID    uuid.UUID           `json:"user_id"`
+		Entity    actions.EntityField `json:"entity"`
+		Signature bitcoin.Signature   `json:"signature"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	user, err := s.fetchUser(request.UserID)
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	hasher := sha256.New()
+	hasher.Write(request.UserID[:])
+	if err := request.Entity.WriteDeterministic(hasher); err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(err, "write entity"))
+		return
+	}
+	hash := sha256.Sum256(hasher.Sum(nil))
+
+	if !request.Signature.Verify(hash[:], user.PublicKey) {
+		s.respondError(w, http.StatusUnauthorized, ErrInvalidSignature)
+		return
+	}
+
+	user.Entity = request.Entity
+	if err := s.saveUser(user); err != nil {
+		s.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.respond(w, struct{}{})
+}
+
+// handleApproveReceive signs the receive of an asset to an address derived from registered xpubs.
+func (s *Server) handleApproveReceive(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		XPubs    bitcoin.ExtendedKeys `json:"xpubs"`
+		Index    uint32               `json:"index"`
+		Contract string               `json:"contract"`
+		AssetID  string               `json:"asset_id"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	contractAddress, err := bitcoin.DecodeAddress(request.Contract)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(err, "decode contract address"))
+		return
+	}
+	contractRawAddress := bitcoin.NewRawAddressFromAddress(contractAddress)
+
+	_, assetCode, err := protocol.DecodeAssetID(request.AssetID)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(err, "decode asset id"))
+		return
+	}
+
+	user, xpubs, err := s.fetchUserByXPubs(request.XPubs)
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	receiveAddress, err := deriveAddress(xpubs, request.Index)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	blockHash, height, err := s.blocks.GetLatestBlock(s.ctx)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "latest block"))
+		return
+	}
+
+	sigHash, err := protocol.TransferOracleSigHash(s.ctx, contractRawAddress, assetCode.Bytes(),
+		receiveAddress, *blockHash, 0, 1)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "signature hash"))
+		return
+	}
+
+	signature, err := s.Key.Sign(sigHash)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "sign"))
+		return
+	}
+
+	if err := s.saveAddress(receiveAddress, user); err != nil {
+		s.respondError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.respond(w, struct {
+		Approved     bool              `json:"approved"`
+		Description  string            `json:"description"`
+		SigAlgorithm uint32            `json:"algorithm"`
+		Signature    bitcoin.Signature `json:"signature"`
+		BlockHeight  uint32            `json:"block_height"`
+		BlockHash    bitcoin.Hash32    `json:"block_hash"`
+		Expiration   uint64            `json:"expiration"`
+	}{
+		Approved:     true,
+		SigAlgorithm: 1,
+		Signature:    signature,
+		BlockHeight:  uint32(height),
+		BlockHash:    *blockHash,
+	})
+}
+
+// handleVerifyPubKey signs the association of a key derived from a registered xpub with an entity.
+//   It is only approved when the entity matches the one registered to the xpub's user.
+func (s *Server) handleVerifyPubKey(w http.ResponseWriter, r *http.Request) {
+	// The xpub is decoded from text because ExtendedKey's json unmarshal doesn't accept the base 58
+	//   text that its json marshal writes.
+	var request struct {
+		XPub   string              `json:"xpub"`
+		Index  uint32              `json:"index"`
+		Entity actions.EntityField `json:"entity"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	xpub, err := bitcoin.ExtendedKeyFromStr58(request.XPub)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(ErrBadRequest, err.Error()))
+		return
+	}
+
+	user, _, err := s.fetchUserByXPubs(bitcoin.ExtendedKeys{xpub})
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	key, err := xpub.ChildKey(request.Index)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, errors.Wrap(err, "generate public key"))
+		return
+	}
+
+	approved, err := entitiesMatch(&user.Entity, &request.Entity)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	blockHash, height, err := s.blocks.GetLatestBlock(s.ctx)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "latest block"))
+		return
+	}
+
+	sigHash, err := protocol.EntityPubKeyOracleSigHash(s.ctx, &request.Entity, key.PublicKey(),
+		*blockHash, approvedFlag(approved))
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "signature hash"))
+		return
+	}
+
+	signature, err := s.Key.Sign(sigHash)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "sign"))
+		return
+	}
+
+	s.respond(w, struct {
+		Approved     bool              `json:"approved"`
+		SigAlgorithm uint32            `json:"algorithm"`
+		Signature    bitcoin.Signature `json:"signature"`
+		BlockHeight  uint32            `json:"block_height"`
+	}{
+		Approved:     approved,
+		SigAlgorithm: 1,
+		Signature:    signature,
+		BlockHeight:  uint32(height),
+	})
+}
+
+// handleVerifyAdmin signs an admin identity certificate for a contract offer. When the offer
+//   references an entity contract, the contract is certified instead of the issuer. Otherwise the
+//   issuer must match the entity registered to the admin's user.
+func (s *Server) handleVerifyAdmin(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		XPubs    bitcoin.ExtendedKeys `json:"xpubs"`
+		Index    uint32               `json:"index"`
+		Issuer   actions.EntityField  `json:"issuer"`
+		Contract bitcoin.RawAddress   `json:"entity_contract"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	user, xpubs, err := s.fetchUserByXPubs(request.XPubs)
+	if err != nil {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	adminAddress, err := deriveAddress(xpubs, request.Index)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var entity interface{}
+	approved := true
+	description := ""
+	if request.Contract.IsEmpty() {
+		entity = &request.Issuer
+		approved, err = entitiesMatch(&user.Entity, &request.Issuer)
+		if err != nil {
+			s.respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !approved {
+			description = "Issuer doesn't match registered entity"
+		}
+	} else {
+		entity = request.Contract
+	}
+
+	blockHash, height, err := s.blocks.GetLatestBlock(s.ctx)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "latest block"))
+		return
+	}
+
+	sigHash, err := protocol.ContractAdminIdentityOracleSigHash(s.ctx, adminAddress, entity,
+		*blockHash, 0, approvedFlag(approved))
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "signature hash"))
+		return
+	}
+
+	signature, err := s.Key.Sign(sigHash)
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "sign"))
+		return
+	}
+
+	if approved {
+		if err := s.saveAddress(adminAddress, user); err != nil {
+			s.respondError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	s.respond(w, struct {
+		Approved    bool              `json:"approved"`
+		Description string            `json:"description"`
+		Signature   bitcoin.Signature `json:"signature"`
+		BlockHeight uint32            `json:"block_height"`
+		Expiration  uint64            `json:"expiration"`
+	}{
+		Approved:    approved,
+		Description: description,
+		Signature:   signature,
+		BlockHeight: uint32(height),
+	})
+}
+
+// handleEntity returns the entity of the user that an address was approved for.
+func (s *Server) handleEntity(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Address bitcoin.RawAddress `json:"address"`
+	}
+	if err := s.decode(r, &request); err != nil {
+		s.respondError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var entity actions.EntityField
+	user, err := s.fetchUserByIndex(addressPath(request.Address))
+	if err == nil {
+		entity = user.Entity
+	} else if errors.Cause(err) != ErrNotFound {
+		s.respondError(w, statusFromError(err), err)
+		return
+	}
+
+	// An unknown address gets an empty entity, since not found means the endpoint isn't served.
+	s.respond(w, struct {
+		Entity actions.EntityField `json:"entity"`
+	}{
+		Entity: entity,
+	})
+}
+
+// decode reads a POST request body.
+func (s *Server) decode(r *http.Request, request interface{}) error {
+	if r.Method != http.MethodPost {
+		return errors.Wrap(ErrBadRequest, r.Method)
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return errors.Wrap(err, "read request")
+	}
+
+	logger.Verbose(s.ctx, "%s Request : %s", r.URL.Path, string(b))
+
+	if err := json.Unmarshal(b, request); err != nil {
+		return errors.Wrap(ErrBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// respond writes the data of a successful request.
+func (s *Server) respond(w http.ResponseWriter, data interface{}) {
+	b, err := json.Marshal(struct {
+		Data interface{} `json:"data"`
+	}{
+		Data: data,
+	})
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, errors.Wrap(err, "marshal response"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (s *Server) respondError(w http.ResponseWriter, status int, err error) {
+	logger.Warn(s.ctx, "Identity request failed : %d %s", status, err)
+	http.Error(w, err.Error(), status)
+}
+
+func statusFromError(err error) int {
+	if errors.Cause(err) == ErrNotFound {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (s *Server) saveUser(user *User) error {
+	b, err := json.Marshal(user)
+	if err != nil {
+		return errors.Wrap(err, "marshal user")
+	}
+
+	return s.storage.Put(s.ctx, fmt.Sprintf("%s/users/%s", serverStorageKey, user.ID), b)
+}
+
+func (s *Server) fetchUser(id uuid.UUID) (*User, error) {
+	b, err := s.storage.Fetch(s.ctx, fmt.Sprintf("%s/users/%s", serverStorageKey, id))
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.Wrap(ErrNotFound, "user")
+		}
+		return nil, errors.Wrap(err, "fetch user")
+	}
+
+	result := &User{}
+	if err := json.Unmarshal(b, result); err != nil {
+		return nil, errors.Wrap(err, "unmarshal user")
+	}
+
+	return result, nil
+}
+
+// fetchUserByIndex returns the user whose id is stored at the index path.
+func (s *Server) fetchUserByIndex(path string) (*User, error) {
+	b, err := s.storage.Fetch(s.ctx, path)
+	if err != nil {
+		if errors.Cause(err) == ErrNotFound {
+			return nil, errors.Wrap(ErrNotFound, "index")
+		}
+		return nil, errors.Wrap(err, "fetch index")
+	}
+
+	id, err := uuid.ParseBytes(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse user id")
+	}
+
+	return s.fetchUser(id)
+}
+
+// fetchUserByXPubs returns the user that registered the xpubs, with the registration.
+func (s *Server) fetchUserByXPubs(xpubs bitcoin.ExtendedKeys) (*User, *UserXPubs, error) {
+	user, err := s.fetchUserByIndex(xpubsPath(xpubs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, registered := range user.XPubs {
+		if registered.XPubs.Equal(xpubs) {
+			return user, &user.XPubs[i], nil
+		}
+	}
+
+	return nil, nil, errors.Wrap(ErrNotFound, "xpubs")
+}
+
+// saveAddress records the user that an address was approved for.
+func (s *Server) saveAddress(address bitcoin.RawAddress, user *User) error {
+	return s.storage.Put(s.ctx, addressPath(address), []byte(user.ID.String()))
+}
+
+func xpubsPath(xpubs bitcoin.ExtendedKeys) string {
+	hash := sha256.Sum256(xpubs.Bytes())
+	return fmt.Sprintf("%s/xpubs/%s", serverStorageKey, hex.EncodeToString(hash[:]))
+}
+
+func addressPath(address bitcoin.RawAddress) string {
+	return fmt.Sprintf("%s/addresses/%x", serverStorageKey, address.Bytes())
+}
+
+// deriveAddress returns the address at the index of registered xpubs.
+func deriveAddress(xpubs *UserXPubs, index uint32) (bitcoin.RawAddress, error) {
+	keys, err := xpubs.XPubs.ChildKeys(index)
+	if err != nil {
+		return bitcoin.RawAddress{}, errors.Wrap(err, "generate keys")
+	}
+
+	result, err := keys.RawAddress(xpubs.RequiredSigners)
+	if err != nil {
+		return bitcoin.RawAddress{}, errors.Wrap(err, "generate address")
+	}
+
+	return result, nil
+}
+
+func entitiesMatch(l, r *actions.EntityField) (bool, error) {
+	var lb, rb bytes.Buffer
+	if err := l.WriteDeterministic(&lb); err != nil {
+		return false, errors.Wrap(err, "write entity")
+	}
+	if err := r.WriteDeterministic(&rb); err != nil {
+		return false, errors.Wrap(err, "write entity")
+	}
+	return bytes.Equal(lb.Bytes(), rb.Bytes()), nil
+}
+
+func approvedFlag(approved bool) uint8 {
+	if approved {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,225 @@
+package identity
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/specification/dist/golang/actions"
+	"github.com/tokenized/specification/dist/golang/assets"
+	"github.com/tokenized/specification/dist/golang/protocol"
+
+	"github.com/pkg/errors"
+)
+
+// testBlocks reports a fixed height as the tip of random block hashes.
+type testBlocks struct {
+	height int
+	hashes *RandBlockHashes
+}
+
+func (b *testBlocks) GetLatestBlock(ctx context.Context) (*bitcoin.Hash32, int32, error) {
+	hash, err := b.hashes.Hash(ctx, b.height)
+	return hash, int32(b.height), err
+}
+
+// testStorage keeps the server's users in memory.
+type testStorage struct {
+	values map[string][]byte
+	lock   sync.Mutex
+}
+
+func (s *testStorage) Fetch(ctx context.Context, key string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	value, exists := s.values[key]
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return value, nil
+}
+
+func (s *testStorage) Put(ctx context.Context, key string, value []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.values[key] = value
+	return nil
+}
+
+func TestServer(t *testing.T) {
+	ctx := context.Background()
+
+	storage := &testStorage{values: make(map[string][]byte)}
+
+	oracleKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate oracle key : %s", err)
+	}
+	oracleAddress, err := oracleKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create oracle address : %s", err)
+	}
+
+	blocks := &testBlocks{height: 1200, hashes: NewRandBlockHashes()}
+	server := httptest.NewServer(NewServer(ctx, oracleAddress, oracleKey, storage, blocks))
+	defer server.Close()
+
+	client, err := GetHTTPClient(ctx, server.URL)
+	if err != nil {
+		t.Fatalf("Failed to get oracle : %s", err)
+	}
+	if !client.ContractAddress.Equal(oracleAddress) ||
+		!client.PublicKey.Equal(oracleKey.PublicKey()) {
+		t.Fatalf("Wrong oracle id")
+	}
+
+	clientKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate client key : %s", err)
+	}
+	client.SetClientKey(clientKey)
+
+	xkey, err := bitcoin.GenerateMasterExtendedKey()
+	if err != nil {
+		t.Fatalf("Failed to generate xkey : %s", err)
+	}
+	xpubs := bitcoin.ExtendedKeys{xkey}.ExtendedPublicKeys()
+
+	entity := actions.EntityField{Name: "Test"}
+
+	userID, err := client.RegisterUser(ctx, entity, []bitcoin.ExtendedKeys{xpubs})
+	if err != nil {
+		t.Fatalf("Failed to register user : %s", err)
+	}
+
+	if err := client.RegisterXPub(ctx, "m/0", xpubs, 1); err != nil {
+		t.Fatalf("Failed to register xpub : %s", err)
+	}
+
+	// The registered xpubs find the same user.
+	existingID, err := client.RegisterUser(ctx, entity, []bitcoin.ExtendedKeys{xpubs})
+	if err != nil {
+		t.Fatalf("Failed to find user : %s", err)
+	}
+	if *existingID != *userID {
+		t.Fatalf("Wrong user id : got %s, want %s", existingID, userID)
+	}
+
+	// Receive
+	contractKey, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate contract key : %s", err)
+	}
+	contractAddress, err := contractKey.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create contract address : %s", err)
+	}
+	contract := bitcoin.NewAddressFromRawAddress(contractAddress, bitcoin.MainNet).String()
+	assetCode := protocol.AssetCodeFromContract(contractAddress, 0)
+	asset := protocol.AssetID(assets.CodeCurrency, *assetCode)
+
+	receiver, _, err := client.ApproveReceive(ctx, contract, asset, 0, 3, xpubs, 5, 1)
+	if err != nil {
+		t.Fatalf("Failed to approve receive : %s", err)
+	}
+
+	if err := ValidateReceive(ctx, client.PublicKey, blocks.hashes, contract, asset,
+		receiver); err != nil {
+		t.Fatalf("Failed to validate receive : %s", err)
+	}
+
+	receiveAddress, err := bitcoin.DecodeRawAddress(receiver.Address)
+	if err != nil {
+		t.Fatalf("Failed to decode receive address : %s", err)
+	}
+	name, err := client.EntityName(ctx, receiveAddress)
+	if err != nil {
+		t.Fatalf("Failed to get entity : %s", err)
+	}
+	if name != entity.Name {
+		t.Fatalf("Wrong entity name : got %s, want %s", name, entity.Name)
+	}
+
+	// Unknown addresses have no name.
+	name, err = client.EntityName(ctx, contractAddress)
+	if err != nil {
+		t.Fatalf("Failed to get unknown entity : %s", err)
+	}
+	if len(name) != 0 {
+		t.Fatalf("Wrong unknown entity name : %s", name)
+	}
+
+	// Unregistered xpubs
+	otherKey, err := bitcoin.GenerateMasterExtendedKey()
+	if err != nil {
+		t.Fatalf("Failed to generate xkey : %s", err)
+	}
+	otherXPubs := bitcoin.ExtendedKeys{otherKey}.ExtendedPublicKeys()
+	if _, _, err := client.ApproveReceive(ctx, contract, asset, 0, 3, otherXPubs, 0,
+		1); errors.Cause(err) != ErrNotFound {
+		t.Fatalf("Unregistered xpubs not rejected : %v", err)
+	}
+
+	// Entity public key
+	approved, err := client.ApproveEntityPublicKey(ctx, entity, xpubs[0], 2)
+	if err != nil {
+		t.Fatalf("Failed to approve entity public key : %s", err)
+	}
+	if err := client.ValidateEntityPublicKey(ctx, blocks.hashes, &entity, *approved); err != nil {
+		t.Fatalf("Failed to validate entity public key : %s", err)
+	}
+
+	if _, err := client.ApproveEntityPublicKey(ctx, actions.EntityField{Name: "Other"}, xpubs[0],
+		2); errors.Cause(err) != ErrNotApproved {
+		t.Fatalf("Wrong entity not rejected : %v", err)
+	}
+
+	// Admin identity certificate
+	adminKeys, err := xpubs.ChildKeys(7)
+	if err != nil {
+		t.Fatalf("Failed to generate admin keys : %s", err)
+	}
+	adminAddress, err := adminKeys.RawAddress(1)
+	if err != nil {
+		t.Fatalf("Failed to generate admin address : %s", err)
+	}
+
+	certificate, err := client.AdminIdentityCertificate(ctx, entity, bitcoin.RawAddress{}, xpubs,
+		7, 1)
+	if err != nil {
+		t.Fatalf("Failed to get admin certificate : %s", err)
+	}
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, client.PublicKey, blocks.hashes,
+		adminAddress, entity, bitcoin.RawAddress{}, *certificate); err != nil {
+		t.Fatalf("Failed to validate admin certificate : %s", err)
+	}
+
+	certificate, err = client.AdminIdentityCertificate(ctx, entity, contractAddress, xpubs, 7, 1)
+	if err != nil {
+		t.Fatalf("Failed to get entity contract admin certificate : %s", err)
+	}
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, client.PublicKey, blocks.hashes,
+		adminAddress, entity, contractAddress, *certificate); err != nil {
+		t.Fatalf("Failed to validate entity contract admin certificate : %s", err)
+	}
+
+	// Updated identity
+	updated := actions.EntityField{Name: "Updated"}
+	if err := client.UpdateIdentity(ctx, updated); err != nil {
+		t.Fatalf("Failed to update identity : %s", err)
+	}
+
+	certificate, err = client.AdminIdentityCertificate(ctx, entity, bitcoin.RawAddress{}, xpubs,
+		7, 1)
+	if errors.Cause(err) != ErrNotApproved {
+		t.Fatalf("Stale issuer not rejected : %v", err)
+	}
+	if err := ValidateAdminIdentityCertificate(ctx, oracleAddress, client.PublicKey, blocks.hashes,
+		adminAddress, entity, bitcoin.RawAddress{}, *certificate); err != ErrNotApproved {
+		t.Fatalf("Not approved certificate invalid : %v", err)
+	}
+}
@@ -297,12 +297,7 @@ func (c *MockClient) AdminIdentityCertificate(ctx context.Context, issuer action
 		return nil, errors.Wrap(err, "get sig block hash")
 	}
 
-	var entity interface{}
-	if entityContract.IsEmpty() {
-		entity = issuer
-	} else {
-		entity = entityContract
-	}
+	entity := adminCertificateEntity(&issuer, entityContract)
 
 	sigHash, err := protocol.ContractAdminIdentityOracleSigHash(ctx, adminAddress, entity,
 		*blockHash, 0, 1)
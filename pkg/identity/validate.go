@@ -31,12 +31,7 @@ func ValidateAdminIdentityCertificate(ctx context.Context,
 		return errors.Wrap(err, "block hash")
 	}
 
-	var entity interface{}
-	if contract.IsEmpty() {
-		entity = issuer
-	} else {
-		entity = contract
-	}
+	entity := adminCertificateEntity(&issuer, contract)
 
 	sigHash, err := protocol.ContractAdminIdentityOracleSigHash(ctx, admin, entity, *blockHash,
 		data.Expiration, 1)
@@ -63,6 +58,18 @@ func ValidateAdminIdentityCertificate(ctx context.Context,
 	return errors.Wrap(ErrInvalidSignature, "validate signature")
 }
 
+// adminCertificateEntity returns the entity an admin certificate is signed for. That is the entity
+//   contract when there is one, otherwise the issuer. The issuer must be a pointer since the sig
+//   hash doesn't accept an EntityField value.
+func adminCertificateEntity(issuer *actions.EntityField,
+	contract bitcoin.RawAddress) interface{} {
+
+	if contract.IsEmpty() {
+		return issuer
+	}
+	return contract
+}
+
 // ValidateReceive checks the validity of an identity oracle signature for a receive.
 func (o *HTTPClient) ValidateEntityPublicKey(ctx context.Context, blocks BlockHashes,
 	entity *actions.EntityField, data ApprovedEntityPublicKey) error {
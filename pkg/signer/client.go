@@ -0,0 +1,170 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"sync"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+// Client signs txs by sending them to a signer server. It implements wallet.Signer.
+type Client struct {
+	network string
+	address string
+
+	lock   sync.Mutex
+	client *rpc.Client
+}
+
+// NewClient returns a client for the signer at the address. See ParseAddress for the format. The
+//   connection is made on first use and remade if it is lost.
+func NewClient(address string) *Client {
+	network, address := ParseAddress(address)
+	return &Client{
+		network: network,
+		address: address,
+	}
+}
+
+// Sign sends the tx to the signer to adjust the fee and sign all inputs.
+func (c *Client) Sign(ctx context.Context, tx *txbuilder.TxBuilder,
+	address bitcoin.RawAddress) error {
+
+	response, err := c.sign(ctx, tx, address, false)
+	if err != nil {
+		return err
+	}
+
+	msg := &wire.MsgTx{}
+	if err := msg.Deserialize(bytes.NewReader(response.Tx)); err != nil {
+		return errors.Wrap(err, "deserialize tx")
+	}
+
+	tx.MsgTx = msg
+	tx.Outputs = response.Outputs
+	return nil
+}
+
+// SignInputs sends the tx to the signer to sign the inputs that spend from the address.
+func (c *Client) SignInputs(ctx context.Context, tx *txbuilder.TxBuilder,
+	address bitcoin.RawAddress) ([]int, error) {
+
+	response, err := c.sign(ctx, tx, address, true)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &wire.MsgTx{}
+	if err := msg.Deserialize(bytes.NewReader(response.Tx)); err != nil {
+		return nil, errors.Wrap(err, "deserialize tx")
+	}
+
+	if len(msg.TxIn) != len(tx.MsgTx.TxIn) {
+		return nil, errors.New("Signer changed input count")
+	}
+
+	for _, index := range response.Signed {
+		if index < 0 || index >= len(msg.TxIn) {
+			return nil, errors.New("Signer signed input out of range")
+		}
+		tx.MsgTx.TxIn[index].SignatureScript = msg.TxIn[index].SignatureScript
+	}
+
+	return response.Signed, nil
+}
+
+// PublicKeys returns the public keys held by the signer.
+func (c *Client) PublicKeys(ctx context.Context) ([]bitcoin.PublicKey, error) {
+	var response PublicKeysResponse
+	if err := c.call(ctx, "PublicKeys", &PublicKeysRequest{}, &response); err != nil {
+		return nil, err
+	}
+
+	return response.PublicKeys, nil
+}
+
+// Close closes the connection to the signer.
+func (c *Client) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client == nil {
+		return nil
+	}
+
+	err := c.client.Close()
+	c.client = nil
+	return err
+}
+
+func (c *Client) sign(ctx context.Context, tx *txbuilder.TxBuilder, address bitcoin.RawAddress,
+	inputsOnly bool) (*SignResponse, error) {
+
+	request, err := newSignRequest(tx, address, inputsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	var response SignResponse
+	if err := c.call(ctx, "Sign", request, &response); err != nil {
+		return nil, err
+	}
+
+	if err := responseError(&response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// call makes an RPC call, reconnecting once if the connection was lost.
+func (c *Client) call(ctx context.Context, method string, request, response interface{}) error {
+	err := c.callOnce(ctx, method, request, response)
+	if err == rpc.ErrShutdown {
+		c.Close()
+		err = c.callOnce(ctx, method, request, response)
+	}
+	return err
+}
+
+func (c *Client) callOnce(ctx context.Context, method string, request,
+	response interface{}) error {
+
+	client, err := c.connect()
+	if err != nil {
+		return err
+	}
+
+	call := client.Go(ServiceName+"."+method, request, response, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Client) connect() (*rpc.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.client != nil {
+		return c.client, nil
+	}
+
+	conn, err := net.Dial(c.network, c.address)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial signer")
+	}
+
+	c.client = jsonrpc.NewClient(conn)
+	return c.client, nil
+}
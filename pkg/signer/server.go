@@ -0,0 +1,119 @@
+package signer
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/logger"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+)
+
+// Server signs txs for the keys in a wallet, for clients connected over a socket. Every tx is
+//   checked against the policy before it is returned.
+type Server struct {
+	wallet *wallet.Wallet
+	policy Policy
+}
+
+// NewServer returns a server that signs with the keys in the wallet.
+func NewServer(w *wallet.Wallet, policy Policy) *Server {
+	return &Server{
+		wallet: w,
+		policy: policy,
+	}
+}
+
+// Serve accepts connections on the listener until it is closed.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	server := rpc.NewServer()
+	if err := server.RegisterName(ServiceName, &service{ctx: ctx, server: s}); err != nil {
+		return errors.Wrap(err, "register service")
+	}
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		logger.Info(ctx, "Signer client connected : %s", conn.RemoteAddr())
+		go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
+
+func (s *Server) isHeld(address bitcoin.RawAddress) bool {
+	_, err := s.wallet.Get(address)
+	return err == nil
+}
+
+func (s *Server) sign(ctx context.Context, request *SignRequest) (*SignResponse, error) {
+	if !s.isHeld(request.Address) {
+		return nil, ErrUnknownAddress
+	}
+
+	tx, err := request.txBuilder()
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidRequest, err.Error())
+	}
+
+	result := &SignResponse{}
+	if request.InputsOnly {
+		// The tx isn't modified so check it before signing.
+		if err := s.policy.Check(tx.MsgTx, s.isHeld); err != nil {
+			return nil, err
+		}
+
+		result.Signed, err = s.wallet.SignInputs(ctx, tx, request.Address)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if err := s.wallet.Sign(ctx, tx, request.Address); err != nil {
+			return nil, err
+		}
+
+		// Fee adjustment can change outputs so check the final tx.
+		if err := s.policy.Check(tx.MsgTx, s.isHeld); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Tx, err = tx.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "serialize tx")
+	}
+	result.Outputs = tx.Outputs
+
+	return result, nil
+}
+
+// service is the RPC receiver. Its methods must match the net/rpc method form.
+type service struct {
+	ctx    context.Context
+	server *Server
+}
+
+func (s *service) Sign(request *SignRequest, response *SignResponse) error {
+	result, err := s.server.sign(s.ctx, request)
+	if err != nil {
+		logger.Warn(s.ctx, "Refused to sign : %s", err)
+		response.Code = errorCode(err)
+		response.Message = err.Error()
+		return nil
+	}
+
+	*response = *result
+	return nil
+}
+
+func (s *service) PublicKeys(request *PublicKeysRequest, response *PublicKeysResponse) error {
+	for _, key := range s.server.wallet.ListAll() {
+		response.PublicKeys = append(response.PublicKeys, key.PublicKey())
+	}
+	return nil
+}
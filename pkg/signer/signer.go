@@ -0,0 +1,213 @@
+package signer
+
+/**
+ * Remote Signer
+ *
+ * What is my purpose?
+ * - You hold contract keys outside of the daemon
+ * - You sign txs that the daemon builds, if they pass policy
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/pkg/wire"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ServiceName is the name of the RPC service.
+	ServiceName = "Signer"
+
+	// Error codes returned in responses. They are converted back to errors by the client so callers
+	//   can check them with errors.Cause.
+	codeOK                = uint8(0)
+	codeInsufficientValue = uint8(1)
+	codeUnknownAddress    = uint8(2)
+	codePolicy            = uint8(3)
+	codeInvalid           = uint8(4)
+)
+
+var (
+	// ErrPolicy occurs when the signer refuses to sign a tx because it doesn't meet the policy.
+	ErrPolicy = errors.New("Signer policy violation")
+
+	// ErrUnknownAddress occurs when the signer doesn't hold the key for an address.
+	ErrUnknownAddress = errors.New("Signer doesn't hold key")
+
+	// ErrInvalidRequest occurs when the signer can't process a request.
+	ErrInvalidRequest = errors.New("Invalid signer request")
+)
+
+// SignRequest is a request to sign a tx for an address. The tx is sent unsigned, with the data
+//   needed to calculate signature hashes and adjust the fee.
+type SignRequest struct {
+	Address       bitcoin.RawAddress            `json:"address"`
+	Tx            []byte                        `json:"tx"`
+	Inputs        []*txbuilder.InputSupplement  `json:"inputs"`
+	Outputs       []*txbuilder.OutputSupplement `json:"outputs"`
+	ChangeAddress bitcoin.RawAddress            `json:"change_address"`
+	FeeRate       float32                       `json:"fee_rate"`
+	DustFeeRate   float32                       `json:"dust_fee_rate"`
+
+	// InputsOnly means only sign the inputs for the address and don't modify the tx.
+	InputsOnly bool `json:"inputs_only"`
+}
+
+// SignResponse is the result of a sign request.
+type SignResponse struct {
+	Tx      []byte                        `json:"tx"`
+	Outputs []*txbuilder.OutputSupplement `json:"outputs"`
+	Signed  []int                         `json:"signed"`
+	Code    uint8                         `json:"code"`
+	Message string                        `json:"message"`
+}
+
+// PublicKeysRequest is a request for the public keys held by the signer.
+type PublicKeysRequest struct{}
+
+// PublicKeysResponse contains the public keys held by the signer.
+type PublicKeysResponse struct {
+	PublicKeys []bitcoin.PublicKey `json:"public_keys"`
+}
+
+// Policy limits what the signer will sign. The zero value allows everything.
+type Policy struct {
+	// MaxValue is the maximum total value of outputs paying to addresses not held by the signer.
+	//   Zero means no limit.
+	MaxValue uint64
+
+	// AllowedAddresses are the addresses, other than those held by the signer, that outputs may
+	//   pay to. Empty means any address.
+	AllowedAddresses []bitcoin.RawAddress
+}
+
+// Check returns ErrPolicy if the tx doesn't meet the policy. isHeld returns true for addresses
+//   held by the signer.
+func (p *Policy) Check(tx *wire.MsgTx, isHeld func(bitcoin.RawAddress) bool) error {
+	value := uint64(0)
+	for i, output := range tx.TxOut {
+		address, err := bitcoin.RawAddressFromLockingScript(output.PkScript)
+		if err != nil {
+			if output.Value != 0 {
+				return errors.Wrapf(ErrPolicy, "output %d pays value to non-address script", i)
+			}
+			continue // Data output
+		}
+
+		if isHeld(address) {
+			continue
+		}
+
+		if !p.isAllowed(address) {
+			return errors.Wrapf(ErrPolicy, "output %d pays to address not allowed", i)
+		}
+
+		value += uint64(output.Value)
+	}
+
+	if p.MaxValue != 0 && value > p.MaxValue {
+		return errors.Wrapf(ErrPolicy, "value %d over max %d", value, p.MaxValue)
+	}
+
+	return nil
+}
+
+func (p *Policy) isAllowed(address bitcoin.RawAddress) bool {
+	if len(p.AllowedAddresses) == 0 {
+		return true
+	}
+
+	for _, allowed := range p.AllowedAddresses {
+		if allowed.Equal(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseAddress splits a signer address into a network and address for net.Dial and net.Listen.
+//   "unix:/path/to/socket" is a unix socket. Anything else is a tcp host:port.
+func ParseAddress(address string) (string, string) {
+	if strings.HasPrefix(address, "unix:") {
+		return "unix", strings.TrimPrefix(address, "unix:")
+	}
+	return "tcp", strings.TrimPrefix(address, "tcp:")
+}
+
+func newSignRequest(tx *txbuilder.TxBuilder, address bitcoin.RawAddress,
+	inputsOnly bool) (*SignRequest, error) {
+
+	var buf bytes.Buffer
+	if err := tx.MsgTx.Serialize(&buf); err != nil {
+		return nil, errors.Wrap(err, "serialize tx")
+	}
+
+	return &SignRequest{
+		Address:       address,
+		Tx:            buf.Bytes(),
+		Inputs:        tx.Inputs,
+		Outputs:       tx.Outputs,
+		ChangeAddress: tx.ChangeAddress,
+		FeeRate:       tx.FeeRate,
+		DustFeeRate:   tx.DustFeeRate,
+		InputsOnly:    inputsOnly,
+	}, nil
+}
+
+func (r *SignRequest) txBuilder() (*txbuilder.TxBuilder, error) {
+	msg := &wire.MsgTx{}
+	if err := msg.Deserialize(bytes.NewReader(r.Tx)); err != nil {
+		return nil, errors.Wrap(err, "deserialize tx")
+	}
+
+	if len(r.Inputs) != len(msg.TxIn) || len(r.Outputs) != len(msg.TxOut) {
+		return nil, fmt.Errorf("Supplement counts don't match tx : inputs %d/%d, outputs %d/%d",
+			len(r.Inputs), len(msg.TxIn), len(r.Outputs), len(msg.TxOut))
+	}
+
+	return &txbuilder.TxBuilder{
+		MsgTx:         msg,
+		Inputs:        r.Inputs,
+		Outputs:       r.Outputs,
+		ChangeAddress: r.ChangeAddress,
+		FeeRate:       r.FeeRate,
+		DustFeeRate:   r.DustFeeRate,
+	}, nil
+}
+
+// responseError converts the code in a response back to an error.
+func responseError(response *SignResponse) error {
+	switch response.Code {
+	case codeOK:
+		return nil
+	case codeInsufficientValue:
+		return errors.Wrap(txbuilder.ErrInsufficientValue, response.Message)
+	case codeUnknownAddress:
+		return errors.Wrap(ErrUnknownAddress, response.Message)
+	case codePolicy:
+		return errors.Wrap(ErrPolicy, response.Message)
+	default:
+		return errors.Wrap(ErrInvalidRequest, response.Message)
+	}
+}
+
+// errorCode converts an error to a code for a response.
+func errorCode(err error) uint8 {
+	switch errors.Cause(err) {
+	case txbuilder.ErrInsufficientValue:
+		return codeInsufficientValue
+	case ErrUnknownAddress:
+		return codeUnknownAddress
+	case ErrPolicy:
+		return codePolicy
+	default:
+		return codeInvalid
+	}
+}
@@ -0,0 +1,138 @@
+package signer
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+	"github.com/tokenized/smart-contract/pkg/wallet"
+
+	"github.com/pkg/errors"
+)
+
+func TestRemoteSign(t *testing.T) {
+	ctx := context.Background()
+
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+	contractKey := wallet.NewKey(key)
+
+	allowed := randomAddress(t)
+	other := randomAddress(t)
+
+	w := wallet.New()
+	if err := w.Add(contractKey); err != nil {
+		t.Fatalf("Failed to add key : %s", err)
+	}
+
+	dir, err := ioutil.TempDir("", "signer")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir : %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "signer.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen : %s", err)
+	}
+	defer listener.Close()
+
+	server := NewServer(w, Policy{
+		MaxValue:         10000,
+		AllowedAddresses: []bitcoin.RawAddress{allowed},
+	})
+	go server.Serve(ctx, listener)
+
+	client := NewClient("unix:" + socket)
+	defer client.Close()
+
+	publicKeys, err := client.PublicKeys(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get public keys : %s", err)
+	}
+	if len(publicKeys) != 1 || !publicKeys[0].Equal(key.PublicKey()) {
+		t.Fatalf("Wrong public keys : %v", publicKeys)
+	}
+
+	tests := []struct {
+		name       string
+		payTo      bitcoin.RawAddress
+		value      uint64
+		inputsOnly bool
+		err        error
+	}{
+		{"allowed", allowed, 5000, false, nil},
+		{"allowed inputs only", allowed, 5000, true, nil},
+		{"over max value", allowed, 20000, false, ErrPolicy},
+		{"address not allowed", other, 5000, false, ErrPolicy},
+		{"address not allowed inputs only", other, 5000, true, ErrPolicy},
+		{"insufficient value", allowed, 100000, false, txbuilder.ErrInsufficientValue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := txbuilder.NewTxBuilder(1.0, 1.0)
+			tx.SetChangeAddress(contractKey.Address, "")
+
+			lockingScript, err := contractKey.Address.LockingScript()
+			if err != nil {
+				t.Fatalf("Failed to create locking script : %s", err)
+			}
+			if err := tx.AddInputUTXO(bitcoin.UTXO{
+				Index:         1,
+				Value:         50000,
+				LockingScript: lockingScript,
+			}); err != nil {
+				t.Fatalf("Failed to add input : %s", err)
+			}
+			if err := tx.AddPaymentOutput(tt.payTo, tt.value, false); err != nil {
+				t.Fatalf("Failed to add output : %s", err)
+			}
+
+			if tt.inputsOnly {
+				var signed []int
+				signed, err = client.SignInputs(ctx, tx, contractKey.Address)
+				if err == nil && len(signed) != 1 {
+					t.Fatalf("Wrong signed inputs : %v", signed)
+				}
+			} else {
+				err = client.Sign(ctx, tx, contractKey.Address)
+			}
+
+			if errors.Cause(err) != tt.err {
+				t.Fatalf("Wrong error : got %v, want %v", err, tt.err)
+			}
+			if err == nil && !tx.AllInputsAreSigned() {
+				t.Fatalf("Inputs not signed")
+			}
+		})
+	}
+
+	// Keys not held by the signer are refused.
+	tx := txbuilder.NewTxBuilder(1.0, 1.0)
+	if err := client.Sign(ctx, tx, other); errors.Cause(err) != ErrUnknownAddress {
+		t.Fatalf("Wrong error for unknown address : %v", err)
+	}
+}
+
+func randomAddress(t *testing.T) bitcoin.RawAddress {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	address, err := key.RawAddress()
+	if err != nil {
+		t.Fatalf("Failed to create address : %s", err)
+	}
+
+	return address
+}
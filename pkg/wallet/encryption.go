@@ -0,0 +1,174 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version of the passphrase encryption format.
+	passphraseVersion = uint8(1)
+
+	// Version of the key encryption format.
+	keyVersion = uint8(1)
+
+	// KeyEncryptionKeySize is the size of a key used with EncryptWithKey.
+	KeyEncryptionKeySize = 32
+
+	saltSize = 16
+
+	// scrypt parameters
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+var (
+	// ErrWrongPassphrase occurs when data can't be decrypted with a passphrase.
+	ErrWrongPassphrase = errors.New("Wrong passphrase or corrupt data")
+
+	// ErrWrongKey occurs when data can't be decrypted with a key encryption key.
+	ErrWrongKey = errors.New("Wrong key or corrupt data")
+
+	// ErrUnknownEncryption occurs when encrypted data has an unsupported version.
+	ErrUnknownEncryption = errors.New("Unknown encryption version")
+)
+
+// EncryptWithPassphrase encrypts data with AES-GCM using a key derived from the passphrase with
+//   scrypt. The output starts with a version and the random salt and nonce.
+func EncryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "rand salt")
+	}
+
+	aead, err := passphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "rand nonce")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(passphraseVersion)
+	buf.Write(salt)
+	buf.Write(nonce)
+	buf.Write(aead.Seal(nil, nonce, data, []byte{passphraseVersion}))
+	return buf.Bytes(), nil
+}
+
+// DecryptWithPassphrase decrypts data encrypted by EncryptWithPassphrase.
+func DecryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrWrongPassphrase
+	}
+	if data[0] != passphraseVersion {
+		return nil, errors.Wrapf(ErrUnknownEncryption, "version %d", data[0])
+	}
+	if len(data) < 1+saltSize {
+		return nil, ErrWrongPassphrase
+	}
+
+	aead, err := passphraseCipher(passphrase, data[1:1+saltSize])
+	if err != nil {
+		return nil, err
+	}
+
+	offset := 1 + saltSize
+	if len(data) < offset+aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	nonce := data[offset : offset+aead.NonceSize()]
+	offset += aead.NonceSize()
+
+	result, err := aead.Open(nil, nonce, data[offset:], []byte{passphraseVersion})
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return result, nil
+}
+
+// EncryptWithKey encrypts data with AES-GCM using a 32 byte key encryption key. The output starts
+//   with a version and the random nonce.
+func EncryptWithKey(data, key []byte) ([]byte, error) {
+	aead, err := keyCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrap(err, "rand nonce")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(keyVersion)
+	buf.Write(nonce)
+	buf.Write(aead.Seal(nil, nonce, data, []byte{keyVersion}))
+	return buf.Bytes(), nil
+}
+
+// DecryptWithKey decrypts data encrypted by EncryptWithKey.
+func DecryptWithKey(data, key []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrWrongKey
+	}
+	if data[0] != keyVersion {
+		return nil, errors.Wrapf(ErrUnknownEncryption, "version %d", data[0])
+	}
+
+	aead, err := keyCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < 1+aead.NonceSize() {
+		return nil, ErrWrongKey
+	}
+	nonce := data[1 : 1+aead.NonceSize()]
+
+	result, err := aead.Open(nil, nonce, data[1+aead.NonceSize():], []byte{keyVersion})
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+
+	return result, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, scryptKeyLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "derive key")
+	}
+
+	return keyCipher(key)
+}
+
+func keyCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeyEncryptionKeySize {
+		return nil, fmt.Errorf("Key encryption key must be %d bytes", KeyEncryptionKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "new cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "new gcm")
+	}
+
+	return aead, nil
+}
@@ -3,13 +3,22 @@ package wallet
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 
 	"github.com/tokenized/pkg/bitcoin"
 )
 
+var (
+	// ErrNoPrivateKey occurs when a key held by a remote signer is used to sign locally.
+	ErrNoPrivateKey = errors.New("Private key not held by wallet")
+)
+
 type Key struct {
 	Address bitcoin.RawAddress
 	Key     bitcoin.Key
+
+	// publicKey is set for keys held by a remote signer, when Key is empty.
+	publicKey bitcoin.PublicKey
 }
 
 func NewKey(key bitcoin.Key) *Key {
@@ -21,6 +30,29 @@ func NewKey(key bitcoin.Key) *Key {
 	return &result
 }
 
+// NewPublicKey returns a key for an address whose private key is held by a remote signer.
+func NewPublicKey(publicKey bitcoin.PublicKey) *Key {
+	result := Key{
+		publicKey: publicKey,
+	}
+
+	result.Address, _ = publicKey.RawAddress()
+	return &result
+}
+
+// PublicKey returns the public key for the address.
+func (rk *Key) PublicKey() bitcoin.PublicKey {
+	if rk.Key.IsEmpty() {
+		return rk.publicKey
+	}
+	return rk.Key.PublicKey()
+}
+
+// HasPrivateKey returns true if the private key is held by the wallet.
+func (rk *Key) HasPrivateKey() bool {
+	return !rk.Key.IsEmpty()
+}
+
 func (rk *Key) Read(buf *bytes.Reader, net bitcoin.Network) error {
 	var length uint8
 	if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
@@ -32,6 +64,18 @@ func (rk *Key) Read(buf *bytes.Reader, net bitcoin.Network) error {
 		return err
 	}
 
+	// Compressed public keys start with 0x02 or 0x03.
+	if length > 0 && (data[0] == 0x02 || data[0] == 0x03) {
+		var err error
+		rk.publicKey, err = bitcoin.PublicKeyFromBytes(data)
+		if err != nil {
+			return err
+		}
+
+		rk.Address, _ = rk.publicKey.RawAddress()
+		return nil
+	}
+
 	var err error
 	rk.Key, err = bitcoin.KeyFromBytes(data, net)
 	if err != nil {
@@ -44,6 +88,9 @@ func (rk *Key) Read(buf *bytes.Reader, net bitcoin.Network) error {
 
 func (rk *Key) Write(buf *bytes.Buffer) error {
 	b := rk.Key.Bytes()
+	if !rk.HasPrivateKey() {
+		b = rk.publicKey.Bytes()
+	}
 	binary.Write(buf, binary.LittleEndian, uint8(len(b)))
 	_, err := buf.Write(b)
 	return err
@@ -24,7 +24,7 @@ func NewKeyStore() *KeyStore {
 }
 
 func (k KeyStore) Add(key *Key) error {
-	hash, err := bitcoin.NewHash20(bitcoin.Hash160(key.Key.PublicKey().Bytes()))
+	hash, err := bitcoin.NewHash20(bitcoin.Hash160(key.PublicKey().Bytes()))
 	if err != nil {
 		return err
 	}
@@ -33,7 +33,7 @@ func (k KeyStore) Add(key *Key) error {
 }
 
 func (k KeyStore) Remove(key *Key) error {
-	hash, err := bitcoin.NewHash20(bitcoin.Hash160(key.Key.PublicKey().Bytes()))
+	hash, err := bitcoin.NewHash20(bitcoin.Hash160(key.PublicKey().Bytes()))
 	if err != nil {
 		return err
 	}
@@ -106,7 +106,7 @@ func (k *KeyStore) Deserialize(buf *bytes.Reader) error {
 			return err
 		}
 
-		hash, err := bitcoin.NewHash20(bitcoin.Hash160(newKey.Key.PublicKey().Bytes()))
+		hash, err := bitcoin.NewHash20(bitcoin.Hash160(newKey.PublicKey().Bytes()))
 		if err != nil {
 			return err
 		}
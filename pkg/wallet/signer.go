@@ -0,0 +1,72 @@
+package wallet
+
+import (
+	"context"
+
+	"github.com/tokenized/pkg/bitcoin"
+	"github.com/tokenized/pkg/txbuilder"
+
+	"github.com/pkg/errors"
+)
+
+// Signer signs txs for contract addresses. The wallet signs with private keys held in memory. A
+//   remote signer holds them in another process so they never enter the daemon.
+type Signer interface {
+	// Sign adjusts the fee and signs all inputs of the tx, like txbuilder.Sign. The inputs must
+	//   spend from the address.
+	Sign(ctx context.Context, tx *txbuilder.TxBuilder, address bitcoin.RawAddress) error
+
+	// SignInputs signs the inputs of the tx that spend from the address without modifying anything
+	//   else, and returns their indexes. It is used when other parties sign the remaining inputs.
+	SignInputs(ctx context.Context, tx *txbuilder.TxBuilder, address bitcoin.RawAddress) ([]int, error)
+}
+
+// Sign adjusts the fee and signs all inputs of the tx with the key for the address.
+func (w *Wallet) Sign(ctx context.Context, tx *txbuilder.TxBuilder,
+	address bitcoin.RawAddress) error {
+
+	key, err := w.privateKey(address)
+	if err != nil {
+		return err
+	}
+
+	return tx.Sign([]bitcoin.Key{key})
+}
+
+// SignInputs signs the inputs of the tx that spend from the address with the key for the address.
+func (w *Wallet) SignInputs(ctx context.Context, tx *txbuilder.TxBuilder,
+	address bitcoin.RawAddress) ([]int, error) {
+
+	key, err := w.privateKey(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []int
+	var hashCache txbuilder.SigHashCache
+	for i, _ := range tx.Inputs {
+		err := tx.SignP2PKHInput(i, key, &hashCache)
+		if errors.Cause(err) == txbuilder.ErrWrongPrivateKey {
+			continue
+		}
+		if err != nil {
+			return result, errors.Wrapf(err, "sign input %d", i)
+		}
+		result = append(result, i)
+	}
+
+	return result, nil
+}
+
+func (w *Wallet) privateKey(address bitcoin.RawAddress) (bitcoin.Key, error) {
+	key, err := w.Get(address)
+	if err != nil {
+		return bitcoin.Key{}, err
+	}
+
+	if !key.HasPrivateKey() {
+		return bitcoin.Key{}, ErrNoPrivateKey
+	}
+
+	return key.Key, nil
+}
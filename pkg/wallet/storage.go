@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Version of the stored wallet format.
+	storageVersion = uint8(1)
+
+	// Encryption methods for stored wallets.
+	methodPassphrase = uint8(1)
+	methodKey        = uint8(2)
+)
+
+var (
+	// storageMagic is at the start of every encrypted stored wallet. A plain serialized wallet starts
+	//   with a little endian key count, which won't match.
+	storageMagic = []byte("TKWE")
+
+	// ErrNotEncrypted occurs when encryption is configured but the stored wallet is plain text.
+	ErrNotEncrypted = errors.New("Stored wallet is not encrypted. Encrypt it with the wallet-rekey command")
+
+	// ErrNoProtection occurs when the stored wallet is encrypted but no passphrase or key file is
+	//   configured.
+	ErrNoProtection = errors.New("Stored wallet is encrypted. Configure a wallet passphrase or key file")
+
+	// ErrWrongMethod occurs when the stored wallet is encrypted with a different method than the one
+	//   configured.
+	ErrWrongMethod = errors.New("Stored wallet is encrypted with a different method")
+)
+
+// Protection specifies how a wallet is encrypted in storage. Set either the passphrase or the key
+//   encryption key. A zero value means the wallet is stored as plain text.
+type Protection struct {
+	Passphrase string
+
+	// KeyEncryptionKey is a 32 byte key, usually loaded with LoadKeyEncryptionKey.
+	KeyEncryptionKey []byte
+}
+
+// IsEnabled returns true if the protection encrypts wallets.
+func (p *Protection) IsEnabled() bool {
+	return p != nil && (len(p.Passphrase) > 0 || len(p.KeyEncryptionKey) > 0)
+}
+
+// LoadKeyEncryptionKey reads a key encryption key from a file. The file must contain either 32
+//   raw bytes or 64 hex characters.
+func LoadKeyEncryptionKey(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read key file")
+	}
+
+	if len(data) == KeyEncryptionKeySize {
+		return data, nil
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(key) != KeyEncryptionKeySize {
+		return nil, fmt.Errorf("Key file must contain %d bytes or %d hex characters",
+			KeyEncryptionKeySize, KeyEncryptionKeySize*2)
+	}
+
+	return key, nil
+}
+
+// IsEncrypted returns true if stored wallet data is encrypted.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, storageMagic)
+}
+
+// Seal encrypts serialized wallet data for storage. The output starts with a header containing the
+//   format version and encryption method. If the protection isn't enabled the data is returned
+//   unchanged.
+func Seal(data []byte, p *Protection) ([]byte, error) {
+	if !p.IsEnabled() {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	buf.Write(storageMagic)
+	buf.WriteByte(storageVersion)
+
+	var encrypted []byte
+	var err error
+	if len(p.KeyEncryptionKey) > 0 {
+		buf.WriteByte(methodKey)
+		encrypted, err = EncryptWithKey(data, p.KeyEncryptionKey)
+	} else {
+		buf.WriteByte(methodPassphrase)
+		encrypted, err = EncryptWithPassphrase(data, p.Passphrase)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "encrypt")
+	}
+
+	buf.Write(encrypted)
+	return buf.Bytes(), nil
+}
+
+// Unseal decrypts stored wallet data written by Seal. It fails rather than returning anything
+//   when the stored data doesn't match the protection, so a misconfigured daemon doesn't start
+//   with an empty or unprotected wallet.
+func Unseal(data []byte, p *Protection) ([]byte, error) {
+	if !IsEncrypted(data) {
+		if p.IsEnabled() {
+			return nil, ErrNotEncrypted
+		}
+		return data, nil // Legacy plain text
+	}
+
+	if !p.IsEnabled() {
+		return nil, ErrNoProtection
+	}
+
+	header := len(storageMagic) + 2
+	if len(data) < header {
+		return nil, errors.Wrap(ErrUnknownEncryption, "truncated header")
+	}
+
+	version := data[len(storageMagic)]
+	if version != storageVersion {
+		return nil, errors.Wrapf(ErrUnknownEncryption, "wallet version %d", version)
+	}
+
+	method := data[len(storageMagic)+1]
+	switch method {
+	case methodKey:
+		if len(p.KeyEncryptionKey) == 0 {
+			return nil, errors.Wrap(ErrWrongMethod, "key file required")
+		}
+		return DecryptWithKey(data[header:], p.KeyEncryptionKey)
+
+	case methodPassphrase:
+		if len(p.KeyEncryptionKey) > 0 {
+			return nil, errors.Wrap(ErrWrongMethod, "passphrase required")
+		}
+		return DecryptWithPassphrase(data[header:], p.Passphrase)
+
+	default:
+		return nil, errors.Wrapf(ErrUnknownEncryption, "wallet method %d", method)
+	}
+}
@@ -0,0 +1,80 @@
+package wallet
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tokenized/pkg/bitcoin"
+
+	"github.com/pkg/errors"
+)
+
+func TestSeal(t *testing.T) {
+	key, err := bitcoin.GenerateKey(bitcoin.MainNet)
+	if err != nil {
+		t.Fatalf("Failed to generate key : %s", err)
+	}
+
+	w := New()
+	if err := w.Add(NewKey(key)); err != nil {
+		t.Fatalf("Failed to add key : %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := w.Serialize(&buf); err != nil {
+		t.Fatalf("Failed to serialize wallet : %s", err)
+	}
+	plain := buf.Bytes()
+
+	kek := make([]byte, KeyEncryptionKeySize)
+	kek[0] = 1
+	otherKek := make([]byte, KeyEncryptionKeySize)
+
+	tests := []struct {
+		name   string
+		seal   *Protection
+		unseal *Protection
+		err    error
+	}{
+		{"plain text", &Protection{}, nil, nil},
+		{"passphrase", &Protection{Passphrase: "test"}, &Protection{Passphrase: "test"}, nil},
+		{"key", &Protection{KeyEncryptionKey: kek}, &Protection{KeyEncryptionKey: kek}, nil},
+		{"wrong passphrase", &Protection{Passphrase: "test"}, &Protection{Passphrase: "other"},
+			ErrWrongPassphrase},
+		{"wrong key", &Protection{KeyEncryptionKey: kek},
+			&Protection{KeyEncryptionKey: otherKek}, ErrWrongKey},
+		{"not configured", &Protection{Passphrase: "test"}, nil, ErrNoProtection},
+		{"not encrypted", nil, &Protection{Passphrase: "test"}, ErrNotEncrypted},
+		{"wrong method", &Protection{KeyEncryptionKey: kek}, &Protection{Passphrase: "test"},
+			ErrWrongMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := Seal(plain, tt.seal)
+			if err != nil {
+				t.Fatalf("Failed to seal : %s", err)
+			}
+
+			if tt.seal.IsEnabled() == bytes.Equal(sealed, plain) {
+				t.Fatalf("Wrong encryption state")
+			}
+
+			result, err := Unseal(sealed, tt.unseal)
+			if errors.Cause(err) != tt.err {
+				t.Fatalf("Wrong unseal error : got %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+
+			read := New()
+			if err := read.Deserialize(bytes.NewReader(result)); err != nil {
+				t.Fatalf("Failed to deserialize wallet : %s", err)
+			}
+			if len(read.ListAll()) != 1 {
+				t.Fatalf("Wrong key count : %d", len(read.ListAll()))
+			}
+		})
+	}
+}